@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/Classic-Homes/gitcells/pkg/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// rejectFileSuffix is appended to the patch path when saving rejected hunks
+const rejectFileSuffix = ".rej"
+
+func newApplyCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply <patch> <workbook|chunk-dir>",
+		Short: "Apply a GitCells patch to a workbook",
+		Long: `Apply a patch created with 'gitcells diff --format patch' to an Excel
+workbook or a chunk directory in .gitcells/data.
+
+Every change in the patch records the content it expects to replace. Changes
+that no longer match the target are rejected instead of overwriting newer
+edits. By default nothing is written if any change is rejected; use --reject
+to apply the rest and save the rejected hunks next to the patch.
+
+Examples:
+  gitcells diff old.xlsx fixed.xlsx --format patch -o fix.patch.json
+  gitcells apply fix.patch.json Budget.xlsx
+  gitcells apply fix.patch.json .gitcells/data/Budget.xlsx_chunks --dry-run
+  gitcells apply fix.patch.json Budget.xlsx --reject`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runApply(cmd, args, logger)
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Check whether the patch applies without writing anything")
+	cmd.Flags().Bool("reject", false, "Apply the changes that match and write rejected hunks to <patch>.rej")
+	cmd.Flags().StringP("output", "o", "", "Write the patched workbook to a different file (workbook targets only)")
+
+	return cmd
+}
+
+func runApply(cmd *cobra.Command, args []string, logger *logrus.Logger) error {
+	patchPath, target := args[0], args[1]
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	allowReject, _ := cmd.Flags().GetBool("reject")
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	patch, err := readPatch(patchPath)
+	if err != nil {
+		return err
+	}
+
+	chunkTarget := isDirectory(target)
+	if chunkTarget {
+		if !strings.HasSuffix(filepath.Clean(target), constants.ChunksDirSuffix) {
+			return utils.NewError(utils.ErrorTypeValidation, "apply", fmt.Sprintf("%s is not a chunk directory", target))
+		}
+		if outputPath != "" {
+			return utils.NewError(utils.ErrorTypeValidation, "apply", "--output is only supported for workbook targets")
+		}
+		if target, err = filepath.Abs(target); err != nil {
+			return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "apply", target, "failed to resolve path")
+		}
+	} else if !isExcelFile(target) {
+		return utils.NewError(utils.ErrorTypeValidation, "apply", fmt.Sprintf("unsupported target: %s", target))
+	}
+
+	conv := converter.NewConverter(logger)
+	options := converter.ConvertOptions{
+		PreserveFormulas: true,
+		PreserveStyles:   true,
+		PreserveComments: true,
+	}
+
+	var doc *models.ExcelDocument
+	if chunkTarget {
+		doc, err = conv.ReadChunks(target)
+	} else {
+		doc, err = conv.ExcelToJSON(target, options)
+	}
+	if err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeConverter, "apply", target, "failed to load target")
+	}
+
+	result := models.ApplyPatch(doc, patch)
+	printPatchResult(cmd.OutOrStdout(), target, result)
+
+	if result.HasRejections() && !allowReject {
+		return utils.NewError(utils.ErrorTypeValidation, "apply",
+			fmt.Sprintf("patch does not apply cleanly: %d change(s) rejected; use --reject to apply the rest", len(result.Rejected)))
+	}
+	if dryRun {
+		return nil
+	}
+
+	if result.HasRejections() {
+		rejectPath := patchPath + rejectFileSuffix
+		if err := writePatch(rejectPath, result.RejectedPatch()); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Rejected hunks written to %s\n", rejectPath)
+	}
+
+	if result.Applied == 0 {
+		return nil
+	}
+
+	if chunkTarget {
+		if _, err := conv.WriteChunks(doc, target, options); err != nil {
+			return utils.WrapFileError(err, utils.ErrorTypeConverter, "apply", target, "failed to write chunks")
+		}
+		return nil
+	}
+
+	if outputPath == "" {
+		outputPath = target
+	}
+	if err := conv.JSONToExcel(doc, outputPath, options); err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeConverter, "apply", outputPath, "failed to write workbook")
+	}
+	return nil
+}
+
+// readPatch loads and validates a patch file
+func readPatch(path string) (*models.Patch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "readPatch", path, "failed to read patch")
+	}
+
+	var patch models.Patch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeValidation, "readPatch", path, "invalid patch file")
+	}
+	if patch.Version != models.PatchFormatVersion {
+		return nil, utils.NewError(utils.ErrorTypeValidation, "readPatch",
+			fmt.Sprintf("unsupported patch version %q (expected %q)", patch.Version, models.PatchFormatVersion))
+	}
+
+	return &patch, nil
+}
+
+// writePatch saves a patch as indented JSON
+func writePatch(path string, patch *models.Patch) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "writePatch", path, "failed to create patch file")
+	}
+	defer file.Close()
+
+	return outputDiffJSON(file, patch)
+}
+
+// printPatchResult reports applied, skipped and rejected changes
+func printPatchResult(w io.Writer, target string, result *models.PatchResult) {
+	fmt.Fprintf(w, "%s: %s\n", target, result.String())
+	for _, rejection := range result.Rejected {
+		location := rejection.Sheet
+		if rejection.Cell != "" {
+			location += "!" + rejection.Cell
+		}
+		fmt.Fprintf(w, "  rejected %s: %s\n", location, rejection.Reason)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/Classic-Homes/gitcells/pkg/models"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newChangelogCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "changelog [paths...]",
+		Short: "Summarise workbook changes between revisions",
+		Long: `Summarise the workbook changes made by the commits between two revisions,
+grouped by workbook and sheet, with authors and counts of cell, formula
+and sheet changes.
+
+Every commit after --from up to and including --to that changed chunk data
+is compared with its parent. Merge commits are skipped; the commits they
+merge are included. Optional paths restrict the changelog to the given
+workbooks or directories.
+
+Examples:
+  gitcells changelog --from v1 --to v2
+  gitcells changelog --from snapshot/2026-02 --to snapshot/2026-03 -o CHANGELOG.md
+  gitcells changelog --from v1 --format json pricing/`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runChangelog(cmd, args, logger)
+		},
+	}
+
+	cmd.Flags().String("from", "", "Revision to start after (default: the first commit)")
+	cmd.Flags().String("to", "HEAD", "Last revision to include")
+	cmd.Flags().String("format", "markdown", "Output format: markdown, json")
+	cmd.Flags().StringP("output", "o", "", "Write output to a file instead of stdout")
+	cmd.Flags().Bool("ignore-recalculated", false, "Do not count cells whose formula is unchanged and only the calculated value differs")
+
+	return cmd
+}
+
+func runChangelog(cmd *cobra.Command, paths []string, logger *logrus.Logger) error {
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	format, _ := cmd.Flags().GetString("format")
+	outputPath, _ := cmd.Flags().GetString("output")
+	ignoreRecalculated, _ := cmd.Flags().GetBool("ignore-recalculated")
+
+	if format != "markdown" && format != "json" {
+		return utils.NewError(utils.ErrorTypeValidation, "changelog", fmt.Sprintf("unsupported format: %s", format))
+	}
+
+	client, err := openRepository(logger)
+	if err != nil {
+		return err
+	}
+	history, err := client.ChunkHistory(from, to)
+	if err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "gitcells-changelog-*")
+	if err != nil {
+		return utils.WrapError(err, utils.ErrorTypeFileSystem, "changelog", "failed to create temporary directory")
+	}
+	defer os.RemoveAll(tempDir)
+
+	reader := &revisionReader{
+		client:  client,
+		conv:    converter.NewConverter(logger),
+		tempDir: tempDir,
+		last:    make(map[string]revisionDocument),
+	}
+	settings := diffSettings{options: models.DiffOptions{IgnoreRecalculated: ignoreRecalculated}}
+	filters := workbookFilters(client.Root(), paths)
+
+	changelog := models.NewChangelog(from, to)
+	for _, entry := range history {
+		subject, _, _ := strings.Cut(entry.Commit.Message, "\n")
+		commit := models.ChangelogCommit{
+			Hash:    entry.Commit.Hash.String(),
+			Author:  entry.Commit.Author.Name,
+			Date:    entry.Commit.Author.When,
+			Subject: subject,
+		}
+
+		for _, workbook := range entry.Workbooks {
+			if !matchesWorkbookFilters(workbook, filters) {
+				continue
+			}
+			oldDoc, err := reader.read(entry.Parent, workbook)
+			if err != nil {
+				logger.Warnf("Skipping %s in %s: %v", workbook, commit.Hash[:7], err)
+				continue
+			}
+			newDoc, err := reader.read(entry.Commit, workbook)
+			if err != nil {
+				logger.Warnf("Skipping %s in %s: %v", workbook, commit.Hash[:7], err)
+				continue
+			}
+			changelog.Add(workbook, commit, compareDocuments(oldDoc, newDoc, settings))
+		}
+	}
+
+	out := io.Writer(cmd.OutOrStdout())
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "changelog", outputPath, "failed to create output file")
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if format == "json" {
+		return outputDiffJSON(out, changelog)
+	}
+	_, err = io.WriteString(out, changelog.ToMarkdown())
+	return err
+}
+
+// revisionReader reads workbooks from the chunk data of commits. It keeps
+// the last version read of each workbook, which is usually the parent
+// version the next commit is compared with.
+type revisionReader struct {
+	client  *git.Client
+	conv    converter.Converter
+	tempDir string
+	last    map[string]revisionDocument
+}
+
+// revisionDocument is a workbook as of a commit
+type revisionDocument struct {
+	commit plumbing.Hash
+	doc    *models.ExcelDocument
+}
+
+// read returns a workbook as of commit, or an empty document if the commit
+// is nil or has no chunk data for it
+func (r *revisionReader) read(commit *object.Commit, workbook string) (*models.ExcelDocument, error) {
+	if commit == nil {
+		return &models.ExcelDocument{}, nil
+	}
+
+	if last, ok := r.last[workbook]; ok && last.commit == commit.Hash {
+		return last.doc, nil
+	}
+
+	destRoot := filepath.Join(r.tempDir, commit.Hash.String())
+	defer os.RemoveAll(destRoot)
+
+	chunkDir, err := exportWorkbookChunks(r.client, commit.Hash.String(), workbook, destRoot)
+	if err != nil {
+		return nil, err
+	}
+	doc := &models.ExcelDocument{}
+	if chunkDir != "" {
+		if doc, err = r.conv.ReadChunks(chunkDir); err != nil {
+			return nil, err
+		}
+	}
+
+	r.last[workbook] = revisionDocument{commit: commit.Hash, doc: doc}
+	return doc, nil
+}
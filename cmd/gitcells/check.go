@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// checkProblem is a staged change that would commit a workbook and its
+// chunks out of step
+type checkProblem struct {
+	Path    string
+	Message string
+}
+
+func newCheckCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Verify staged Excel files match their JSON chunks",
+		Long: `Verify that the staged changes keep Excel files and their .gitcells/data
+chunks in step. The check fails when a staged workbook has no chunks, its
+chunks are out of date or not staged, or when chunks are staged without the
+workbook change they were converted from.
+
+This is what the pre-commit hook installed by 'gitcells hooks install' runs.`,
+		Args: cobra.NoArgs,
+		// A failed check is not a usage error
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCheck(cmd.OutOrStdout(), logger)
+		},
+	}
+
+	return cmd
+}
+
+func runCheck(w io.Writer, logger *logrus.Logger) error {
+	gitRoot, err := git.FindRepositoryRoot(".")
+	if err != nil {
+		return err
+	}
+	client, err := git.NewClient(gitRoot, &git.Config{}, logger)
+	if err != nil {
+		return err
+	}
+
+	problems, checked, err := checkStaged(client, logger)
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		if checked > 0 {
+			fmt.Fprintf(w, "✅ %d staged workbook(s) match their chunks\n", checked)
+		}
+		return nil
+	}
+
+	fmt.Fprintln(w, "❌ Staged Excel files and chunks are out of step:")
+	for _, problem := range problems {
+		fmt.Fprintf(w, "   %s: %s\n", problem.Path, problem.Message)
+	}
+	fmt.Fprintln(w, "\n💡 Hint: Run 'gitcells sync' and stage .gitcells/data, or commit with --no-verify to skip this check")
+
+	return utils.NewError(utils.ErrorTypeValidation, "check", fmt.Sprintf("%d problem(s) found", len(problems)))
+}
+
+// checkStaged inspects the staged changes and returns the problems found
+// and the number of staged workbooks checked
+func checkStaged(client *git.Client, logger *logrus.Logger) ([]checkProblem, int, error) {
+	status, err := client.Status()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	paths := make([]string, 0, len(status))
+	for p := range status {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var problems []checkProblem
+	checked := 0
+	stagedWorkbooks := make(map[string]bool)
+	chunkWorkbooks := make(map[string]bool)
+
+	for _, p := range paths {
+		fileStatus := status[p]
+		if !isStaged(fileStatus.Staging) {
+			continue
+		}
+
+		if workbook, ok := git.WorkbookForChunkPath(client.Root(), p); ok {
+			chunkWorkbooks[workbook] = true
+			continue
+		}
+
+		if fileStatus.Staging == gogit.Deleted || !isExcelFile(p) || strings.HasPrefix(path.Base(p), constants.ExcelTempPrefix) {
+			continue
+		}
+
+		stagedWorkbooks[p] = true
+		checked++
+		if message := checkStagedWorkbook(client, status, p, logger); message != "" {
+			problems = append(problems, checkProblem{Path: p, Message: message})
+		}
+	}
+
+	workbooks := make([]string, 0, len(chunkWorkbooks))
+	for workbook := range chunkWorkbooks {
+		workbooks = append(workbooks, workbook)
+	}
+	sort.Strings(workbooks)
+
+	for _, workbook := range workbooks {
+		if stagedWorkbooks[workbook] {
+			continue
+		}
+		// Chunks may be committed alone when the workbook itself is ignored
+		// or unchanged; only an unstaged workbook edit is a problem
+		if fileStatus := status.File(workbook); fileStatus.Worktree == gogit.Modified || fileStatus.Worktree == gogit.Untracked {
+			problems = append(problems, checkProblem{Path: workbook, Message: "chunks are staged but the workbook is not"})
+		}
+	}
+
+	return problems, checked, nil
+}
+
+// checkStagedWorkbook compares a staged workbook with its chunks and returns
+// a description of the problem, or "" if they match
+func checkStagedWorkbook(client *git.Client, status gogit.Status, relPath string, logger *logrus.Logger) string {
+	excelPath := filepath.Join(client.Root(), filepath.FromSlash(relPath))
+
+	fileStatus, err := getFileStatus(excelPath, logger)
+	if err != nil {
+		return fmt.Sprintf("failed to read status: %v", err)
+	}
+
+	switch fileStatus.Status {
+	case "new":
+		return "no chunks in .gitcells/data"
+	case "modified":
+		return "chunks are out of date"
+	}
+
+	chunkDir, err := filepath.Rel(client.Root(), filepath.Dir(fileStatus.JSONPath))
+	if err != nil {
+		return fmt.Sprintf("failed to locate chunks: %v", err)
+	}
+	chunkPrefix := filepath.ToSlash(chunkDir) + "/"
+	for p, chunkStatus := range status {
+		if strings.HasPrefix(p, chunkPrefix) && chunkStatus.Worktree != gogit.Unmodified {
+			return "chunks have unstaged changes"
+		}
+	}
+
+	// The working copy matches the chunks; make sure the staged copy does too
+	if status.File(relPath).Worktree == gogit.Modified {
+		metadata, err := readJSONMetadata(fileStatus.JSONPath)
+		if err != nil || metadata.Checksum == "" {
+			return "workbook has unstaged changes"
+		}
+		reader, err := client.ReadStaged(relPath)
+		if err != nil {
+			return fmt.Sprintf("failed to read staged workbook: %v", err)
+		}
+		defer reader.Close()
+
+		checksum, err := converter.Checksum(reader)
+		if err != nil || checksum != metadata.Checksum {
+			return "staged workbook differs from its chunks"
+		}
+	}
+
+	return ""
+}
+
+// isStaged reports whether a staging code describes a staged change
+func isStaged(code gogit.StatusCode) bool {
+	return code != gogit.Unmodified && code != gogit.Untracked
+}
@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
 )
 
 func TestRootCommand(t *testing.T) {
@@ -400,6 +405,22 @@ func TestSyncCommand_Functionality(t *testing.T) {
 		err := cmd.Execute()
 		assert.NoError(t, err)
 	})
+
+	t.Run("sync writes chunks to the workbook's chunk directory", func(t *testing.T) {
+		f := excelize.NewFile()
+		require.NoError(t, f.SetCellValue("Sheet1", "A1", "budget"))
+		require.NoError(t, f.SaveAs(filepath.Join(tempDir, "budget.xlsx")))
+		require.NoError(t, f.Close())
+
+		logger := logrus.New()
+		logger.SetLevel(logrus.ErrorLevel)
+		cmd := newSyncCommand(logger)
+		cmd.SetArgs([]string{})
+		require.NoError(t, cmd.Execute())
+
+		assert.FileExists(t, filepath.Join(tempDir, ".gitcells", "data", "budget.xlsx_chunks", "workbook.json"))
+		assert.NoDirExists(t, filepath.Join(tempDir, ".gitcells", "data", ".gitcells"))
+	})
 }
 
 func TestCommandFlags(t *testing.T) {
@@ -518,3 +539,88 @@ func TestRootCommandVersion(t *testing.T) {
 		assert.Contains(t, output, "test-version")
 	})
 }
+
+func TestFindExcelFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"budget.xlsx",
+		"notes.txt",
+		"~$budget.xlsx",
+		"archive/2023/old.xlsx",
+		"archive/2023/keep.xlsx",
+		"drafts/plan.xlsx",
+		"reports/q1.xlsx",
+		"reports/draft.xlsx",
+		".gitcells/data/budget.xlsx",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitcellsignore"), []byte("drafts/\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "reports", ".gitcellsignore"), []byte("/draft.xlsx\n"), 0600))
+
+	files, err := findExcelFiles(dir, []string{"*.xlsx"}, []string{"archive/**/*.xlsx", "!keep.xlsx"})
+	require.NoError(t, err)
+
+	var rel []string
+	for _, file := range files {
+		r, err := filepath.Rel(dir, file)
+		require.NoError(t, err)
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	assert.ElementsMatch(t, []string{"budget.xlsx", "archive/2023/keep.xlsx", "reports/q1.xlsx"}, rel)
+}
+
+func TestHookScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts need a POSIX shell")
+	}
+
+	// The stub records its arguments, so the scripts show what they ran
+	dir := filepath.Join(t.TempDir(), "it's $HOME")
+	require.NoError(t, os.MkdirAll(dir, 0750))
+	binary := filepath.Join(dir, "gitcells")
+	calls := filepath.Join(dir, "calls")
+	require.NoError(t, os.WriteFile(binary, []byte("#!/bin/sh\necho \"$*\" >> '"+strings.ReplaceAll(calls, "'", `'\''`)+"'\n"), 0700)) // #nosec G306 -- test stub must be executable
+
+	for _, hook := range gitHooks {
+		script := filepath.Join(dir, hook.Name)
+		require.NoError(t, os.WriteFile(script, []byte(hookScript(hook.Name, hook.Command, binary)), 0600))
+		out, err := exec.Command("sh", script, "HEAD~1").CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	data, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, "check\nhooks refresh --from HEAD~1\nhooks refresh --from ORIG_HEAD\n", string(data))
+
+	// A missing binary is reported by its path and skipped
+	script := filepath.Join(dir, "pre-commit")
+	require.NoError(t, os.WriteFile(script, []byte(hookScript("pre-commit", gitHooks[0].Command, binary+"-missing")), 0600))
+	out, err := exec.Command("sh", script).CombinedOutput()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), binary+"-missing not found")
+}
+
+func TestParseRevisionRange(t *testing.T) {
+	tests := []struct {
+		rev, oldRev, newRev string
+	}{
+		{"HEAD~1", "HEAD~1", ""},
+		{"main..feature", "main", "feature"},
+		{"main..", "main", "HEAD"},
+		{"..feature", "HEAD", "feature"},
+	}
+	for _, tt := range tests {
+		oldRev, newRev, err := parseRevisionRange(tt.rev)
+		require.NoError(t, err, tt.rev)
+		assert.Equal(t, tt.oldRev, oldRev, tt.rev)
+		assert.Equal(t, tt.newRev, newRev, tt.rev)
+	}
+
+	_, _, err := parseRevisionRange("main...feature")
+	assert.ErrorContains(t, err, "not supported")
+	_, _, err = parseRevisionRange("..")
+	assert.Error(t, err)
+}
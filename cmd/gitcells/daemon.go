@@ -0,0 +1,328 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/daemon"
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/watcher"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newDaemonCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the watcher in the background",
+		Long: `Run 'gitcells watch' as a background process that outlives the terminal.
+
+The daemon writes its PID to .gitcells/daemon.pid and its log to
+.gitcells/daemon.log, and is controlled through .gitcells/daemon.sock.`,
+	}
+
+	cmd.AddCommand(
+		newDaemonStartCommand(logger),
+		newDaemonStopCommand(),
+		newDaemonStatusCommand(),
+		newDaemonPauseCommand(),
+		newDaemonResumeCommand(),
+		newDaemonSyncCommand(),
+	)
+	return cmd
+}
+
+func newDaemonStartCommand(logger *logrus.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "start [directories...]",
+		Short: "Start watching directories in the background",
+		Long:  "Start a background watcher for the given directories, or the current one. Flags after -- are passed to 'gitcells watch'.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dirs := args
+			var watchFlags []string
+			if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+				dirs, watchFlags = args[:dash], args[dash:]
+			}
+			if len(dirs) == 0 {
+				dirs = []string{"."}
+			}
+
+			watchArgs := append([]string{"watch", "--daemon"}, watchFlags...)
+			if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+				watchArgs = append(watchArgs, "--config", configPath)
+			}
+			if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+				watchArgs = append(watchArgs, "--verbose")
+			}
+			watchArgs = append(watchArgs, dirs...)
+
+			paths := daemon.PathsFor(".")
+			pid, err := daemon.Start(paths, watchArgs)
+			if err != nil {
+				return err
+			}
+			logger.Debugf("Daemon command line: %v", watchArgs)
+			fmt.Printf("Started watcher daemon (pid %d), logging to %s\n", pid, paths.LogFile)
+			return nil
+		},
+	}
+}
+
+func newDaemonStopCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Commit pending changes and stop the background watcher",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pid, err := daemon.Stop(daemon.PathsFor("."))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Stopped watcher daemon (pid %d)\n", pid)
+			return nil
+		},
+	}
+}
+
+func newDaemonStatusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the state of the background watcher",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, ok := daemon.Running(".")
+			if !ok {
+				fmt.Println("Watcher daemon is not running")
+				return nil
+			}
+			status, err := client.Status()
+			if err != nil {
+				return err
+			}
+			displayDaemonStatus(status)
+
+			if showQueue, _ := cmd.Flags().GetBool("queue"); showQueue {
+				queue, err := client.Queue()
+				if err != nil {
+					return err
+				}
+				for _, item := range queue.Pending {
+					icon := "⏳"
+					if item.Held {
+						icon = "⏸ "
+					}
+					fmt.Printf("%s %s %s (queued %s)\n", icon, item.Event.Type, item.Event.Path, item.Queued.Format("2006-01-02 15:04:05"))
+				}
+				displayQueue(watcher.QueueState{Failed: queue.Failed})
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("queue", false, "list the changes waiting to be processed and those that failed")
+	return cmd
+}
+
+func newDaemonPauseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause",
+		Short: "Hold changes until the background watcher is resumed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := runningDaemon()
+			if err != nil {
+				return err
+			}
+			if _, err := client.Pause(); err != nil {
+				return err
+			}
+			fmt.Println("Watcher daemon paused; changes are held until 'gitcells daemon resume'")
+			return nil
+		},
+	}
+}
+
+func newDaemonResumeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume",
+		Short: "Process held changes and continue watching",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := runningDaemon()
+			if err != nil {
+				return err
+			}
+			if _, err := client.Resume(); err != nil {
+				return err
+			}
+			fmt.Println("Watcher daemon resumed")
+			return nil
+		},
+	}
+}
+
+func newDaemonSyncCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "sync",
+		Short: "Convert and commit workbooks the background watcher missed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := runningDaemon()
+			if err != nil {
+				return err
+			}
+			synced, err := client.Sync()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Synced %d workbooks\n", synced)
+			return nil
+		},
+	}
+}
+
+// runningDaemon returns a client for the daemon of the current repository
+func runningDaemon() (*daemon.Client, error) {
+	client, ok := daemon.Running(".")
+	if !ok {
+		return nil, fmt.Errorf("watcher daemon is not running; start it with 'gitcells daemon start'")
+	}
+	return client, nil
+}
+
+// displayDaemonStatus prints the state reported by a daemon
+func displayDaemonStatus(status daemon.Status) {
+	state := "running"
+	if status.Paused {
+		state = fmt.Sprintf("paused, holding %d changes", status.HeldChanges)
+	}
+	fmt.Printf("👁  Watcher daemon %s (pid %d, since %s)\n", state, status.PID, status.Started.Format("2006-01-02 15:04:05"))
+	fmt.Printf("   Watching %d directories\n", len(status.Directories))
+	if status.LastEvent != "" {
+		fmt.Printf("   Last event: %s at %s\n", status.LastEvent, status.LastEventTime.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("   Converting: %d, waiting: %d\n", status.ActiveEvents, status.QueuedEvents)
+	fmt.Printf("   Pending: %d, failed: %d\n", status.PendingChanges, status.FailedChanges)
+}
+
+// syncPollInterval is how often a sync checks whether the workbooks it
+// found have been converted
+const syncPollInterval = 100 * time.Millisecond
+
+// watchDaemon is the watch command as driven by the daemon control API
+type watchDaemon struct {
+	fw      *watcher.FileWatcher
+	queue   *watcher.Queue
+	batcher *git.CommitBatcher
+	dirs    []string
+	logger  *logrus.Logger
+	started time.Time
+
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+
+	mu            sync.Mutex
+	paused        bool
+	lastEvent     string
+	lastEventTime time.Time
+}
+
+func newWatchDaemon(queue *watcher.Queue, batcher *git.CommitBatcher, dirs []string, logger *logrus.Logger) *watchDaemon {
+	return &watchDaemon{
+		queue:    queue,
+		batcher:  batcher,
+		dirs:     dirs,
+		logger:   logger,
+		started:  time.Now(),
+		shutdown: make(chan struct{}),
+	}
+}
+
+// handle passes an event to the queue, or holds it there while paused
+func (d *watchDaemon) handle(event watcher.FileEvent) error {
+	d.mu.Lock()
+	d.lastEvent = fmt.Sprintf("%s %s", event.Type, event.Path)
+	d.lastEventTime = time.Now()
+	if d.paused {
+		// Holding under the lock keeps Resume from missing the event
+		defer d.mu.Unlock()
+		d.logger.Infof("Paused; holding %s of %s", event.Type, event.Path)
+		return d.queue.Hold(event)
+	}
+	d.mu.Unlock()
+
+	return d.queue.Handle(event)
+}
+
+func (d *watchDaemon) Status() daemon.Status {
+	queued, active := d.fw.QueueDepth()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return daemon.Status{
+		PID:            os.Getpid(),
+		Started:        d.started,
+		Paused:         d.paused,
+		Directories:    d.fw.GetWatchedDirectories(),
+		LastEvent:      d.lastEvent,
+		LastEventTime:  d.lastEventTime,
+		HeldChanges:    d.queue.Held(),
+		PendingChanges: len(d.queue.Pending()),
+		FailedChanges:  len(d.queue.Failed()),
+		QueuedEvents:   queued,
+		ActiveEvents:   active,
+	}
+}
+
+func (d *watchDaemon) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.paused {
+		d.paused = true
+		d.logger.Info("Paused")
+	}
+}
+
+func (d *watchDaemon) Resume() {
+	d.mu.Lock()
+	wasPaused := d.paused
+	d.paused = false
+	var held []watcher.FileEvent
+	if wasPaused {
+		held = d.queue.Release()
+	}
+	d.mu.Unlock()
+
+	if !wasPaused {
+		return
+	}
+	d.logger.Infof("Resumed; processing %d held changes", len(held))
+	for _, event := range held {
+		d.fw.Notify(event)
+	}
+}
+
+func (d *watchDaemon) Sync() (int, error) {
+	synced := reconcileWorkbooks(d.fw, d.dirs, d.fw.Notify, d.logger)
+
+	// The workbooks are converted like any other change; commit them once
+	// they are
+	for !d.fw.Idle() {
+		select {
+		case <-time.After(syncPollInterval):
+		case <-d.shutdown:
+			return synced, nil
+		}
+	}
+	return synced, d.batcher.Flush()
+}
+
+func (d *watchDaemon) Queue() watcher.QueueState {
+	return watcher.QueueState{Pending: d.queue.Pending(), Failed: d.queue.Failed()}
+}
+
+func (d *watchDaemon) ClearFailed() (int, error) {
+	return d.queue.ClearFailed()
+}
+
+func (d *watchDaemon) Shutdown() {
+	d.shutdownOnce.Do(func() {
+		close(d.shutdown)
+	})
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -25,14 +26,20 @@ const (
 
 func newDiffCommand(logger *logrus.Logger) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "diff <file1> [file2]",
+		Use:   "diff <file1|dir1> [file2|dir2]",
 		Short: "Show differences between Excel files or versions",
-		Long: `Compare two Excel files or show changes in a file.
+		Long: `Compare two Excel files, two directories of workbooks, or the tracked
+workbooks of two git revisions.
 
 Examples:
-  gitcells diff file1.xlsx file2.xlsx    # Compare two Excel files
-  gitcells diff file.xlsx                # Compare with stored version`,
-		Args: cobra.RangeArgs(minDiffArgs, maxDiffArgs),
+  gitcells diff file1.xlsx file2.xlsx       # Compare two Excel files
+  gitcells diff old/ new/                   # Compare workbooks paired by path
+  gitcells diff --rev main..feature         # Compare all tracked workbooks between revisions
+  gitcells diff --rev HEAD~1 reports/       # Compare a revision with the working tree
+  gitcells diff --rev main..feature --format html -o report.html
+  gitcells diff old.xlsx new.xlsx --format xlsx -o changes.xlsx
+  gitcells diff old.xlsx new.xlsx --format patch -o fix.patch.json`,
+		Args: cobra.MaximumNArgs(maxDiffArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runDiff(cmd, args, logger)
 		},
@@ -42,24 +49,129 @@ Examples:
 	cmd.Flags().Bool("summary", false, "Show only summary of changes")
 	cmd.Flags().Bool("no-color", false, "Disable colored output")
 	cmd.Flags().Bool("tui", false, "Launch interactive TUI diff viewer")
-	cmd.Flags().String("format", "text", "Output format: text, json")
+	cmd.Flags().String("format", "text", "Output format: text, json, html, xlsx, patch")
+	cmd.Flags().StringP("output", "o", "", "Write output to a file instead of stdout")
+	cmd.Flags().String("rev", "", "Compare tracked workbooks between git revisions (<rev>..<rev>, an empty side meaning HEAD, or <rev> for the working tree)")
 	cmd.Flags().StringSlice("sheets", []string{}, "Only compare specific sheets")
 	cmd.Flags().Bool("ignore-formatting", false, "Ignore cell formatting differences")
 	cmd.Flags().Bool("ignore-empty", false, "Ignore empty cell differences")
+	cmd.Flags().Bool("formula-aware", false, "Separate recalculated formula results from input edits and show token-level formula diffs")
+	cmd.Flags().Bool("ignore-recalculated", false, "Hide cells whose formula is unchanged and only the calculated value differs")
 
 	return cmd
 }
 
+// diffSettings holds the comparison flags shared by every diff mode
+type diffSettings struct {
+	sheets           []string
+	ignoreFormatting bool
+	ignoreEmpty      bool
+	options          models.DiffOptions
+	keepDocuments    bool
+}
+
+// diffOutput holds the output flags shared by every diff mode
+type diffOutput struct {
+	format      string
+	summaryOnly bool
+	useColor    bool
+	// oldLabel and newLabel name the two sides of a single-file diff
+	oldLabel string
+	newLabel string
+}
+
 func runDiff(cmd *cobra.Command, args []string, logger *logrus.Logger) error {
 	// Get flags
 	summaryOnly, _ := cmd.Flags().GetBool("summary")
 	noColor, _ := cmd.Flags().GetBool("no-color")
 	tuiMode, _ := cmd.Flags().GetBool("tui")
 	format, _ := cmd.Flags().GetString("format")
+	outputPath, _ := cmd.Flags().GetString("output")
+	rev, _ := cmd.Flags().GetString("rev")
 	sheets, _ := cmd.Flags().GetStringSlice("sheets")
 	ignoreFormatting, _ := cmd.Flags().GetBool("ignore-formatting")
 	ignoreEmpty, _ := cmd.Flags().GetBool("ignore-empty")
+	formulaAware, _ := cmd.Flags().GetBool("formula-aware")
+	ignoreRecalculated, _ := cmd.Flags().GetBool("ignore-recalculated")
+
+	settings := diffSettings{
+		sheets:           sheets,
+		ignoreFormatting: ignoreFormatting,
+		ignoreEmpty:      ignoreEmpty,
+		options: models.DiffOptions{
+			FormulaAware:       formulaAware,
+			IgnoreRecalculated: ignoreRecalculated,
+		},
+	}
+	output := diffOutput{
+		format:      format,
+		summaryOnly: summaryOnly,
+		useColor:    !noColor && outputPath == "",
+	}
+
+	batchMode := rev != "" || (len(args) == maxDiffArgs && isDirectory(args[0]) && isDirectory(args[1]))
+	if !batchMode && len(args) < minDiffArgs {
+		return utils.NewError(utils.ErrorTypeValidation, "diff", "specify two files, two directories or --rev")
+	}
+	if batchMode && tuiMode {
+		return utils.NewError(utils.ErrorTypeValidation, "diff", "the TUI viewer only supports comparing two files")
+	}
+	if batchMode && (format == "xlsx" || format == "patch") {
+		return utils.NewError(utils.ErrorTypeValidation, "diff", fmt.Sprintf("the %s format only supports comparing two files", format))
+	}
+	if format == "xlsx" {
+		if outputPath == "" {
+			return utils.NewError(utils.ErrorTypeValidation, "diff", "the xlsx format requires --output")
+		}
+	}
+
+	// The grid report needs both versions of every workbook
+	settings.keepDocuments = format == "html"
+
+	var batch *models.BatchDiff
+	var workbook *models.ReportWorkbook
+	var err error
+
+	switch {
+	case rev != "":
+		batch, err = diffRevisions(rev, args, settings, logger)
+	case batchMode:
+		batch, err = diffDirectories(args[0], args[1], settings, logger)
+	default:
+		workbook, err = diffFiles(args, settings, logger)
+		if len(args) == maxDiffArgs {
+			output.oldLabel, output.newLabel = args[0], args[1]
+		}
+	}
+	if err != nil {
+		return err
+	}
 
+	if tuiMode {
+		return runDiffTUI(workbook.Diff)
+	}
+	if format == "xlsx" {
+		return writeDiffWorkbook(workbook, outputPath, logger)
+	}
+
+	out := io.Writer(os.Stdout)
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "diff", outputPath, "failed to create output file")
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if batch != nil {
+		return writeBatchDiff(out, batch, output)
+	}
+	return writeDiff(out, workbook, output)
+}
+
+// diffFiles compares two Excel files
+func diffFiles(args []string, settings diffSettings, logger *logrus.Logger) (*models.ReportWorkbook, error) {
 	var file1, file2 string
 	file1 = args[0]
 
@@ -69,49 +181,94 @@ func runDiff(cmd *cobra.Command, args []string, logger *logrus.Logger) error {
 	} else {
 		// For single file diff, we would need to load from chunks
 		// This is not yet implemented
-		return utils.NewError(utils.ErrorTypeValidation, "diff", "comparing with stored version not yet implemented - please specify two Excel files")
+		return nil, utils.NewError(utils.ErrorTypeValidation, "diff", "comparing with stored version not yet implemented - please specify two Excel files or use --rev")
 	}
 
 	logger.Debugf("Comparing %s with %s", file1, file2)
 
 	// Load documents
-	doc1, err := loadDocument(file1, false, ignoreFormatting, logger)
+	doc1, err := loadDocument(file1, false, settings.ignoreFormatting, logger)
 	if err != nil {
-		return utils.WrapFileError(err, utils.ErrorTypeConverter, "load_document", file1, "failed to load first document")
+		return nil, utils.WrapFileError(err, utils.ErrorTypeConverter, "load_document", file1, "failed to load first document")
 	}
 
-	doc2, err := loadDocument(file2, false, ignoreFormatting, logger)
+	doc2, err := loadDocument(file2, false, settings.ignoreFormatting, logger)
 	if err != nil {
-		return utils.WrapFileError(err, utils.ErrorTypeConverter, "load_document", file2, "failed to load second document")
+		return nil, utils.WrapFileError(err, utils.ErrorTypeConverter, "load_document", file2, "failed to load second document")
 	}
 
+	return &models.ReportWorkbook{
+		Path: file2,
+		Old:  doc1,
+		New:  doc2,
+		Diff: compareDocuments(doc1, doc2, settings),
+	}, nil
+}
+
+// compareDocuments applies sheet and empty-cell filters around ComputeDiffWithOptions
+func compareDocuments(doc1, doc2 *models.ExcelDocument, settings diffSettings) *models.ExcelDiff {
 	// Filter sheets if specified
-	if len(sheets) > 0 {
-		doc1 = filterSheets(doc1, sheets)
-		doc2 = filterSheets(doc2, sheets)
+	if len(settings.sheets) > 0 {
+		doc1 = filterSheets(doc1, settings.sheets)
+		doc2 = filterSheets(doc2, settings.sheets)
 	}
 
 	// Compute diff
-	diff := models.ComputeDiff(doc1, doc2)
+	diff := models.ComputeDiffWithOptions(doc1, doc2, settings.options)
 
 	// Filter empty cell changes if requested
-	if ignoreEmpty {
+	if settings.ignoreEmpty {
 		diff = filterEmptyChanges(diff)
 	}
 
-	// Output results
-	if tuiMode {
-		return runDiffTUI(diff)
-	}
+	return diff
+}
 
-	switch format {
+// writeDiff writes a single workbook diff in the requested format
+func writeDiff(w io.Writer, workbook *models.ReportWorkbook, output diffOutput) error {
+	switch output.format {
 	case "json":
-		return outputDiffJSON(diff)
+		return outputDiffJSON(w, workbook.Diff)
+	case "patch":
+		return outputDiffJSON(w, models.NewPatch(workbook.Old, workbook.New, workbook.Diff))
+	case "html":
+		report := models.HTMLReport{
+			Title:     "GitCells diff: " + filepath.Base(workbook.Path),
+			OldLabel:  output.oldLabel,
+			NewLabel:  output.newLabel,
+			Workbooks: []models.ReportWorkbook{*workbook},
+		}
+		_, err := io.WriteString(w, models.GenerateHTMLReport(report))
+		return err
 	default:
-		return outputDiffText(diff, summaryOnly, !noColor)
+		return outputDiffText(w, workbook.Diff, output.summaryOnly, output.useColor)
 	}
 }
 
+// writeDiffWorkbook saves the new version of a workbook with its changes
+// highlighted, plus a summary sheet, for review in Excel
+func writeDiffWorkbook(workbook *models.ReportWorkbook, outputPath string, logger *logrus.Logger) error {
+	doc := models.BuildDiffWorkbook(workbook.Old, workbook.New, workbook.Diff)
+
+	conv := converter.NewConverter(logger)
+	options := converter.ConvertOptions{
+		PreserveFormulas: true,
+		PreserveStyles:   true,
+		PreserveComments: true,
+	}
+	if err := conv.JSONToExcel(doc, outputPath, options); err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeConverter, "diff", outputPath, "failed to write diff workbook")
+	}
+
+	logger.Infof("Wrote %s (%s)", outputPath, workbook.Diff.String())
+	return nil
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
 func loadDocument(filePath string, jsonMode, ignoreFormatting bool, logger *logrus.Logger) (*models.ExcelDocument, error) {
 	ext := strings.ToLower(filepath.Ext(filePath))
 
@@ -184,8 +341,14 @@ func filterEmptyChanges(diff *models.ExcelDiff) *models.ExcelDiff {
 
 	// Recalculate summary
 	filtered.Summary.CellChanges = 0
+	filtered.Summary.RecalculatedCells = 0
 	for _, sheetDiff := range filtered.SheetDiffs {
 		filtered.Summary.CellChanges += len(sheetDiff.Changes)
+		for _, change := range sheetDiff.Changes {
+			if change.Recalculated {
+				filtered.Summary.RecalculatedCells++
+			}
+		}
 	}
 
 	return filtered
@@ -203,61 +366,73 @@ func isEmptyValue(value interface{}) bool {
 	return false
 }
 
-func outputDiffJSON(diff *models.ExcelDiff) error {
-	encoder := json.NewEncoder(os.Stdout)
+func outputDiffJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(diff)
+	return encoder.Encode(v)
 }
 
-func outputDiffText(diff *models.ExcelDiff, summaryOnly, useColor bool) error {
+// diffColors holds the ANSI color codes used by text output
+type diffColors struct {
+	green, red, yellow, blue, gray, reset string
+}
+
+func newDiffColors(useColor bool) diffColors {
+	if !useColor {
+		return diffColors{}
+	}
+	return diffColors{
+		green:  "\033[32m",
+		red:    "\033[31m",
+		yellow: "\033[33m",
+		blue:   "\033[34m",
+		gray:   "\033[90m",
+		reset:  "\033[0m",
+	}
+}
+
+func outputDiffText(w io.Writer, diff *models.ExcelDiff, summaryOnly, useColor bool) error {
 	if !diff.HasChanges() {
-		fmt.Println("No differences found")
+		fmt.Fprintln(w, "No differences found")
 		return nil
 	}
 
-	// Color codes
-	var (
-		green  = ""
-		red    = ""
-		yellow = ""
-		blue   = ""
-		reset  = ""
-	)
-
-	if useColor {
-		green = "\033[32m"
-		red = "\033[31m"
-		yellow = "\033[33m"
-		blue = "\033[34m"
-		reset = "\033[0m"
-	}
+	c := newDiffColors(useColor)
 
 	// Print summary
-	fmt.Printf("%s=== Diff Summary ===%s\n", blue, reset)
-	fmt.Printf("Timestamp: %s\n", diff.Timestamp.Format("2006-01-02 15:04:05"))
-	fmt.Printf("Changes: %s\n", diff.String())
-	fmt.Println()
+	fmt.Fprintf(w, "%s=== Diff Summary ===%s\n", c.blue, c.reset)
+	fmt.Fprintf(w, "Timestamp: %s\n", diff.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Changes: %s\n", diff.String())
+	fmt.Fprintln(w)
 
 	if summaryOnly {
 		return nil
 	}
 
+	writeSheetDiffs(w, diff, c, useColor)
+	return nil
+}
+
+// writeSheetDiffs prints the per-sheet cell changes of a diff
+func writeSheetDiffs(w io.Writer, diff *models.ExcelDiff, c diffColors, useColor bool) {
+	green, red, yellow, blue, gray, reset := c.green, c.red, c.yellow, c.blue, c.gray, c.reset
+
 	// Print detailed changes
 	for _, sheetDiff := range diff.SheetDiffs {
-		fmt.Printf("%s=== Sheet: %s ===%s\n", blue, sheetDiff.SheetName, reset)
+		fmt.Fprintf(w, "%s=== Sheet: %s ===%s\n", blue, sheetDiff.SheetName, reset)
 
 		if sheetDiff.Action != "" {
 			actionColor := green
 			if sheetDiff.Action == models.ChangeTypeDelete {
 				actionColor = red
 			}
-			fmt.Printf("Action: %s%s%s\n", actionColor, strings.ToUpper(string(sheetDiff.Action)), reset)
+			fmt.Fprintf(w, "Action: %s%s%s\n", actionColor, strings.ToUpper(string(sheetDiff.Action)), reset)
 		}
 
 		if len(sheetDiff.Changes) == 0 {
-			fmt.Println("No cell changes")
+			fmt.Fprintln(w, "No cell changes")
 		} else {
-			fmt.Printf("Cell changes (%d):\n", len(sheetDiff.Changes))
+			fmt.Fprintf(w, "Cell changes (%d):\n", len(sheetDiff.Changes))
 
 			for _, change := range sheetDiff.Changes {
 				var changeColor string
@@ -273,39 +448,51 @@ func outputDiffText(diff *models.ExcelDiff, summaryOnly, useColor bool) error {
 				case models.ChangeTypeModify:
 					changeColor = yellow
 					symbol = "~"
+					if change.Recalculated {
+						changeColor = gray
+						symbol = "="
+					}
 				}
 
-				fmt.Printf("  %s%s %s%s", changeColor, symbol, change.Cell, reset)
+				fmt.Fprintf(w, "  %s%s %s%s", changeColor, symbol, change.Cell, reset)
 
 				if change.Description != "" {
-					fmt.Printf(": %s", change.Description)
+					fmt.Fprintf(w, ": %s", change.Description)
 				}
 
 				// Show value changes
 				switch change.Type {
 				case models.ChangeTypeModify:
-					if change.OldValue != nil || change.NewValue != nil {
-						fmt.Printf(" (%s%v%s → %s%v%s)",
+					if len(change.FormulaDiff) > 0 {
+						// The description already carries the plain-text markers
+						if useColor {
+							fmt.Fprintf(w, "\n      formula: %s", formatFormulaDiffText(change.FormulaDiff))
+						}
+					} else if !change.Recalculated && (change.OldValue != nil || change.NewValue != nil) {
+						fmt.Fprintf(w, " (%s%v%s → %s%v%s)",
 							red, change.OldValue, reset,
 							green, change.NewValue, reset)
 					}
 				case models.ChangeTypeAdd:
 					if change.NewValue != nil {
-						fmt.Printf(" (%s%v%s)", green, change.NewValue, reset)
+						fmt.Fprintf(w, " (%s%v%s)", green, change.NewValue, reset)
 					}
 				case models.ChangeTypeDelete:
 					if change.OldValue != nil {
-						fmt.Printf(" (%s%v%s)", red, change.OldValue, reset)
+						fmt.Fprintf(w, " (%s%v%s)", red, change.OldValue, reset)
 					}
 				}
 
-				fmt.Println()
+				fmt.Fprintln(w)
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
+}
 
-	return nil
+// formatFormulaDiffText renders a token-level formula diff with terminal colors
+func formatFormulaDiffText(segments []models.FormulaDiffSegment) string {
+	return models.FormatFormulaDiffWith(segments, "\033[31;9m", "\033[0m", "\033[32;1m", "\033[0m")
 }
 
 // diffTUIModel wraps the DiffViewer for the TUI application
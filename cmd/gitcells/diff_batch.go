@@ -0,0 +1,373 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/Classic-Homes/gitcells/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// workingTreeLabel names the working tree side of a revision diff
+const workingTreeLabel = "working tree"
+
+// diffDirectories compares every workbook in two directories, pairing them
+// by their path relative to each directory
+func diffDirectories(oldDir, newDir string, settings diffSettings, logger *logrus.Logger) (*models.BatchDiff, error) {
+	oldFiles, err := collectExcelWorkbooks(oldDir)
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "diff", oldDir, "failed to scan directory")
+	}
+	newFiles, err := collectExcelWorkbooks(newDir)
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "diff", newDir, "failed to scan directory")
+	}
+
+	batch := models.NewBatchDiff(oldDir, newDir)
+	batch.KeepDocuments = settings.keepDocuments
+	for _, key := range unionKeys(oldFiles, newFiles) {
+		oldPath, hasOld := oldFiles[key]
+		newPath, hasNew := newFiles[key]
+
+		oldDoc, newDoc := &models.ExcelDocument{}, &models.ExcelDocument{}
+		if hasOld {
+			if oldDoc, err = loadDocument(oldPath, false, settings.ignoreFormatting, logger); err != nil {
+				batch.AddError(key, err)
+				continue
+			}
+		}
+		if hasNew {
+			if newDoc, err = loadDocument(newPath, false, settings.ignoreFormatting, logger); err != nil {
+				batch.AddError(key, err)
+				continue
+			}
+		}
+
+		batch.AddComparison(key, presentDocument(oldDoc, hasOld), presentDocument(newDoc, hasNew), compareDocuments(oldDoc, newDoc, settings))
+	}
+
+	return batch, nil
+}
+
+// diffRevisions compares the tracked chunk data of two git revisions. A
+// single revision is compared with the working tree. Optional paths restrict
+// the comparison to the given workbooks or directories.
+func diffRevisions(rev string, paths []string, settings diffSettings, logger *logrus.Logger) (*models.BatchDiff, error) {
+	oldRev, newRev, err := parseRevisionRange(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	gitRoot, err := git.FindRepositoryRoot(".")
+	if err != nil {
+		return nil, err
+	}
+	client, err := git.NewClient(gitRoot, &git.Config{}, logger)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, utils.NewError(utils.ErrorTypeGit, "diff", "not a git repository")
+	}
+
+	return diffCommits(client, oldRev, newRev, workbookFilters(gitRoot, paths), settings, logger)
+}
+
+// parseRevisionRange splits a --rev value into the revisions to compare.
+// As in git, an empty side of a..b is HEAD. A single revision returns an
+// empty newRev, for the working tree. The a...b form compares with the
+// merge base in git and is rejected rather than read differently.
+func parseRevisionRange(rev string) (oldRev, newRev string, err error) {
+	if strings.Contains(rev, "...") {
+		return "", "", utils.NewError(utils.ErrorTypeValidation, "diff",
+			fmt.Sprintf("revision range %s is not supported; use <rev>..<rev>", rev))
+	}
+
+	oldRev, newRev, isRange := strings.Cut(rev, "..")
+	if !isRange {
+		return rev, "", nil
+	}
+	if oldRev == "" && newRev == "" {
+		return "", "", utils.NewError(utils.ErrorTypeValidation, "diff", fmt.Sprintf("invalid revision range: %s", rev))
+	}
+	if oldRev == "" {
+		oldRev = "HEAD"
+	}
+	if newRev == "" {
+		newRev = "HEAD"
+	}
+	return oldRev, newRev, nil
+}
+
+// diffCommits compares the chunk data of the workbooks matching filters
+// (repository-relative workbook paths or directories) between two
+// revisions. An empty newRev compares oldRev with the working tree.
+func diffCommits(client *git.Client, oldRev, newRev string, filters []string, settings diffSettings, logger *logrus.Logger) (*models.BatchDiff, error) {
+	gitRoot := client.Root()
+
+	tempDir, err := os.MkdirTemp("", "gitcells-diff-*")
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeFileSystem, "diff", "failed to create temporary directory")
+	}
+	defer os.RemoveAll(tempDir)
+
+	oldRoot := filepath.Join(tempDir, "old")
+	if _, err := client.ExportTree(oldRev, constants.GitCellsDataDir, oldRoot); err != nil {
+		return nil, err
+	}
+
+	newRoot, newLabel := gitRoot, workingTreeLabel
+	if newRev != "" {
+		newRoot, newLabel = filepath.Join(tempDir, "new"), newRev
+		if _, err := client.ExportTree(newRev, constants.GitCellsDataDir, newRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	oldChunks, err := collectChunkWorkbooks(oldRoot)
+	if err != nil {
+		return nil, err
+	}
+	newChunks, err := collectChunkWorkbooks(newRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	conv := converter.NewConverter(logger)
+	batch := models.NewBatchDiff(oldRev, newLabel)
+	batch.KeepDocuments = settings.keepDocuments
+
+	for _, key := range unionKeys(oldChunks, newChunks) {
+		if !matchesWorkbookFilters(key, filters) {
+			continue
+		}
+		oldDir, hasOld := oldChunks[key]
+		newDir, hasNew := newChunks[key]
+
+		oldDoc, newDoc := &models.ExcelDocument{}, &models.ExcelDocument{}
+		if hasOld {
+			if oldDoc, err = conv.ReadChunks(oldDir); err != nil {
+				batch.AddError(key, err)
+				continue
+			}
+		}
+		if hasNew {
+			if newDoc, err = conv.ReadChunks(newDir); err != nil {
+				batch.AddError(key, err)
+				continue
+			}
+		}
+
+		batch.AddComparison(workbookDisplayName(key, oldDoc, newDoc), presentDocument(oldDoc, hasOld), presentDocument(newDoc, hasNew), compareDocuments(oldDoc, newDoc, settings))
+	}
+
+	return batch, nil
+}
+
+// presentDocument returns doc when the workbook exists on that side, or nil
+func presentDocument(doc *models.ExcelDocument, exists bool) *models.ExcelDocument {
+	if !exists {
+		return nil
+	}
+	return doc
+}
+
+// collectExcelWorkbooks maps the slash-separated relative path of every
+// Excel file below dir to its full path
+func collectExcelWorkbooks(dir string) (map[string]string, error) {
+	files, err := findExcelFiles(dir, excelIncludePatterns(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	workbooks := make(map[string]string, len(files))
+	for _, file := range files {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		workbooks[filepath.ToSlash(rel)] = file
+	}
+	return workbooks, nil
+}
+
+// collectChunkWorkbooks maps each chunk directory below root's
+// .gitcells/data to a workbook key: its relative path without the chunk suffix
+func collectChunkWorkbooks(root string) (map[string]string, error) {
+	dataDir := filepath.Join(root, constants.GitCellsDataDir)
+	workbooks := make(map[string]string)
+
+	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+		return workbooks, nil
+	}
+
+	err := filepath.WalkDir(dataDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || !strings.HasSuffix(d.Name(), constants.ChunksDirSuffix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dataDir, path)
+		if err != nil {
+			return err
+		}
+		workbooks[strings.TrimSuffix(filepath.ToSlash(rel), constants.ChunksDirSuffix)] = path
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "collectChunkWorkbooks", dataDir, "failed to scan chunk directories")
+	}
+
+	return workbooks, nil
+}
+
+// workbookFilters converts path arguments into workbook key prefixes
+func workbookFilters(gitRoot string, paths []string) []string {
+	filters := make([]string, 0, len(paths))
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(gitRoot, abs)
+		if err != nil {
+			continue
+		}
+		filters = append(filters, filepath.ToSlash(rel))
+	}
+	return filters
+}
+
+// matchesWorkbookFilters reports whether a workbook key falls under any filter
+func matchesWorkbookFilters(key string, filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, filter := range filters {
+		// Chunk directories written by older versions omit the file extension
+		trimmed := strings.TrimSuffix(filter, filepath.Ext(filter))
+		if filter == "." || key == filter || key == trimmed || strings.HasPrefix(key, filter+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// workbookDisplayName restores the original file extension of a workbook
+// key when the chunk directory name does not carry it
+func workbookDisplayName(key string, docs ...*models.ExcelDocument) string {
+	if isExcelFile(key) {
+		return key
+	}
+	for _, doc := range docs {
+		if ext := filepath.Ext(doc.Metadata.OriginalFile); ext != "" {
+			return key + ext
+		}
+	}
+	return key
+}
+
+// excelIncludePatterns returns glob patterns matching every Excel extension
+func excelIncludePatterns() []string {
+	patterns := make([]string, len(constants.ExcelExtensions))
+	for i, ext := range constants.ExcelExtensions {
+		patterns[i] = "*" + ext
+	}
+	return patterns
+}
+
+// isExcelFile reports whether path has an Excel extension
+func isExcelFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, excelExt := range constants.ExcelExtensions {
+		if ext == excelExt {
+			return true
+		}
+	}
+	return false
+}
+
+// unionKeys returns the sorted union of the keys of two maps
+func unionKeys(a, b map[string]string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	keys := make([]string, 0, len(a)+len(b))
+	for _, m := range []map[string]string{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeBatchDiff writes an aggregate diff in the requested format
+func writeBatchDiff(w io.Writer, batch *models.BatchDiff, output diffOutput) error {
+	switch output.format {
+	case "json":
+		return outputDiffJSON(w, batch)
+	case "html":
+		_, err := io.WriteString(w, batch.ToHTMLReport())
+		return err
+	default:
+		return outputBatchText(w, batch, output.summaryOnly, output.useColor)
+	}
+}
+
+func outputBatchText(w io.Writer, batch *models.BatchDiff, summaryOnly, useColor bool) error {
+	c := newDiffColors(useColor)
+
+	fmt.Fprintf(w, "%s=== Diff Summary: %s → %s ===%s\n", c.blue, batch.OldLabel, batch.NewLabel, c.reset)
+	fmt.Fprintf(w, "Timestamp: %s\n", batch.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Workbooks compared: %d\n", batch.Summary.FilesCompared)
+	fmt.Fprintf(w, "Changes: %s\n", batch.String())
+	fmt.Fprintln(w)
+
+	if len(batch.Files) == 0 {
+		fmt.Fprintln(w, "No workbooks found")
+		return nil
+	}
+
+	for _, file := range batch.Files {
+		switch {
+		case file.Error != "":
+			fmt.Fprintf(w, "  %s! %s%s: %s\n", c.red, file.Path, c.reset, file.Error)
+		case file.Status == models.ChangeTypeAdd:
+			fmt.Fprintf(w, "  %s+ %s%s (%s)\n", c.green, file.Path, c.reset, file.Diff.String())
+		case file.Status == models.ChangeTypeDelete:
+			fmt.Fprintf(w, "  %s- %s%s (%s)\n", c.red, file.Path, c.reset, file.Diff.String())
+		case file.Status == models.ChangeTypeModify:
+			fmt.Fprintf(w, "  %s~ %s%s (%s)\n", c.yellow, file.Path, c.reset, file.Diff.String())
+		default:
+			fmt.Fprintf(w, "  %s  %s (unchanged)%s\n", c.gray, file.Path, c.reset)
+		}
+	}
+	fmt.Fprintln(w)
+
+	if summaryOnly {
+		return nil
+	}
+
+	for _, file := range batch.Files {
+		if file.Status == "" || file.Diff == nil {
+			continue
+		}
+		fmt.Fprintf(w, "%s### %s ###%s\n\n", c.blue, file.Path, c.reset)
+		writeSheetDiffs(w, file.Diff, c, useColor)
+	}
+
+	return nil
+}
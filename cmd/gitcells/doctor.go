@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// doctorFinding is one result of a doctor check
+type doctorFinding struct {
+	OK      bool
+	Message string
+	// Fixable findings are corrected by 'gitcells doctor --fix'
+	Fixable bool
+}
+
+func newDoctorCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the repository matches the configured binary storage",
+		Long: `Check that the repository stores Excel files the way git.binary_storage in
+.gitcells.yaml says it should:
+
+  git     Excel files are committed as ordinary binary files
+  lfs     Excel files are committed through Git LFS
+  ignore  Excel files are not committed; the JSON chunks are the source of truth
+
+The check covers the GitCells entries in .gitattributes and .gitignore, the
+Git LFS setup and the Excel files already in the index. --fix rewrites the
+.gitattributes and .gitignore entries; files committed the wrong way have
+to be fixed by hand.`,
+		Args: cobra.NoArgs,
+		// A failed check is not a usage error
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, _ := cmd.Flags().GetString("config")
+			fix, _ := cmd.Flags().GetBool("fix")
+			return runDoctor(cmd.OutOrStdout(), configPath, fix, logger)
+		},
+	}
+
+	cmd.Flags().Bool("fix", false, "rewrite the GitCells entries in .gitattributes and .gitignore")
+
+	return cmd
+}
+
+func runDoctor(w io.Writer, configPath string, fix bool, logger *logrus.Logger) error {
+	gitRoot, err := git.FindRepositoryRoot(".")
+	if err != nil {
+		return err
+	}
+	client, err := git.NewClient(gitRoot, &git.Config{}, logger)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadRepositoryConfig(gitRoot, configPath)
+	if err != nil {
+		return err
+	}
+	storage, err := git.ParseBinaryStorage(cfg.Git.BinaryStorage)
+	if err != nil {
+		return err
+	}
+
+	if fix {
+		files, err := writeStorageFiles(gitRoot, storage)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			fmt.Fprintf(w, "🔧 Updated %s\n", file)
+		}
+	}
+
+	findings, err := checkBinaryStorage(client, storage)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "🩺 Binary storage: %s\n", storage)
+	problems, fixable := 0, 0
+	for _, finding := range findings {
+		if finding.OK {
+			fmt.Fprintf(w, "   ✅ %s\n", finding.Message)
+			continue
+		}
+		fmt.Fprintf(w, "   ❌ %s\n", finding.Message)
+		problems++
+		if finding.Fixable {
+			fixable++
+		}
+	}
+
+	if problems == 0 {
+		return nil
+	}
+	if fixable > 0 {
+		fmt.Fprintln(w, "\n💡 Hint: Run 'gitcells doctor --fix' to rewrite .gitattributes and .gitignore")
+	}
+	return utils.NewError(utils.ErrorTypeValidation, "doctor", fmt.Sprintf("%d problem(s) found", problems))
+}
+
+// checkBinaryStorage compares the repository with a binary storage mode
+func checkBinaryStorage(client *git.Client, storage git.BinaryStorage) ([]doctorFinding, error) {
+	var findings []doctorFinding
+
+	for _, file := range []struct {
+		Name  string
+		Lines []string
+	}{
+		{".gitattributes", git.AttributesFor(storage)},
+		{".gitignore", git.IgnoresFor(storage)},
+	} {
+		lines, found, err := git.ReadManagedBlock(filepath.Join(client.Root(), file.Name))
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !found:
+			findings = append(findings, doctorFinding{Message: file.Name + " has no GitCells entries", Fixable: true})
+		case strings.Join(lines, "\n") != strings.Join(file.Lines, "\n"):
+			findings = append(findings, doctorFinding{Message: file.Name + " entries do not match " + string(storage) + " storage", Fixable: true})
+		default:
+			findings = append(findings, doctorFinding{OK: true, Message: file.Name + " entries match"})
+		}
+	}
+
+	if storage == git.BinaryStorageLFS {
+		if _, err := exec.LookPath("git-lfs"); err != nil {
+			findings = append(findings, doctorFinding{Message: "git-lfs is not installed"})
+		}
+		if client.LFSConfigured() {
+			findings = append(findings, doctorFinding{OK: true, Message: "Git LFS filter is configured"})
+		} else {
+			findings = append(findings, doctorFinding{Message: "Git LFS filter is not configured; run 'git lfs install'"})
+		}
+	}
+
+	tracked, err := client.TrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(tracked)
+
+	mismatched := 0
+	for _, relPath := range tracked {
+		if !isExcelFile(relPath) || strings.HasPrefix(path.Base(relPath), constants.ExcelTempPrefix) {
+			continue
+		}
+		if message := checkTrackedWorkbook(client, storage, relPath); message != "" {
+			findings = append(findings, doctorFinding{Message: relPath + ": " + message})
+			mismatched++
+		}
+	}
+	if mismatched == 0 {
+		findings = append(findings, doctorFinding{OK: true, Message: "tracked Excel files match " + string(storage) + " storage"})
+	}
+
+	return findings, nil
+}
+
+// checkTrackedWorkbook returns why a tracked workbook does not match a
+// binary storage mode, or "" if it does
+func checkTrackedWorkbook(client *git.Client, storage git.BinaryStorage, relPath string) string {
+	if storage == git.BinaryStorageIgnore {
+		return "committed although Excel files are ignored; run 'git rm --cached' on it"
+	}
+
+	reader, err := client.ReadStaged(relPath)
+	if err != nil {
+		return fmt.Sprintf("failed to read staged content: %v", err)
+	}
+	defer reader.Close()
+
+	pointer := git.IsLFSPointer(reader)
+	switch {
+	case storage == git.BinaryStorageLFS && !pointer:
+		return "committed as a binary file instead of through Git LFS; run 'git add --renormalize' on it"
+	case storage == git.BinaryStorageGit && pointer:
+		return "committed through Git LFS although binary storage is git"
+	}
+	return ""
+}
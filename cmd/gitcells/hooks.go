@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/config"
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/Classic-Homes/gitcells/pkg/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// hookScriptHeader starts every hook script. %[1]s is the hook name and
+// %[2]s the shell-quoted gitcells binary.
+const hookScriptHeader = `#!/bin/sh
+` + git.HookMarker + `: %[1]s
+# Installed by 'gitcells hooks install'; remove with 'gitcells hooks uninstall'.
+
+hook_dir=$(dirname "$0")
+if [ -x "$hook_dir/%[1]s` + git.ChainedHookSuffix + `" ]; then
+	"$hook_dir/%[1]s` + git.ChainedHookSuffix + `" "$@" || exit $?
+fi
+
+gitcells=%[2]s
+if ! command -v "$gitcells" >/dev/null 2>&1; then
+	echo "gitcells: $gitcells not found, skipping %[1]s hook" >&2
+	exit 0
+fi
+
+`
+
+// gitHooks maps each hook GitCells installs to the command it runs, which
+// finds the gitcells binary in $gitcells
+var gitHooks = []struct {
+	Name    string
+	Command string
+}{
+	{"pre-commit", `exec "$gitcells" check`},
+	{"post-checkout", `"$gitcells" hooks refresh --from "$1" || true`},
+	{"post-merge", `"$gitcells" hooks refresh --from ORIG_HEAD || true`},
+}
+
+// hookScript renders the script of a hook that runs binary
+func hookScript(name, command, binary string) string {
+	quoted := "'" + strings.ReplaceAll(binary, "'", `'\''`) + "'"
+	return fmt.Sprintf(hookScriptHeader, name, quoted) + command + "\n"
+}
+
+func newHooksCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Manage git hooks that keep Excel files and chunks in step",
+		Long: `Install or remove git hooks for GitCells.
+
+pre-commit     runs 'gitcells check' and blocks commits whose staged Excel
+               files and .gitcells/data chunks don't match
+post-checkout  rebuilds workbooks from their chunks after a checkout
+post-merge     rebuilds workbooks from their chunks after a merge or pull
+
+Existing hooks are kept: they are renamed to <hook>` + git.ChainedHookSuffix + ` and run
+before the GitCells hook. core.hooksPath is honoured.`,
+	}
+
+	cmd.AddCommand(
+		newHooksInstallCommand(logger),
+		newHooksUninstallCommand(logger),
+		newHooksRefreshCommand(logger),
+	)
+
+	return cmd
+}
+
+func newHooksInstallCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the pre-commit, post-checkout and post-merge hooks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			binary, _ := cmd.Flags().GetString("binary")
+
+			client, err := openRepository(logger)
+			if err != nil {
+				return err
+			}
+
+			for _, hook := range gitHooks {
+				state, err := client.InstallHook(hook.Name, hookScript(hook.Name, hook.Command, binary))
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", hook.Name, state)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("binary", "gitcells", "gitcells command the hooks run")
+
+	return cmd
+}
+
+func newHooksUninstallCommand(logger *logrus.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the GitCells hooks and restore chained hooks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := openRepository(logger)
+			if err != nil {
+				return err
+			}
+
+			for _, hook := range gitHooks {
+				state, err := client.UninstallHook(hook.Name)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", hook.Name, state)
+			}
+			return nil
+		},
+	}
+}
+
+func newHooksRefreshCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Rebuild workbooks that are out of date with their chunks",
+		Long: `Rebuild Excel files from their .gitcells/data chunks. This is run by the
+post-checkout and post-merge hooks.
+
+Workbooks tracked by git are left to git. A workbook that differs from its
+chunks is only overwritten when it matched the chunks at the --from
+revision, so local edits that were never converted are kept.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, _ := cmd.Flags().GetString("from")
+
+			client, err := openRepository(logger)
+			if err != nil {
+				return err
+			}
+			return refreshWorkbooks(cmd.OutOrStdout(), client, from, logger)
+		},
+	}
+
+	cmd.Flags().String("from", "", "revision the working tree was at before the checkout or merge")
+
+	return cmd
+}
+
+// openRepository opens the git repository containing the current directory
+func openRepository(logger *logrus.Logger) (*git.Client, error) {
+	gitRoot, err := git.FindRepositoryRoot(".")
+	if err != nil {
+		return nil, err
+	}
+	return git.NewClient(gitRoot, &git.Config{}, logger)
+}
+
+// loadRepositoryConfig loads configPath, or the repository's .gitcells.yaml
+// if configPath is empty
+func loadRepositoryConfig(gitRoot, configPath string) (*config.Config, error) {
+	if configPath == "" {
+		if _, err := os.Stat(filepath.Join(gitRoot, constants.ConfigFileName)); err == nil {
+			configPath = filepath.Join(gitRoot, constants.ConfigFileName)
+		}
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeConfig, "loadConfig", configPath, "failed to load config")
+	}
+	return cfg, nil
+}
+
+// refreshWorkbooks rebuilds every untracked workbook whose chunks changed
+func refreshWorkbooks(w io.Writer, client *git.Client, from string, logger *logrus.Logger) error {
+	dataDir := filepath.Join(client.Root(), constants.GitCellsDataDir)
+	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	conv := converter.NewConverter(logger)
+	refreshed := 0
+
+	err := filepath.WalkDir(dataDir, func(walkPath string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || !strings.HasSuffix(d.Name(), constants.ChunksDirSuffix) {
+			return nil
+		}
+
+		relChunkDir, err := filepath.Rel(client.Root(), walkPath)
+		if err != nil {
+			return filepath.SkipDir
+		}
+		relChunkDir = filepath.ToSlash(relChunkDir)
+
+		workbook, ok := git.WorkbookForChunkPath(client.Root(), path.Join(relChunkDir, constants.WorkbookFileName))
+		if !ok {
+			return filepath.SkipDir
+		}
+
+		done, err := refreshWorkbook(client, conv, walkPath, relChunkDir, workbook, from)
+		if err != nil {
+			fmt.Fprintf(w, "⚠️  %s: %v\n", workbook, err)
+		} else if done {
+			fmt.Fprintf(w, "🔄 Refreshed %s\n", workbook)
+			refreshed++
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "refresh", dataDir, "failed to scan chunk directories")
+	}
+
+	if refreshed > 0 {
+		fmt.Fprintf(w, "✅ Refreshed %d workbook(s) from chunks\n", refreshed)
+	}
+	return nil
+}
+
+// refreshWorkbook rebuilds one workbook from its chunk directory if needed
+// and safe, and reports whether it did
+func refreshWorkbook(client *git.Client, conv converter.Converter, chunkDir, relChunkDir, workbook, from string) (bool, error) {
+	if client.IsTracked(workbook) {
+		return false, nil
+	}
+
+	metadata, err := readJSONMetadata(filepath.Join(chunkDir, constants.WorkbookFileName))
+	if err != nil {
+		return false, fmt.Errorf("failed to read chunk metadata: %w", err)
+	}
+
+	excelPath := filepath.Join(client.Root(), filepath.FromSlash(workbook))
+	if info, err := os.Stat(excelPath); err == nil {
+		if workbookInSync(excelPath, info.ModTime(), metadata) {
+			return false, nil
+		}
+		if !workbookMatchedAt(client, from, relChunkDir, excelPath, info.ModTime()) {
+			return false, fmt.Errorf("has changes that are not in its chunks, leaving it unchanged")
+		}
+	}
+
+	doc, err := conv.ReadChunks(chunkDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to read chunks: %w", err)
+	}
+	options := converter.ConvertOptions{
+		PreserveFormulas: true,
+		PreserveStyles:   true,
+		PreserveComments: true,
+	}
+	if err := os.MkdirAll(filepath.Dir(excelPath), dirPermissions); err != nil {
+		return false, err
+	}
+	if err := conv.JSONToExcel(doc, excelPath, options); err != nil {
+		return false, fmt.Errorf("failed to write workbook: %w", err)
+	}
+
+	// A rebuilt workbook is byte-for-byte different from the original, so
+	// record that it matches the chunks through its modification time
+	if err := os.Chtimes(excelPath, time.Now(), metadata.Modified); err != nil {
+		return true, fmt.Errorf("failed to set modification time: %w", err)
+	}
+	return true, nil
+}
+
+// workbookMatchedAt reports whether a workbook matched its chunks as they
+// were at rev, meaning it holds no edits of its own
+func workbookMatchedAt(client *git.Client, rev, relChunkDir, excelPath string, modTime time.Time) bool {
+	if rev == "" {
+		return false
+	}
+
+	reader, err := client.ReadFileAt(rev, path.Join(relChunkDir, constants.WorkbookFileName))
+	if err != nil {
+		return false
+	}
+	defer reader.Close()
+
+	var doc models.ExcelDocument
+	if err := json.NewDecoder(reader).Decode(&doc); err != nil {
+		return false
+	}
+	return workbookInSync(excelPath, modTime, &doc.Metadata)
+}
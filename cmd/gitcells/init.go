@@ -4,14 +4,17 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/Classic-Homes/gitcells/internal/git"
 	"github.com/Classic-Homes/gitcells/internal/tui"
 	"github.com/Classic-Homes/gitcells/internal/utils"
-	"github.com/go-git/go-git/v5"
+	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -26,6 +29,19 @@ git:
   user_name: "GitCells"
   user_email: "gitcells@localhost"
   commit_template: "GitCells: {action} {filename} at {timestamp}"
+  commit_batch_window: 0s
+  commit_body: false
+  binary_storage: git
+  signing:
+    format: ""
+    key: ""
+    use_agent: false
+  session:
+    enabled: false
+    strategy: squash
+  attribution:
+    mode: fixed
+    authors_file: ""
 
 watcher:
   directories: []
@@ -38,6 +54,16 @@ watcher:
     - ".xlsx"
     - ".xls"
     - ".xlsm"
+  on_delete: remove
+  wait_for_unlock: false
+  backend: auto
+  poll_interval: 2s
+  poll_hash: false
+  retry_attempts: 5
+  retry_delay: 2s
+  reconcile_on_start: true
+  reconcile_interval: 0s
+  max_concurrency: 2
 
 converter:
   preserve_formulas: true
@@ -48,6 +74,43 @@ converter:
   max_cells_per_sheet: 1000000
 `
 
+// writeStorageFiles writes the GitCells entries for a binary storage mode to
+// .gitattributes and .gitignore in dir and returns the files it changed
+func writeStorageFiles(dir string, storage git.BinaryStorage) ([]string, error) {
+	var changed []string
+	for name, lines := range map[string][]string{
+		".gitattributes": git.AttributesFor(storage),
+		".gitignore":     git.IgnoresFor(storage),
+	} {
+		path := filepath.Join(dir, name)
+		updated, err := git.WriteManagedBlock(path, lines)
+		if err != nil {
+			return changed, err
+		}
+		if updated {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// setupLFS installs the Git LFS filters for the repository in dir
+func setupLFS(ctx context.Context, dir string, logger *logrus.Logger) {
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		logger.Warn("Git LFS is not installed. Install it and run 'git lfs install' before committing Excel files")
+		return
+	}
+
+	lfsCmd := exec.CommandContext(ctx, "git", "lfs", "install", "--local")
+	lfsCmd.Dir = dir
+	if output, err := lfsCmd.CombinedOutput(); err != nil {
+		logger.Warnf("Failed to set up Git LFS: %v: %s", err, strings.TrimSpace(string(output)))
+		return
+	}
+	logger.Info("Git LFS set up for this repository")
+}
+
 // initTimeout is the maximum time to wait for initialization operations
 const initTimeout = 10 * time.Second
 
@@ -162,8 +225,18 @@ func newInitCommand(logger *logrus.Logger) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "init [directory]",
 		Short: "Initialize GitCells in a directory",
-		Long:  "Initialize GitCells configuration and Git repository in the specified directory",
-		Args:  cobra.MaximumNArgs(1),
+		Long: `Initialize GitCells configuration and Git repository in the specified directory.
+
+--binary-storage decides how the Excel files themselves are kept in git:
+
+  git     commit them as ordinary binary files (default)
+  lfs     commit them through Git LFS
+  ignore  keep them out of git; the JSON chunks in .gitcells/data are the
+          source of truth
+
+The choice is written to .gitcells.yaml, .gitattributes and .gitignore.
+Run 'gitcells doctor' to check the repository still matches it.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Check if TUI mode is requested
 			useTUI, _ := cmd.Flags().GetBool("tui")
@@ -213,8 +286,15 @@ func newInitCommand(logger *logrus.Logger) *cobra.Command {
 				}
 			}
 
+			storageFlag, _ := cmd.Flags().GetString("binary-storage")
+			storage, err := git.ParseBinaryStorage(storageFlag)
+			if err != nil {
+				return err
+			}
+			configContent := strings.Replace(defaultConfig, "binary_storage: git", "binary_storage: "+string(storage), 1)
+
 			err = timeoutOperation(ctx, logger, "config file creation", func() error {
-				return os.WriteFile(configPath, []byte(defaultConfig), filePermissions)
+				return os.WriteFile(configPath, []byte(configContent), filePermissions)
 			})
 			if err != nil {
 				suggestSolution(err, absDir, logger)
@@ -223,52 +303,38 @@ func newInitCommand(logger *logrus.Logger) *cobra.Command {
 
 			logger.Infof("Created GitCells configuration at %s", configPath)
 
-			// Create .gitignore
-			gitignorePath := filepath.Join(absDir, ".gitignore")
-			gitignoreContent := `# Excel temporary files
-~$*.xlsx
-~$*.xls
-~$*.xlsm
-*.tmp
-
-# OS files
-.DS_Store
-Thumbs.db
-
-# GitCells
-.gitcells.cache/
-`
-			err = timeoutOperation(ctx, logger, ".gitignore creation", func() error {
-				return os.WriteFile(gitignorePath, []byte(gitignoreContent), filePermissions)
-			})
+			// Record how Excel files are stored in .gitattributes and .gitignore
+			files, err := writeStorageFiles(absDir, storage)
 			if err != nil {
-				logger.Warnf("Failed to create .gitignore: %v", err)
+				logger.Warnf("Failed to update git storage files: %v", err)
 				suggestSolution(err, absDir, logger)
 			} else {
-				logger.Info("Created .gitignore file")
+				for _, file := range files {
+					logger.Infof("Updated %s for %s binary storage", file, storage)
+				}
 			}
 
 			// Initialize git repo if requested
 			initGit, _ := cmd.Flags().GetBool("git")
 			if initGit {
-				var repo *git.Repository
-				var worktree *git.Worktree
+				var repo *gogit.Repository
+				var worktree *gogit.Worktree
 
 				// Check if directory is already a git repository with timeout
 				err = timeoutOperation(ctx, logger, "git repository check", func() error {
 					var err error
-					repo, err = git.PlainOpen(absDir)
+					repo, err = gogit.PlainOpen(absDir)
 					return err
 				})
 
 				switch err {
 				case nil:
 					logger.Info("Directory is already a git repository")
-				case git.ErrRepositoryNotExists:
+				case gogit.ErrRepositoryNotExists:
 					// Initialize new git repository with timeout
 					err = timeoutOperation(ctx, logger, "git repository initialization", func() error {
 						var err error
-						repo, err = git.PlainInit(absDir, false)
+						repo, err = gogit.PlainInit(absDir, false)
 						return err
 					})
 					if err != nil {
@@ -294,9 +360,11 @@ Thumbs.db
 							logger.Warnf("Failed to add .gitcells.yaml to git: %v", err)
 						}
 
-						// Add .gitignore to git
-						if _, err := worktree.Add(".gitignore"); err != nil {
-							logger.Warnf("Failed to add .gitignore to git: %v", err)
+						// Add .gitignore and .gitattributes to git
+						for _, file := range []string{".gitignore", ".gitattributes"} {
+							if _, err := worktree.Add(file); err != nil {
+								logger.Warnf("Failed to add %s to git: %v", file, err)
+							}
 						}
 
 						// Check if there are changes to commit
@@ -307,7 +375,7 @@ Thumbs.db
 
 						if !status.IsClean() {
 							// Create initial commit
-							_, err := worktree.Commit("Initial GitCells setup", &git.CommitOptions{
+							_, err := worktree.Commit("Initial GitCells setup", &gogit.CommitOptions{
 								Author: &object.Signature{
 									Name:  "GitCells",
 									Email: "gitcells@localhost",
@@ -333,6 +401,10 @@ Thumbs.db
 				}
 			}
 
+			if initGit && storage == git.BinaryStorageLFS {
+				setupLFS(ctx, absDir, logger)
+			}
+
 			logger.Info("GitCells initialized successfully!")
 			return nil
 		},
@@ -341,6 +413,7 @@ Thumbs.db
 	cmd.Flags().Bool("force", false, "overwrite existing configuration")
 	cmd.Flags().Bool("git", true, "initialize git repository")
 	cmd.Flags().Bool("tui", false, "use TUI setup wizard")
+	cmd.Flags().String("binary-storage", string(git.BinaryStorageGit), "how to store Excel files: git, lfs or ignore (JSON chunks only)")
 
 	return cmd
 }
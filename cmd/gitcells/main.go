@@ -28,10 +28,20 @@ func main() {
 	rootCmd.AddCommand(
 		newInitCommand(logger),
 		newWatchCommand(logger),
+		newDaemonCommand(logger),
 		newSyncCommand(logger),
 		newConvertCommand(logger),
 		newStatusCommand(logger),
 		newDiffCommand(logger),
+		newApplyCommand(logger),
+		newRestoreCommand(logger),
+		newCheckCommand(logger),
+		newHooksCommand(logger),
+		newDoctorCommand(logger),
+		newSessionCommand(logger),
+		newSnapshotCommand(logger),
+		newChangelogCommand(logger),
+		newUndoCommand(logger),
 		newUpdateCommand(logger),
 		newVersionCommand(logger),
 		newTUICommand(logger),
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/Classic-Homes/gitcells/internal/config"
+	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+// liveSettings are the configuration keys a running watcher applies when
+// the configuration file changes; the others take effect on restart
+var liveSettings = []string{
+	"watcher.ignore_patterns",
+	"watcher.file_extensions",
+	"watcher.debounce_delay",
+	"watcher.wait_for_unlock",
+	"converter.",
+}
+
+// liveConfig is the configuration of a running watcher, updated when the
+// configuration file changes
+type liveConfig struct {
+	mu  sync.RWMutex
+	cfg *config.Config
+
+	// watcherConfig builds the watcher configuration, applying the
+	// command line flags that override the file
+	watcherConfig func(*config.Config) *watcher.Config
+	// overridden maps the keys set by flags to those flags
+	overridden map[string]string
+	logger     *logrus.Logger
+}
+
+func newLiveConfig(cfg *config.Config, watcherConfig func(*config.Config) *watcher.Config, overridden map[string]string, logger *logrus.Logger) *liveConfig {
+	return &liveConfig{
+		cfg:           cfg,
+		watcherConfig: watcherConfig,
+		overridden:    overridden,
+		logger:        logger,
+	}
+}
+
+// ConvertOptions returns the converter options in effect
+func (l *liveConfig) ConvertOptions() converter.ConvertOptions {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return converter.OptionsFromConfig(l.cfg.Converter)
+}
+
+// Apply applies the settings of cfg that can change while watching to fw
+// and logs what changed, returning whether the set of workbooks watched may
+// have changed
+func (l *liveConfig) Apply(cfg *config.Config, fw *watcher.FileWatcher) bool {
+	l.mu.Lock()
+	changes := configChanges("", reflect.ValueOf(*l.cfg), reflect.ValueOf(*cfg))
+	l.cfg = cfg
+	l.mu.Unlock()
+
+	if len(changes) == 0 {
+		l.logger.Info("Configuration file saved without changes")
+		return false
+	}
+
+	var restart []string
+	workbooksChanged := false
+	for _, change := range changes {
+		switch {
+		case l.overridden[change.key] != "":
+			l.logger.Infof("Configuration changed %s, but --%s overrides it", change.key, l.overridden[change.key])
+		case isLiveSetting(change.key):
+			l.logger.Infof("Configuration changed %s from %v to %v", change.key, change.old, change.new)
+			if change.key == "watcher.ignore_patterns" || change.key == "watcher.file_extensions" {
+				workbooksChanged = true
+			}
+		default:
+			restart = append(restart, change.key)
+		}
+	}
+	if len(restart) > 0 {
+		l.logger.Warnf("Restart the watcher to apply changes to %s", strings.Join(restart, ", "))
+	}
+
+	fw.Reconfigure(l.watcherConfig(cfg))
+	return workbooksChanged
+}
+
+func isLiveSetting(key string) bool {
+	for _, live := range liveSettings {
+		if key == live || (strings.HasSuffix(live, ".") && strings.HasPrefix(key, live)) {
+			return true
+		}
+	}
+	return false
+}
+
+// configChange is a configuration key whose value changed
+type configChange struct {
+	key      string
+	old, new interface{}
+}
+
+// configChanges compares two configurations key by key, naming keys as
+// they are written in the configuration file
+func configChanges(prefix string, old, new reflect.Value) []configChange {
+	var changes []configChange
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		key := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+
+		if field.Type.Kind() == reflect.Struct {
+			changes = append(changes, configChanges(key+".", old.Field(i), new.Field(i))...)
+			continue
+		}
+		if !reflect.DeepEqual(old.Field(i).Interface(), new.Field(i).Interface()) {
+			changes = append(changes, configChange{key: key, old: old.Field(i).Interface(), new: new.Field(i).Interface()})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// backupSuffix is appended to a workbook replaced by an in-place restore.
+// It deliberately is not an Excel extension so the backup is not tracked.
+const backupSuffix = ".bak"
+
+func newRestoreCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <workbook>",
+		Short: "Rebuild a workbook from a historical revision",
+		Long: `Rebuild an Excel workbook from the chunk data committed at a git revision,
+without checking out the repository.
+
+The revision may be a commit, branch, tag, an expression such as HEAD~3, or
+a date (YYYY-MM-DD, "YYYY-MM-DD HH:MM" or RFC 3339). A date selects the last
+commit on the current branch made at or before it.
+
+Examples:
+  gitcells restore Budget.xlsx --rev HEAD~3 -o Budget-old.xlsx
+  gitcells restore Budget.xlsx --rev v1.2 -o Budget-v1.2.xlsx
+  gitcells restore Budget.xlsx --rev 2024-05-31 --in-place`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRestore(cmd, args[0], logger)
+		},
+	}
+
+	cmd.Flags().String("rev", "", "Revision to restore from: commit, branch, tag or date (required)")
+	cmd.Flags().StringP("output", "o", "", "Write the restored workbook to this path")
+	cmd.Flags().Bool("in-place", false, "Replace the workbook, keeping a timestamped backup of the current file")
+	cmd.Flags().Bool("no-backup", false, "Do not keep a backup when restoring in place")
+	_ = cmd.MarkFlagRequired("rev")
+
+	return cmd
+}
+
+func runRestore(cmd *cobra.Command, workbook string, logger *logrus.Logger) error {
+	rev, _ := cmd.Flags().GetString("rev")
+	outputPath, _ := cmd.Flags().GetString("output")
+	inPlace, _ := cmd.Flags().GetBool("in-place")
+	noBackup, _ := cmd.Flags().GetBool("no-backup")
+
+	if outputPath == "" && !inPlace {
+		return utils.NewError(utils.ErrorTypeValidation, "restore", "specify --output or --in-place")
+	}
+	if outputPath != "" && inPlace {
+		return utils.NewError(utils.ErrorTypeValidation, "restore", "--output and --in-place cannot be combined")
+	}
+	if !isExcelFile(workbook) {
+		return utils.NewError(utils.ErrorTypeValidation, "restore", fmt.Sprintf("not an Excel file: %s", workbook))
+	}
+
+	absWorkbook, err := filepath.Abs(workbook)
+	if err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "restore", workbook, "failed to resolve path")
+	}
+
+	gitRoot, err := git.FindRepositoryRoot(filepath.Dir(absWorkbook))
+	if err != nil {
+		return err
+	}
+	client, err := git.NewClient(gitRoot, &git.Config{}, logger)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return utils.NewError(utils.ErrorTypeGit, "restore", "not a git repository")
+	}
+
+	commit, err := client.ResolveRevision(rev)
+	if err != nil {
+		return err
+	}
+
+	relPath, err := filepath.Rel(gitRoot, absWorkbook)
+	if err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "restore", workbook, "workbook is outside the repository")
+	}
+
+	tempDir, err := os.MkdirTemp("", "gitcells-restore-*")
+	if err != nil {
+		return utils.WrapError(err, utils.ErrorTypeFileSystem, "restore", "failed to create temporary directory")
+	}
+	defer os.RemoveAll(tempDir)
+
+	chunkDir, err := exportWorkbookChunks(client, commit.Hash.String(), relPath, tempDir)
+	if err != nil {
+		return err
+	}
+	if chunkDir == "" {
+		return utils.NewError(utils.ErrorTypeValidation, "restore",
+			fmt.Sprintf("%s is not tracked at revision %s (%s)", relPath, rev, commit.Hash.String()[:7]))
+	}
+
+	conv := converter.NewConverter(logger)
+	doc, err := conv.ReadChunks(chunkDir)
+	if err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeConverter, "restore", relPath, "failed to read chunks")
+	}
+
+	backup := ""
+	if inPlace {
+		outputPath = workbook
+		if !noBackup {
+			if backup, err = backupWorkbook(workbook); err != nil {
+				return err
+			}
+		}
+	}
+
+	options := converter.ConvertOptions{
+		PreserveFormulas: true,
+		PreserveStyles:   true,
+		PreserveComments: true,
+	}
+	if err := conv.JSONToExcel(doc, outputPath, options); err != nil {
+		if backup != "" {
+			// Put the original back rather than leaving the user without it
+			_ = os.Rename(backup, workbook)
+		}
+		return utils.WrapFileError(err, utils.ErrorTypeConverter, "restore", outputPath, "failed to write workbook")
+	}
+
+	if backup != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "Backed up current workbook to %s\n", backup)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Restored %s from %s (%s, %s) to %s\n",
+		relPath, rev, commit.Hash.String()[:7], commit.Committer.When.Format("2006-01-02 15:04"), outputPath)
+	return nil
+}
+
+// exportWorkbookChunks exports the chunk directory of a workbook at rev into
+// destRoot and returns its path, or "" if the workbook is not tracked there.
+// Chunk directories written by older versions omit the file extension.
+func exportWorkbookChunks(client *git.Client, rev, relPath, destRoot string) (string, error) {
+	relDir := filepath.ToSlash(filepath.Dir(relPath))
+	name := filepath.Base(relPath)
+
+	for _, chunkName := range []string{name, strings.TrimSuffix(name, filepath.Ext(name))} {
+		dir := filepath.ToSlash(filepath.Join(constants.GitCellsDataDir, relDir, chunkName+constants.ChunksDirSuffix))
+		count, err := client.ExportTree(rev, dir, destRoot)
+		if err != nil {
+			return "", err
+		}
+		if count > 0 {
+			return filepath.Join(destRoot, filepath.FromSlash(dir)), nil
+		}
+	}
+	return "", nil
+}
+
+// backupWorkbook renames a workbook to a timestamped backup next to it. It
+// returns the backup path, or "" if the workbook does not exist.
+func backupWorkbook(workbook string) (string, error) {
+	if _, err := os.Stat(workbook); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	backup := fmt.Sprintf("%s.%s%s", workbook, time.Now().Format("2006-01-02-15-04-05"), backupSuffix)
+	if err := os.Rename(workbook, backup); err != nil {
+		return "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "restore", workbook, "failed to back up workbook")
+	}
+	return backup, nil
+}
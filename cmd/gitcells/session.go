@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newSessionCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session",
+		Short: "Collect auto-commits on a session branch",
+		Long: `Work on a gitcells/<user>/<date> session branch so auto-commits from watch
+don't land on the main branch one by one.
+
+'watch --session' (or git.session.enabled) starts or continues a session
+before committing. 'session finish' then adds the whole session to the
+branch it was started from, as one squashed commit or as a merge commit,
+with a message summarising the workbooks and commits in it.`,
+	}
+
+	cmd.AddCommand(
+		newSessionStartCommand(logger),
+		newSessionStatusCommand(logger),
+		newSessionFinishCommand(logger),
+	)
+
+	return cmd
+}
+
+func newSessionStartCommand(logger *logrus.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "start",
+		Short: "Check out today's session branch, creating it from the current branch",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := openRepository(logger)
+			if err != nil {
+				return err
+			}
+
+			session, err := client.StartSession(git.SessionUser())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "🌿 On session branch %s (target %s, %d commit(s))\n",
+				session.Branch, session.Target, session.Commits)
+			return nil
+		},
+	}
+}
+
+func newSessionStatusCommand(logger *logrus.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the current session and the message finishing it would use",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := openRepository(logger)
+			if err != nil {
+				return err
+			}
+
+			branch, err := client.CurrentBranch()
+			if err != nil {
+				return err
+			}
+			session, err := client.SessionInfo(branch)
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "Not on a session branch (current branch: %s)\n", branch)
+				return nil
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "🌿 Session: %s\n", session.Branch)
+			fmt.Fprintf(w, "   Target:  %s\n", session.Target)
+			fmt.Fprintf(w, "   Commits: %d\n", session.Commits)
+			if session.Commits > 0 {
+				summary, err := client.SessionSummary(branch)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "\nFinishing would commit:\n\n%s\n", summary)
+			}
+			return nil
+		},
+	}
+}
+
+func newSessionFinishCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "finish",
+		Short: "Add the session to its target branch and check the target out",
+		Long: `Add a session to the branch it was started from and check that branch out.
+
+--strategy squash  one commit with the session's changes (default)
+--strategy merge   a merge commit, keeping the session commits in history
+
+The target branch must not have commits the session lacks; bring them into
+the session with git first. Tracked files must have no uncommitted changes.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, _ := cmd.Flags().GetString("config")
+			branch, _ := cmd.Flags().GetString("branch")
+			keepBranch, _ := cmd.Flags().GetBool("keep-branch")
+
+			gitRoot, err := git.FindRepositoryRoot(".")
+			if err != nil {
+				return err
+			}
+			cfg, err := loadRepositoryConfig(gitRoot, configPath)
+			if err != nil {
+				return err
+			}
+
+			strategy := cfg.Git.Session.Strategy
+			if cmd.Flags().Changed("strategy") {
+				strategy, _ = cmd.Flags().GetString("strategy")
+			}
+
+			// The finishing commit uses the configured author and signing key
+			client, err := git.NewClient(gitRoot, &git.Config{
+				UserName:    cfg.Git.UserName,
+				UserEmail:   cfg.Git.UserEmail,
+				Signing:     git.SigningConfig(cfg.Git.Signing),
+				Attribution: git.AttributionConfig(cfg.Git.Attribution),
+			}, logger)
+			if err != nil {
+				return err
+			}
+
+			if branch == "" {
+				if branch, err = client.CurrentBranch(); err != nil {
+					return err
+				}
+			}
+			session, err := client.SessionInfo(branch)
+			if err != nil {
+				return err
+			}
+
+			hash, err := client.FinishSession(branch, strategy, keepBranch)
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			if hash.IsZero() {
+				fmt.Fprintf(w, "✅ Session %s had no commits; switched to %s\n", session.Branch, session.Target)
+			} else {
+				fmt.Fprintf(w, "✅ Finished session %s into %s (%s, %d commit(s)) as %s\n",
+					session.Branch, session.Target, strategy, session.Commits, hash.String()[:8])
+			}
+			if !keepBranch {
+				fmt.Fprintf(w, "   Deleted branch %s\n", session.Branch)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("strategy", git.SessionSquash, "how to add the session: squash or merge (overrides git.session.strategy)")
+	cmd.Flags().String("branch", "", "session branch to finish (default: the current branch)")
+	cmd.Flags().Bool("keep-branch", false, "keep the session branch after finishing")
+
+	return cmd
+}
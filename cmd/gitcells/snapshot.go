@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/Classic-Homes/gitcells/pkg/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newSnapshotCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Create, list and compare named versions of workbooks",
+		Long: `Freeze named versions of workbooks, for example at month-end close.
+
+A snapshot is an annotated git tag named snapshot/<name> on the current
+commit. Its message carries a JSON manifest listing each workbook with a
+SHA-256 checksum of its chunk data. Restore a workbook from a snapshot with
+'gitcells restore <workbook> --rev snapshot/<name>'.`,
+	}
+
+	cmd.AddCommand(
+		newSnapshotCreateCommand(logger),
+		newSnapshotListCommand(logger),
+		newSnapshotDiffCommand(logger),
+	)
+
+	return cmd
+}
+
+func newSnapshotCreateCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <name> [workbooks...]",
+		Short: "Tag the current commit as a snapshot of workbooks",
+		Long: `Tag the current commit as a snapshot of the given workbooks or directories,
+or of every workbook with committed chunk data if none are given. The
+chunk data of the selected workbooks must have no uncommitted changes.
+
+Examples:
+  gitcells snapshot create 2026-03
+  gitcells snapshot create 2026-03-budget Budget.xlsx reports/ -m "Approved by board"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, _ := cmd.Flags().GetString("config")
+			note, _ := cmd.Flags().GetString("message")
+
+			gitRoot, err := git.FindRepositoryRoot(".")
+			if err != nil {
+				return err
+			}
+			cfg, err := loadRepositoryConfig(gitRoot, configPath)
+			if err != nil {
+				return err
+			}
+
+			// The tag uses the configured tagger and signing key
+			client, err := git.NewClient(gitRoot, &git.Config{
+				UserName:    cfg.Git.UserName,
+				UserEmail:   cfg.Git.UserEmail,
+				Signing:     git.SigningConfig(cfg.Git.Signing),
+				Attribution: git.AttributionConfig(cfg.Git.Attribution),
+			}, logger)
+			if err != nil {
+				return err
+			}
+
+			snapshot, err := client.CreateSnapshot(args[0], workbookFilters(gitRoot, args[1:]), note)
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "📸 Created snapshot %s (tag %s) at %s\n", snapshot.Name, snapshot.Tag, snapshot.Commit.String()[:8])
+			for _, workbook := range snapshot.Manifest.Workbooks {
+				fmt.Fprintf(w, "   %s  %s\n", workbook.SHA256[:12], workbook.Path)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("message", "m", "", "note to store with the snapshot")
+
+	return cmd
+}
+
+func newSnapshotListCommand(logger *logrus.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List snapshots, oldest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := openRepository(logger)
+			if err != nil {
+				return err
+			}
+
+			snapshots, err := client.Snapshots()
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			if len(snapshots) == 0 {
+				fmt.Fprintln(w, "No snapshots")
+				return nil
+			}
+			for _, snapshot := range snapshots {
+				fmt.Fprintf(w, "📸 %s  %s  %s  %d workbook(s)  %s\n",
+					snapshot.Name,
+					snapshot.Manifest.Created.Local().Format("2006-01-02 15:04"),
+					snapshot.Commit.String()[:8],
+					len(snapshot.Manifest.Workbooks),
+					snapshot.Tagger.Name)
+				if note := firstLine(snapshot.Manifest.Note); note != "" {
+					fmt.Fprintf(w, "   %s\n", note)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newSnapshotDiffCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <a> <b>",
+		Short: "Compare the workbooks of two snapshots",
+		Long: `Compare the workbooks listed in either of two snapshots, cell by cell.
+Workbooks whose checksums match in both manifests are reported unchanged.
+
+Examples:
+  gitcells snapshot diff 2026-02 2026-03
+  gitcells snapshot diff 2026-02 2026-03 --summary
+  gitcells snapshot diff 2026-02 2026-03 --format html -o close.html`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSnapshotDiff(cmd, args[0], args[1], logger)
+		},
+	}
+
+	cmd.Flags().Bool("summary", false, "Show only summary of changes")
+	cmd.Flags().Bool("no-color", false, "Disable colored output")
+	cmd.Flags().String("format", "text", "Output format: text, json, html")
+	cmd.Flags().StringP("output", "o", "", "Write output to a file instead of stdout")
+
+	return cmd
+}
+
+func runSnapshotDiff(cmd *cobra.Command, a, b string, logger *logrus.Logger) error {
+	summaryOnly, _ := cmd.Flags().GetBool("summary")
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	format, _ := cmd.Flags().GetString("format")
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	if format != "text" && format != "json" && format != "html" {
+		return utils.NewError(utils.ErrorTypeValidation, "snapshot", fmt.Sprintf("unsupported format: %s", format))
+	}
+
+	client, err := openRepository(logger)
+	if err != nil {
+		return err
+	}
+	oldSnapshot, err := client.Snapshot(a)
+	if err != nil {
+		return err
+	}
+	newSnapshot, err := client.Snapshot(b)
+	if err != nil {
+		return err
+	}
+
+	// Compare every workbook in either manifest; the checksums decide which
+	// ones need a cell-level comparison
+	checksums := make(map[string]string)
+	for _, workbook := range oldSnapshot.Manifest.Workbooks {
+		checksums[workbook.Path] = workbook.SHA256
+	}
+	var filters, unchanged []string
+	seen := make(map[string]bool)
+	for _, workbook := range newSnapshot.Manifest.Workbooks {
+		seen[workbook.Path] = true
+		if checksums[workbook.Path] == workbook.SHA256 {
+			unchanged = append(unchanged, workbook.Path)
+			continue
+		}
+		filters = append(filters, workbook.Path)
+	}
+	for _, workbook := range oldSnapshot.Manifest.Workbooks {
+		if !seen[workbook.Path] {
+			filters = append(filters, workbook.Path)
+		}
+	}
+
+	batch := models.NewBatchDiff(oldSnapshot.Tag, newSnapshot.Tag)
+	// diffCommits compares every workbook when given no filters
+	if len(filters) > 0 {
+		settings := diffSettings{keepDocuments: format == "html"}
+		if batch, err = diffCommits(client, oldSnapshot.Tag, newSnapshot.Tag, filters, settings, logger); err != nil {
+			return err
+		}
+	}
+	for _, path := range unchanged {
+		batch.AddWorkbook(path, nil, true, true)
+	}
+	sort.SliceStable(batch.Files, func(i, j int) bool { return batch.Files[i].Path < batch.Files[j].Path })
+
+	out := io.Writer(cmd.OutOrStdout())
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "snapshot", outputPath, "failed to create output file")
+		}
+		defer file.Close()
+		out = file
+	}
+
+	return writeBatchDiff(out, batch, diffOutput{
+		format:      format,
+		summaryOnly: summaryOnly,
+		useColor:    !noColor && outputPath == "",
+	})
+}
+
+// firstLine returns the first line of s
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
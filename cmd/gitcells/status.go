@@ -10,8 +10,11 @@ import (
 	"time"
 
 	"github.com/Classic-Homes/gitcells/internal/constants"
-	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/daemon"
+	"github.com/Classic-Homes/gitcells/internal/ignore"
 	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/Classic-Homes/gitcells/internal/watcher"
 	"github.com/Classic-Homes/gitcells/pkg/models"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -37,6 +40,7 @@ func newStatusCommand(logger *logrus.Logger) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			detailed, _ := cmd.Flags().GetBool("detailed")
 			includePatterns, _ := cmd.Flags().GetStringSlice("include")
+			excludePatterns, _ := cmd.Flags().GetStringSlice("exclude")
 
 			// Get current directory
 			dir := "."
@@ -44,14 +48,34 @@ func newStatusCommand(logger *logrus.Logger) *cobra.Command {
 				dir = args[0]
 			}
 
+			if clear, _ := cmd.Flags().GetBool("clear-failed"); clear {
+				// A running daemon keeps the queue in memory and must clear it
+				var cleared int
+				var err error
+				if client, ok := daemon.Running(dir); ok {
+					cleared, err = client.ClearFailed()
+				} else {
+					cleared, err = watcher.ClearFailed(watcher.QueuePath(dir))
+				}
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Cleared %d failed changes\n", cleared)
+				return nil
+			}
+
+			// Changes the watcher has not committed yet, or gave up on
+			queue, daemonStatus := readWatcherState(dir, logger)
+
 			// Find all Excel files
-			excelFiles, err := findExcelFiles(dir, includePatterns)
+			excelFiles, err := findExcelFiles(dir, includePatterns, excludePatterns)
 			if err != nil {
 				return utils.WrapError(err, utils.ErrorTypeFileSystem, "findExcelFiles", "failed to scan for Excel files")
 			}
 
 			if len(excelFiles) == 0 {
 				fmt.Println("No Excel files found in the current directory")
+				displayWatcher(queue, daemonStatus)
 				return nil
 			}
 
@@ -68,19 +92,27 @@ func newStatusCommand(logger *logrus.Logger) *cobra.Command {
 
 			// Display results
 			displayStatus(statuses, detailed)
+			displayWatcher(queue, daemonStatus)
 
 			return nil
 		},
 	}
 
 	cmd.Flags().Bool("detailed", false, "show detailed status information")
-	cmd.Flags().StringSlice("include", []string{"*.xlsx", "*.xls", "*.xlsm"}, "file patterns to include")
+	cmd.Flags().StringSlice("include", []string{"*.xlsx", "*.xls", "*.xlsm"}, "gitignore-style patterns of files to include")
+	cmd.Flags().StringSlice("exclude", []string{constants.ExcelTempPrefix + "*", constants.TempFilePattern}, "gitignore-style patterns of files to exclude")
+	cmd.Flags().Bool("clear-failed", false, "clear the list of changes the watcher failed to process")
 
 	return cmd
 }
 
-func findExcelFiles(dir string, patterns []string) ([]string, error) {
+// findExcelFiles returns the files below dir that match the include
+// patterns and are not excluded by the exclude patterns or a
+// .gitcellsignore file. Patterns follow gitignore rules, relative to dir.
+func findExcelFiles(dir string, include, exclude []string) ([]string, error) {
 	var files []string
+	includes := ignore.Parse(include, dir)
+	excludes := ignore.NewMatcher(dir, exclude)
 
 	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -100,22 +132,16 @@ func findExcelFiles(dir string, patterns []string) ([]string, error) {
 			return nil
 		}
 
-		if d.IsDir() {
+		if excludes.Match(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
-		// Check if file matches any pattern
-		for _, pattern := range patterns {
-			matched, err := filepath.Match(pattern, d.Name())
-			if err != nil {
-				continue
-			}
-			if matched {
-				files = append(files, path)
-				break
-			}
+		if !d.IsDir() && includes.Match(path, false) {
+			files = append(files, path)
 		}
-
 		return nil
 	})
 
@@ -136,25 +162,8 @@ func getFileStatus(excelPath string, logger *logrus.Logger) (FileStatus, error)
 	status.ExcelModTime = excelInfo.ModTime()
 	status.ExcelSize = excelInfo.Size()
 
-	// Determine JSON path
-	gitRoot, err := git.FindRepositoryRoot(filepath.Dir(excelPath))
-	if err != nil {
-		// If not in a git repo, use local .gitcells directory
-		gitRoot = "."
-	}
-
-	relPath, err := filepath.Rel(gitRoot, excelPath)
-	if err != nil {
-		relPath = excelPath
-	}
-
-	jsonDir := filepath.Join(gitRoot, constants.GitCellsDataDir, filepath.Dir(relPath))
-	baseName := strings.TrimSuffix(filepath.Base(excelPath), filepath.Ext(excelPath))
-
 	// Check for chunked files only
-	chunkDir := filepath.Join(jsonDir, baseName+constants.ChunksDirSuffix)
-	workbookJsonPath := filepath.Join(chunkDir, constants.WorkbookFileName)
-	status.JSONPath = workbookJsonPath
+	status.JSONPath = filepath.Join(converter.ChunkDir(excelPath), constants.WorkbookFileName)
 
 	// Check if JSON exists
 	jsonInfo, err := os.Stat(status.JSONPath)
@@ -182,19 +191,30 @@ func getFileStatus(excelPath string, logger *logrus.Logger) (FileStatus, error)
 		}
 	} else {
 		// Use metadata for more accurate status
-		if metadata.Modified.Equal(status.ExcelModTime) || metadata.Modified.After(status.ExcelModTime) {
+		status.LastSyncTime = &metadata.Created
+		if workbookInSync(excelPath, status.ExcelModTime, metadata) {
 			status.Status = "synced"
-			status.LastSyncTime = &metadata.Created
 		} else {
 			status.Status = "modified"
 			status.HasChanges = true
-			status.LastSyncTime = &metadata.Created
 		}
 	}
 
 	return status, nil
 }
 
+// workbookInSync reports whether a workbook matches the chunks described by
+// metadata: its checksum is the one recorded at conversion, or it has not
+// been modified since, as with workbooks rebuilt from chunks
+func workbookInSync(excelPath string, modTime time.Time, metadata *models.DocumentMetadata) bool {
+	if metadata.Checksum != "" {
+		if checksum, err := converter.FileChecksum(excelPath); err == nil && checksum == metadata.Checksum {
+			return true
+		}
+	}
+	return !modTime.After(metadata.Modified)
+}
+
 func readJSONMetadata(jsonPath string) (*models.DocumentMetadata, error) {
 	file, err := os.Open(jsonPath)
 	if err != nil {
@@ -263,6 +283,57 @@ func displayStatus(statuses []FileStatus, detailed bool) {
 	}
 }
 
+// readWatcherState returns the watcher's event queue, live from the daemon
+// along with its status if one is running, or else from the queue file
+func readWatcherState(dir string, logger *logrus.Logger) (watcher.QueueState, *daemon.Status) {
+	if client, ok := daemon.Running(dir); ok {
+		status, err := client.Status()
+		if err == nil {
+			var queue watcher.QueueState
+			if queue, err = client.Queue(); err == nil {
+				return queue, &status
+			}
+		}
+		logger.Warnf("Failed to reach the watcher daemon: %v", err)
+	}
+
+	queue, err := watcher.ReadQueue(watcher.QueuePath(dir))
+	if err != nil {
+		logger.Warnf("Failed to read the watcher's event queue: %v", err)
+	}
+	return queue, nil
+}
+
+// displayWatcher shows the state of the watcher daemon, if one is running,
+// and the watcher's event queue
+func displayWatcher(queue watcher.QueueState, daemonStatus *daemon.Status) {
+	if daemonStatus != nil {
+		fmt.Println()
+		displayDaemonStatus(*daemonStatus)
+	}
+	displayQueue(queue)
+}
+
+// displayQueue lists the changes the watcher has yet to process and those
+// it gave up on after retrying
+func displayQueue(queue watcher.QueueState) {
+	if len(queue.Pending) > 0 {
+		fmt.Printf("\n⏳ %d changes waiting to be processed by the watcher\n", len(queue.Pending))
+	}
+	if len(queue.Failed) == 0 {
+		return
+	}
+
+	fmt.Println("\n⚠️  Failed Changes")
+	fmt.Println("─────────────────")
+	for _, item := range queue.Failed {
+		fmt.Printf("❌ %s %s\n", item.Event.Type, item.Event.Path)
+		fmt.Printf("   Failed: %s after %d attempts\n", item.FailedAt.Format("2006-01-02 15:04:05"), item.Attempts)
+		fmt.Printf("   Error:  %s\n", item.LastError)
+	}
+	fmt.Println("\n💡 Hint: Save the workbook again to retry, or run 'gitcells status --clear-failed' to clear this list")
+}
+
 func getStatusIcon(status string) string {
 	switch status {
 	case "synced":
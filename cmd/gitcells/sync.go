@@ -33,21 +33,24 @@ func newSyncCommand(logger *logrus.Logger) *cobra.Command {
 			}
 
 			// Load configuration
-			cfg, err := config.Load(dir)
+			configPath, _ := cmd.Flags().GetString("config")
+			if configPath == "" {
+				if _, err := os.Stat(filepath.Join(dir, constants.ConfigFileName)); err == nil {
+					configPath = filepath.Join(dir, constants.ConfigFileName)
+				}
+			}
+			cfg, err := config.Load(configPath)
 			if err != nil {
 				logger.Warnf("Failed to load config, using defaults: %v", err)
 				cfg = config.GetDefault()
 			}
 
 			// Find all Excel files
-			excelFiles, err := findExcelFiles(dir, includePatterns)
+			excelFiles, err := findExcelFiles(dir, includePatterns, excludePatterns)
 			if err != nil {
 				return utils.WrapError(err, utils.ErrorTypeFileSystem, "findExcelFiles", "failed to scan for Excel files")
 			}
 
-			// Filter out excluded patterns
-			excelFiles = filterExcludedFiles(excelFiles, excludePatterns)
-
 			if len(excelFiles) == 0 {
 				fmt.Println("No Excel files found to sync")
 				return nil
@@ -108,7 +111,7 @@ func newSyncCommand(logger *logrus.Logger) *cobra.Command {
 					ChunkingStrategy:           cfg.Converter.ChunkingStrategy,
 				}
 
-				err := conv.ExcelToJSONFile(fileStatus.ExcelPath, fileStatus.JSONPath, options)
+				err := conv.ExcelToJSONFile(fileStatus.ExcelPath, fileStatus.ExcelPath, options)
 				if err != nil {
 					fmt.Printf("❌ Error: %v\n", err)
 					logger.Errorf("Failed to convert %s: %v", fileStatus.ExcelPath, err)
@@ -145,6 +148,8 @@ func newSyncCommand(logger *logrus.Logger) *cobra.Command {
 					UserName:       cfg.Git.UserName,
 					UserEmail:      cfg.Git.UserEmail,
 					CommitTemplate: cfg.Git.CommitTemplate,
+					Signing:        git.SigningConfig(cfg.Git.Signing),
+					Attribution:    git.AttributionConfig(cfg.Git.Attribution),
 				}
 
 				gitClient, err := git.NewClient(gitRoot, gitCfg, logger)
@@ -173,36 +178,12 @@ func newSyncCommand(logger *logrus.Logger) *cobra.Command {
 	for i, ext := range constants.ExcelExtensions {
 		includePatterns[i] = "*" + ext
 	}
-	cmd.Flags().StringSlice("include", includePatterns, "file patterns to include")
-	cmd.Flags().StringSlice("exclude", []string{constants.ExcelTempPrefix + "*", constants.TempFilePattern}, "file patterns to exclude")
+	cmd.Flags().StringSlice("include", includePatterns, "gitignore-style patterns of files to include")
+	cmd.Flags().StringSlice("exclude", []string{constants.ExcelTempPrefix + "*", constants.TempFilePattern}, "gitignore-style patterns of files to exclude")
 
 	return cmd
 }
 
-func filterExcludedFiles(files []string, excludePatterns []string) []string {
-	var filtered []string
-	for _, file := range files {
-		excluded := false
-		fileName := filepath.Base(file)
-
-		for _, pattern := range excludePatterns {
-			matched, err := filepath.Match(pattern, fileName)
-			if err != nil {
-				continue
-			}
-			if matched {
-				excluded = true
-				break
-			}
-		}
-
-		if !excluded {
-			filtered = append(filtered, file)
-		}
-	}
-	return filtered
-}
-
 func generateCommitMessage(template string, fileCount int) string {
 	message := template
 
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newUndoCommand(logger *logrus.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo",
+		Short: "Revert the most recent GitCells commit",
+		Long: `Revert the most recent commit GitCells made on the current branch with a new
+commit, like 'git revert'. GitCells commits are recognised by the
+git.user_name and git.user_email committer and a subject matching
+git.commit_template. Running undo again reverts the GitCells commit before.
+
+Undo refuses if later commits changed any of the same files, or if those
+files have uncommitted changes.
+
+--rebuild also writes the affected workbooks from the restored chunks,
+keeping a timestamped backup of each current file.
+
+Examples:
+  gitcells undo --dry-run
+  gitcells undo --rebuild`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUndo(cmd, logger)
+		},
+	}
+
+	cmd.Flags().Bool("rebuild", false, "Rebuild the affected workbooks from the restored chunks")
+	cmd.Flags().Bool("no-backup", false, "Do not keep a backup of workbooks replaced by --rebuild")
+	cmd.Flags().Bool("dry-run", false, "Show the commit that would be reverted without changing anything")
+
+	return cmd
+}
+
+func runUndo(cmd *cobra.Command, logger *logrus.Logger) error {
+	configPath, _ := cmd.Flags().GetString("config")
+	rebuild, _ := cmd.Flags().GetBool("rebuild")
+	noBackup, _ := cmd.Flags().GetBool("no-backup")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	gitRoot, err := git.FindRepositoryRoot(".")
+	if err != nil {
+		return err
+	}
+	cfg, err := loadRepositoryConfig(gitRoot, configPath)
+	if err != nil {
+		return err
+	}
+	client, err := git.NewClient(gitRoot, &git.Config{
+		UserName:    cfg.Git.UserName,
+		UserEmail:   cfg.Git.UserEmail,
+		Signing:     git.SigningConfig(cfg.Git.Signing),
+		Attribution: git.AttributionConfig(cfg.Git.Attribution),
+	}, logger)
+	if err != nil {
+		return err
+	}
+
+	plan, err := client.PlanUndo(cfg.Git.CommitTemplate)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	subject, _, _ := strings.Cut(plan.Commit.Message, "\n")
+	fmt.Fprintf(w, "↩️  Reverting %s %s (%s)\n", plan.Commit.Hash.String()[:8], subject,
+		plan.Commit.Author.When.Format("2006-01-02 15:04"))
+	for _, workbook := range plan.Workbooks {
+		fmt.Fprintf(w, "   %s\n", workbook)
+	}
+	if dryRun {
+		return nil
+	}
+
+	hash, err := client.Undo(plan)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "✅ Committed revert %s\n", hash.String()[:8])
+
+	if !rebuild {
+		if len(plan.Workbooks) > 0 {
+			fmt.Fprintln(w, "\n💡 Hint: Run 'gitcells undo' with --rebuild, or 'gitcells restore', to update the workbooks themselves")
+		}
+		return nil
+	}
+
+	conv := converter.NewConverter(logger)
+	options := converter.ConvertOptions{
+		PreserveFormulas: true,
+		PreserveStyles:   true,
+		PreserveComments: true,
+	}
+	failed := 0
+	for _, workbook := range plan.Workbooks {
+		// Workbooks committed as files were already restored by the revert
+		if containsString(plan.Files, workbook) {
+			continue
+		}
+
+		workbookPath := filepath.Join(gitRoot, filepath.FromSlash(workbook))
+		chunkDir := converter.ChunkDir(workbookPath)
+		if _, err := os.Stat(chunkDir); os.IsNotExist(err) {
+			fmt.Fprintf(w, "   %s has no chunks before the reverted commit; left as it is\n", workbook)
+			continue
+		}
+
+		if err := rebuildWorkbook(conv, chunkDir, workbookPath, options, noBackup, w); err != nil {
+			fmt.Fprintf(w, "❌ %s: %v\n", workbook, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(w, "🔄 Rebuilt %s\n", workbook)
+	}
+
+	if failed > 0 {
+		return utils.NewError(utils.ErrorTypeConverter, "undo", fmt.Sprintf("failed to rebuild %d workbook(s)", failed))
+	}
+	return nil
+}
+
+// rebuildWorkbook writes a workbook from its chunks, backing up the current
+// file unless noBackup is set
+func rebuildWorkbook(conv converter.Converter, chunkDir, workbookPath string, options converter.ConvertOptions, noBackup bool, w io.Writer) error {
+	doc, err := conv.ReadChunks(chunkDir)
+	if err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeConverter, "undo", chunkDir, "failed to read chunks")
+	}
+
+	backup := ""
+	if !noBackup {
+		if backup, err = backupWorkbook(workbookPath); err != nil {
+			return err
+		}
+	}
+	if err := conv.JSONToExcel(doc, workbookPath, options); err != nil {
+		if backup != "" {
+			_ = os.Rename(backup, workbookPath)
+		}
+		return utils.WrapFileError(err, utils.ErrorTypeConverter, "undo", workbookPath, "failed to write workbook")
+	}
+	if backup != "" {
+		fmt.Fprintf(w, "   Backed up current workbook to %s\n", backup)
+	}
+	return nil
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
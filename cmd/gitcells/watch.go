@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/Classic-Homes/gitcells/internal/config"
+	"github.com/Classic-Homes/gitcells/internal/constants"
 	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/daemon"
 	"github.com/Classic-Homes/gitcells/internal/git"
 	"github.com/Classic-Homes/gitcells/internal/utils"
 	"github.com/Classic-Homes/gitcells/internal/watcher"
@@ -30,6 +32,14 @@ func newWatchCommand(logger *logrus.Logger) *cobra.Command {
 				return utils.WrapFileError(err, utils.ErrorTypeConfig, "watch", configPath, "failed to load config")
 			}
 
+			// Two watchers would convert and commit every change twice
+			runDaemon, _ := cmd.Flags().GetBool("daemon")
+			daemonPaths := daemon.PathsFor(".")
+			if pid := daemon.ReadPID(daemonPaths); pid != 0 && pid != os.Getpid() {
+				return utils.NewError(utils.ErrorTypeValidation, "watch",
+					fmt.Sprintf("the watcher daemon (pid %d) is already watching this repository; stop it with 'gitcells daemon stop'", pid))
+			}
+
 			// Override config with command flags
 			autoCommit, _ := cmd.Flags().GetBool("auto-commit")
 			autoPush, _ := cmd.Flags().GetBool("auto-push")
@@ -42,8 +52,12 @@ func newWatchCommand(logger *logrus.Logger) *cobra.Command {
 			conv := converter.NewConverter(logger)
 
 			gitConfig := &git.Config{
-				UserName:  cfg.Git.UserName,
-				UserEmail: cfg.Git.UserEmail,
+				UserName:       cfg.Git.UserName,
+				UserEmail:      cfg.Git.UserEmail,
+				CommitTemplate: cfg.Git.CommitTemplate,
+				CommitBody:     cfg.Git.CommitBody,
+				Signing:        git.SigningConfig(cfg.Git.Signing),
+				Attribution:    git.AttributionConfig(cfg.Git.Attribution),
 			}
 
 			gitClient, err := git.NewClient(".", gitConfig, logger)
@@ -51,6 +65,67 @@ func newWatchCommand(logger *logrus.Logger) *cobra.Command {
 				return utils.WrapError(err, utils.ErrorTypeGit, "watch", "failed to initialize git client")
 			}
 
+			useSession := cfg.Git.Session.Enabled
+			if cmd.Flags().Changed("session") {
+				useSession, _ = cmd.Flags().GetBool("session")
+			}
+			if useSession && autoCommit && gitClient != nil {
+				session, err := gitClient.StartSession(git.SessionUser())
+				if err != nil {
+					return err
+				}
+				logger.Infof("Committing to session branch %s; run 'gitcells session finish' to add it to %s", session.Branch, session.Target)
+			}
+
+			batchWindow := cfg.Git.CommitBatchWindow
+			if cmd.Flags().Changed("batch-window") {
+				batchWindow, _ = cmd.Flags().GetDuration("batch-window")
+			}
+			batcher := git.NewCommitBatcher(batchWindow, gitClient.CommitChanges, logger)
+
+			onDelete := cfg.Watcher.OnDelete
+			if cmd.Flags().Changed("on-delete") {
+				onDelete, _ = cmd.Flags().GetString("on-delete")
+			}
+			if onDelete != onDeleteRemove && onDelete != onDeleteArchive {
+				return utils.NewError(utils.ErrorTypeValidation, "watch", "unsupported on-delete action: "+onDelete)
+			}
+
+			// Setup watcher
+			overridden := make(map[string]string)
+			for key, flag := range map[string]string{
+				"watcher.wait_for_unlock": "wait-for-unlock",
+				"watcher.backend":         "backend",
+				"watcher.max_concurrency": "max-concurrency",
+			} {
+				if cmd.Flags().Changed(flag) {
+					overridden[key] = flag
+				}
+			}
+			watcherConfigFor := func(cfg *config.Config) *watcher.Config {
+				watcherConfig := &watcher.Config{
+					IgnorePatterns: cfg.Watcher.IgnorePatterns,
+					DebounceDelay:  cfg.Watcher.DebounceDelay,
+					FileExtensions: cfg.Watcher.FileExtensions,
+					WaitForUnlock:  cfg.Watcher.WaitForUnlock,
+					Backend:        cfg.Watcher.Backend,
+					PollInterval:   cfg.Watcher.PollInterval,
+					PollHash:       cfg.Watcher.PollHash,
+					MaxConcurrency: cfg.Watcher.MaxConcurrency,
+				}
+				if overridden["watcher.wait_for_unlock"] != "" {
+					watcherConfig.WaitForUnlock, _ = cmd.Flags().GetBool("wait-for-unlock")
+				}
+				if overridden["watcher.backend"] != "" {
+					watcherConfig.Backend, _ = cmd.Flags().GetString("backend")
+				}
+				if overridden["watcher.max_concurrency"] != "" {
+					watcherConfig.MaxConcurrency, _ = cmd.Flags().GetInt("max-concurrency")
+				}
+				return watcherConfig
+			}
+			live := newLiveConfig(cfg, watcherConfigFor, overridden, logger)
+
 			// Create event handler
 			handler := func(event watcher.FileEvent) error {
 				logger.Infof("Processing %s: %s", event.Type, event.Path)
@@ -60,46 +135,33 @@ func newWatchCommand(logger *logrus.Logger) *cobra.Command {
 					return nil
 				}
 
-				// Convert Excel to JSON using chunking
-				convertOptions := converter.ConvertOptions{
-					PreserveFormulas: cfg.Converter.PreserveFormulas,
-					PreserveStyles:   cfg.Converter.PreserveStyles,
-					PreserveComments: cfg.Converter.PreserveComments,
-					CompactJSON:      cfg.Converter.CompactJSON,
-					IgnoreEmptyCells: cfg.Converter.IgnoreEmptyCells,
-					MaxCellsPerSheet: cfg.Converter.MaxCellsPerSheet,
-					ChunkingStrategy: "sheet-based",
-				}
-
-				// The converter will automatically save to .gitcells/data directory
-				if convertErr := conv.ExcelToJSONFile(event.Path, event.Path, convertOptions); convertErr != nil {
-					return utils.WrapFileError(convertErr, utils.ErrorTypeConverter, "watch", event.Path, "failed to convert Excel to JSON")
+				change, err := watcher.UpdateChunks(conv, event, live.ConvertOptions(), onDelete == onDeleteArchive, logger)
+				if err != nil || gitClient == nil || (len(change.Files) == 0 && len(change.Removed) == 0) {
+					return err
 				}
+				return batcher.Add(change)
+			}
 
-				// Commit changes if git repository exists
-				if gitClient != nil {
-					// Get the chunk paths that were created
-					chunkPaths, err := conv.GetChunkPaths(event.Path)
-					if err != nil {
-						logger.Warnf("Failed to get chunk paths for git commit: %v", err)
-						// Fall back to committing the entire .gitcells/data directory
-						chunkPaths = []string{filepath.Join(".gitcells", "data")}
-					}
-
-					message := fmt.Sprintf("GitCells: %s %s", event.Type.String(), filepath.Base(event.Path))
-					return gitClient.AutoCommit(chunkPaths, message)
-				}
-				return nil
+			// Failed events are retried, and events not yet handled when the
+			// watcher stops are replayed on the next start
+			queue, err := watcher.OpenQueue(watcher.QueuePath("."), handler, cfg.Watcher.RetryAttempts, cfg.Watcher.RetryDelay, logger)
+			if err != nil {
+				return err
+			}
+			if failed := len(queue.Failed()); failed > 0 {
+				logger.Warnf("%d changes failed earlier; run 'gitcells status' to see them", failed)
 			}
 
-			// Setup watcher
-			watcherConfig := &watcher.Config{
-				IgnorePatterns: cfg.Watcher.IgnorePatterns,
-				DebounceDelay:  cfg.Watcher.DebounceDelay,
-				FileExtensions: cfg.Watcher.FileExtensions,
+			// As a daemon, events pass through the controller so that the
+			// control API can pause them
+			eventHandler := queue.Handle
+			var ctl *watchDaemon
+			if runDaemon {
+				ctl = newWatchDaemon(queue, batcher, args, logger)
+				eventHandler = ctl.handle
 			}
 
-			fw, err := watcher.NewFileWatcher(watcherConfig, handler, logger)
+			fw, err := watcher.NewFileWatcher(watcherConfigFor(cfg), eventHandler, logger)
 			if err != nil {
 				return utils.WrapError(err, utils.ErrorTypeWatcher, "watch", "failed to create file watcher")
 			}
@@ -113,25 +175,164 @@ func newWatchCommand(logger *logrus.Logger) *cobra.Command {
 				}
 			}
 
+			// Serve the control API before catching up, which can take a while
+			var stopRequested <-chan struct{}
+			if runDaemon {
+				ctl.fw = fw
+				if err := daemon.WritePID(daemonPaths); err != nil {
+					return err
+				}
+				defer daemon.RemovePID(daemonPaths)
+
+				server, err := daemon.Serve(daemonPaths.Socket, ctl, logger)
+				if err != nil {
+					return err
+				}
+				defer server.Close()
+				stopRequested = ctl.shutdown
+				logger.Infof("Running as daemon (pid %d), control socket %s", os.Getpid(), daemonPaths.Socket)
+			}
+
+			// Catch up before watching: first the events an earlier run did
+			// not finish, then the workbooks changed while nothing watched them
+			queue.Replay(fw.Notify)
+
+			reconcileOnStart := cfg.Watcher.ReconcileOnStart
+			if cmd.Flags().Changed("reconcile") {
+				reconcileOnStart, _ = cmd.Flags().GetBool("reconcile")
+			}
+			reconcileInterval := cfg.Watcher.ReconcileInterval
+			if cmd.Flags().Changed("reconcile-interval") {
+				reconcileInterval, _ = cmd.Flags().GetDuration("reconcile-interval")
+			}
+			if reconcileOnStart && autoCommit {
+				reconcileWorkbooks(fw, args, fw.Notify, logger)
+			}
+
 			// Start watching
 			if err := fw.Start(); err != nil {
 				return utils.WrapError(err, utils.ErrorTypeWatcher, "watch", "failed to start file watcher")
 			}
 
+			stopReconcile := make(chan struct{})
+			defer close(stopReconcile)
+			if reconcileInterval > 0 && autoCommit {
+				go reconcilePeriodically(fw, args, reconcileInterval, stopReconcile, logger)
+			}
+
+			// Apply changes to the configuration file without restarting,
+			// keeping the current configuration if the new one is invalid
+			configFile := configPath
+			if configFile == "" {
+				configFile = constants.ConfigFileName
+			}
+			reloader, err := config.WatchFile(configFile, func(newCfg *config.Config, err error) {
+				if err != nil {
+					logger.Errorf("Keeping the current configuration: %v", err)
+					return
+				}
+				if live.Apply(newCfg, fw) && autoCommit {
+					// Convert the workbooks that are no longer ignored
+					reconcileWorkbooks(fw, args, fw.Notify, logger)
+				}
+			})
+			if err != nil {
+				logger.Warnf("Changes to %s will not be applied until the watcher restarts: %v", configFile, err)
+			} else {
+				defer reloader.Close()
+			}
+
 			logger.Info("Watching for changes... Press Ctrl+C to stop")
 
 			// Wait for interrupt signal
 			sigChan := make(chan os.Signal, 1)
 			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-			<-sigChan
+			select {
+			case <-sigChan:
+			case <-stopRequested:
+			}
 
 			logger.Info("Shutting down...")
-			return fw.Stop()
+			// Stopping waits for conversions in progress, whose changes are
+			// then committed with the rest
+			stopErr := fw.Stop()
+			queue.Close()
+			if err := batcher.Flush(); err != nil {
+				logger.Errorf("Failed to commit pending changes: %v", err)
+			}
+			return stopErr
 		},
 	}
 
 	cmd.Flags().Bool("auto-commit", true, "automatically commit changes to git")
 	cmd.Flags().Bool("auto-push", false, "automatically push commits to remote")
+	cmd.Flags().Duration("batch-window", 0, "combine changes saved within this window into one commit (overrides git.commit_batch_window)")
+	cmd.Flags().Bool("session", false, "commit to a gitcells/<user>/<date> session branch (overrides git.session.enabled)")
+	cmd.Flags().String("on-delete", onDeleteRemove, "what to do with the chunks of deleted workbooks: remove or archive (overrides watcher.on_delete)")
+	cmd.Flags().Bool("wait-for-unlock", false, "convert workbooks only once they are closed in Excel or LibreOffice (overrides watcher.wait_for_unlock)")
+	cmd.Flags().String("backend", watcher.BackendAuto, "how to detect changes: fsnotify, poll or auto (overrides watcher.backend)")
+	cmd.Flags().Bool("reconcile", true, "convert workbooks changed while the watcher was stopped before watching (overrides watcher.reconcile_on_start)")
+	cmd.Flags().Duration("reconcile-interval", 0, "also check for missed changes at this interval (overrides watcher.reconcile_interval)")
+	cmd.Flags().Int("max-concurrency", watcher.DefaultMaxConcurrency, "how many workbooks to convert at once (overrides watcher.max_concurrency)")
+	cmd.Flags().Bool("daemon", false, "run as the background watcher started by 'gitcells daemon start'")
+	_ = cmd.Flags().MarkHidden("daemon")
 
 	return cmd
 }
+
+// reconcileWorkbooks passes an event to handle for every workbook in dirs
+// that 'gitcells status' would report as new or modified, that is whose
+// chunks are missing or do not match it, and returns how many there were
+func reconcileWorkbooks(fw *watcher.FileWatcher, dirs []string, handle func(watcher.FileEvent), logger *logrus.Logger) int {
+	reconciled := 0
+	for _, dir := range dirs {
+		for _, workbook := range fw.Workbooks(dir) {
+			status, err := getFileStatus(workbook, logger)
+			if err != nil {
+				logger.Warnf("Failed to get status for %s: %v", workbook, err)
+				continue
+			}
+
+			event := watcher.FileEvent{Path: workbook, Timestamp: time.Now()}
+			switch status.Status {
+			case "new":
+				event.Type = watcher.EventTypeCreate
+			case "modified":
+				event.Type = watcher.EventTypeModify
+			default:
+				continue
+			}
+
+			logger.Infof("Found %s workbook %s that was not converted", status.Status, workbook)
+			handle(event)
+			reconciled++
+		}
+	}
+	if reconciled > 0 {
+		logger.Infof("Reconciled %d workbooks changed while not watched", reconciled)
+	}
+	return reconciled
+}
+
+// reconcilePeriodically repeats the reconciliation every interval until stop
+// is closed, passing changes through the watcher so that workbooks still
+// being saved are left until they are complete
+func reconcilePeriodically(fw *watcher.FileWatcher, dirs []string, interval time.Duration, stop <-chan struct{}, logger *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			reconcileWorkbooks(fw, dirs, fw.Notify, logger)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Actions for the chunks of deleted workbooks
+const (
+	onDeleteRemove  = "remove"
+	onDeleteArchive = "archive"
+)
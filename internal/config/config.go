@@ -2,9 +2,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/Classic-Homes/gitcells/internal/ignore"
+	"github.com/Classic-Homes/gitcells/internal/utils"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
@@ -31,6 +35,45 @@ type GitConfig struct {
 	UserName       string `yaml:"user_name"`
 	UserEmail      string `yaml:"user_email"`
 	CommitTemplate string `yaml:"commit_template"`
+	// CommitBatchWindow groups changes saved within this window into one
+	// commit. Zero commits every change separately.
+	CommitBatchWindow time.Duration `yaml:"commit_batch_window"`
+	// CommitBody adds a summary of changed sheets and cells to commit messages
+	CommitBody bool `yaml:"commit_body"`
+	// BinaryStorage is how Excel files themselves are stored: git, lfs or
+	// ignore (only the JSON chunks are committed)
+	BinaryStorage string `yaml:"binary_storage"`
+	// Signing signs GitCells commits with an OpenPGP or SSH key
+	Signing SigningConfig `yaml:"signing"`
+	// Session commits watch changes to a per-user session branch
+	Session SessionConfig `yaml:"session"`
+	// Attribution chooses who is recorded as the author of commits
+	Attribution AttributionConfig `yaml:"attribution"`
+}
+
+type AttributionConfig struct {
+	// Mode is fixed (user_name and user_email), workbook (the workbook's
+	// LastModifiedBy), os (the user running GitCells) or git (git config)
+	Mode string `yaml:"mode"`
+	// AuthorsFile maps display names to email addresses
+	AuthorsFile string `yaml:"authors_file"`
+}
+
+type SessionConfig struct {
+	// Enabled makes watch commit to a gitcells/<user>/<date> branch
+	Enabled bool `yaml:"enabled"`
+	// Strategy is how 'gitcells session finish' adds the session to its
+	// target branch: squash or merge
+	Strategy string `yaml:"strategy"`
+}
+
+type SigningConfig struct {
+	// Format is openpgp or ssh; empty leaves commits unsigned
+	Format string `yaml:"format"`
+	// Key is the private key path, or with UseAgent the public key that
+	// selects an ssh-agent key
+	Key      string `yaml:"key"`
+	UseAgent bool   `yaml:"use_agent"`
 }
 
 type WatcherConfig struct {
@@ -38,6 +81,30 @@ type WatcherConfig struct {
 	IgnorePatterns []string      `yaml:"ignore_patterns"`
 	DebounceDelay  time.Duration `yaml:"debounce_delay"`
 	FileExtensions []string      `yaml:"file_extensions"`
+	// OnDelete is what happens to the chunks of a deleted workbook: remove
+	// or archive (move them to .gitcells/archive)
+	OnDelete string `yaml:"on_delete"`
+	// WaitForUnlock delays converting a workbook until it is closed in
+	// Excel or LibreOffice
+	WaitForUnlock bool `yaml:"wait_for_unlock"`
+	// Backend is how changes are detected: fsnotify, poll, or auto to poll
+	// network file systems only
+	Backend      string        `yaml:"backend"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// PollHash makes polling compare file contents rather than size and
+	// modification time
+	PollHash bool `yaml:"poll_hash"`
+	// RetryAttempts and RetryDelay control how failed events are retried
+	// before they are kept in the dead-letter list
+	RetryAttempts int           `yaml:"retry_attempts"`
+	RetryDelay    time.Duration `yaml:"retry_delay"`
+	// ReconcileOnStart converts workbooks changed while the watcher was not
+	// running before it starts handling events
+	ReconcileOnStart bool `yaml:"reconcile_on_start"`
+	// ReconcileInterval repeats that check while watching. Zero disables it.
+	ReconcileInterval time.Duration `yaml:"reconcile_interval"`
+	// MaxConcurrency is how many workbooks are converted at once
+	MaxConcurrency int `yaml:"max_concurrency"`
 }
 
 type ConverterConfig struct {
@@ -75,9 +142,28 @@ func Load(configPath string) (*Config, error) {
 	v.SetDefault("git.user_name", "GitCells")
 	v.SetDefault("git.user_email", "gitcells@localhost")
 	v.SetDefault("git.commit_template", "GitCells: {action} {filename} at {timestamp}")
+	v.SetDefault("git.commit_batch_window", "0s")
+	v.SetDefault("git.commit_body", false)
+	v.SetDefault("git.binary_storage", "git")
+	v.SetDefault("git.signing.format", "")
+	v.SetDefault("git.signing.use_agent", false)
+	v.SetDefault("git.session.enabled", false)
+	v.SetDefault("git.session.strategy", "squash")
+	v.SetDefault("git.attribution.mode", "fixed")
+	v.SetDefault("git.attribution.authors_file", "")
 	v.SetDefault("watcher.debounce_delay", "1s")
 	v.SetDefault("watcher.file_extensions", []string{".xlsx", ".xls", ".xlsm"})
 	v.SetDefault("watcher.ignore_patterns", []string{"~$*", "*.tmp"})
+	v.SetDefault("watcher.on_delete", "remove")
+	v.SetDefault("watcher.wait_for_unlock", false)
+	v.SetDefault("watcher.backend", "auto")
+	v.SetDefault("watcher.poll_interval", "2s")
+	v.SetDefault("watcher.poll_hash", false)
+	v.SetDefault("watcher.retry_attempts", 5)
+	v.SetDefault("watcher.retry_delay", "2s")
+	v.SetDefault("watcher.reconcile_on_start", true)
+	v.SetDefault("watcher.reconcile_interval", "0s")
+	v.SetDefault("watcher.max_concurrency", 2)
 	v.SetDefault("converter.preserve_formulas", true)
 	v.SetDefault("converter.preserve_styles", true)
 	v.SetDefault("converter.preserve_comments", true)
@@ -112,19 +198,45 @@ func Load(configPath string) (*Config, error) {
 	cfg := &Config{
 		Version: v.GetString("version"),
 		Git: GitConfig{
-			Remote:         v.GetString("git.remote"),
-			Branch:         v.GetString("git.branch"),
-			AutoPush:       v.GetBool("git.auto_push"),
-			AutoPull:       v.GetBool("git.auto_pull"),
-			UserName:       v.GetString("git.user_name"),
-			UserEmail:      v.GetString("git.user_email"),
-			CommitTemplate: v.GetString("git.commit_template"),
+			Remote:            v.GetString("git.remote"),
+			Branch:            v.GetString("git.branch"),
+			AutoPush:          v.GetBool("git.auto_push"),
+			AutoPull:          v.GetBool("git.auto_pull"),
+			UserName:          v.GetString("git.user_name"),
+			UserEmail:         v.GetString("git.user_email"),
+			CommitTemplate:    v.GetString("git.commit_template"),
+			CommitBatchWindow: v.GetDuration("git.commit_batch_window"),
+			CommitBody:        v.GetBool("git.commit_body"),
+			BinaryStorage:     v.GetString("git.binary_storage"),
+			Signing: SigningConfig{
+				Format:   v.GetString("git.signing.format"),
+				Key:      v.GetString("git.signing.key"),
+				UseAgent: v.GetBool("git.signing.use_agent"),
+			},
+			Session: SessionConfig{
+				Enabled:  v.GetBool("git.session.enabled"),
+				Strategy: v.GetString("git.session.strategy"),
+			},
+			Attribution: AttributionConfig{
+				Mode:        v.GetString("git.attribution.mode"),
+				AuthorsFile: v.GetString("git.attribution.authors_file"),
+			},
 		},
 		Watcher: WatcherConfig{
-			Directories:    v.GetStringSlice("watcher.directories"),
-			IgnorePatterns: v.GetStringSlice("watcher.ignore_patterns"),
-			DebounceDelay:  v.GetDuration("watcher.debounce_delay"),
-			FileExtensions: v.GetStringSlice("watcher.file_extensions"),
+			Directories:       v.GetStringSlice("watcher.directories"),
+			IgnorePatterns:    v.GetStringSlice("watcher.ignore_patterns"),
+			DebounceDelay:     v.GetDuration("watcher.debounce_delay"),
+			FileExtensions:    v.GetStringSlice("watcher.file_extensions"),
+			OnDelete:          v.GetString("watcher.on_delete"),
+			WaitForUnlock:     v.GetBool("watcher.wait_for_unlock"),
+			Backend:           v.GetString("watcher.backend"),
+			PollInterval:      v.GetDuration("watcher.poll_interval"),
+			PollHash:          v.GetBool("watcher.poll_hash"),
+			RetryAttempts:     v.GetInt("watcher.retry_attempts"),
+			RetryDelay:        v.GetDuration("watcher.retry_delay"),
+			ReconcileOnStart:  v.GetBool("watcher.reconcile_on_start"),
+			ReconcileInterval: v.GetDuration("watcher.reconcile_interval"),
+			MaxConcurrency:    v.GetInt("watcher.max_concurrency"),
 		},
 		Converter: ConverterConfig{
 			PreserveFormulas: v.GetBool("converter.preserve_formulas"),
@@ -152,6 +264,88 @@ func Load(configPath string) (*Config, error) {
 	return cfg, nil
 }
 
+// Validate reports the first watcher or converter setting that cannot be
+// used, so that a broken configuration file is refused before it is applied
+func (c *Config) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return utils.NewError(utils.ErrorTypeConfig, "Validate", fmt.Sprintf(format, args...))
+	}
+
+	w := c.Watcher
+	durations := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"watcher.debounce_delay", w.DebounceDelay},
+		{"watcher.poll_interval", w.PollInterval},
+		{"watcher.retry_delay", w.RetryDelay},
+		{"watcher.reconcile_interval", w.ReconcileInterval},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			return invalid("%s must not be negative, got %s", d.key, d.value)
+		}
+	}
+
+	if len(w.FileExtensions) == 0 {
+		return invalid("watcher.file_extensions must list at least one extension")
+	}
+	for _, ext := range w.FileExtensions {
+		if !strings.HasPrefix(ext, ".") {
+			return invalid("watcher.file_extensions entry %q must start with a dot", ext)
+		}
+	}
+	for _, pattern := range w.IgnorePatterns {
+		if err := ignore.Validate(pattern); err != nil {
+			return invalid("watcher.ignore_patterns entry %q is not a valid pattern: %v", pattern, err)
+		}
+	}
+
+	switch w.OnDelete {
+	case "", "remove", "archive":
+	default:
+		return invalid("watcher.on_delete must be remove or archive, got %q", w.OnDelete)
+	}
+	switch w.Backend {
+	case "", "auto", "fsnotify", "poll":
+	default:
+		return invalid("watcher.backend must be auto, fsnotify or poll, got %q", w.Backend)
+	}
+	if w.RetryAttempts < 0 {
+		return invalid("watcher.retry_attempts must not be negative, got %d", w.RetryAttempts)
+	}
+	if w.MaxConcurrency < 0 {
+		return invalid("watcher.max_concurrency must not be negative, got %d", w.MaxConcurrency)
+	}
+
+	g := c.Git
+	switch strings.ToLower(g.Signing.Format) {
+	case "", "openpgp", "gpg", "ssh":
+	default:
+		return invalid("git.signing.format must be openpgp or ssh, got %q", g.Signing.Format)
+	}
+	switch g.Attribution.Mode {
+	case "", "fixed", "workbook", "os", "git":
+	default:
+		return invalid("git.attribution.mode must be fixed, workbook, os or git, got %q", g.Attribution.Mode)
+	}
+	switch g.Session.Strategy {
+	case "", "squash", "merge":
+	default:
+		return invalid("git.session.strategy must be squash or merge, got %q", g.Session.Strategy)
+	}
+	switch strings.ToLower(strings.TrimSpace(g.BinaryStorage)) {
+	case "", "git", "lfs", "ignore":
+	default:
+		return invalid("git.binary_storage must be git, lfs or ignore, got %q", g.BinaryStorage)
+	}
+
+	if c.Converter.MaxCellsPerSheet < 0 {
+		return invalid("converter.max_cells_per_sheet must not be negative, got %d", c.Converter.MaxCellsPerSheet)
+	}
+	return nil
+}
+
 // Save saves the configuration to a file using YAML marshaling.
 // This approach is simpler and more maintainable than manually setting
 // each field in viper, as new fields are automatically handled.
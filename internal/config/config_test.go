@@ -22,6 +22,10 @@ func TestLoadDefaultConfig(t *testing.T) {
 	assert.Equal(t, false, cfg.Git.AutoPush)
 	assert.Equal(t, true, cfg.Git.AutoPull)
 	assert.Equal(t, "GitCells", cfg.Git.UserName)
+	assert.Zero(t, cfg.Git.CommitBatchWindow)
+	assert.Equal(t, false, cfg.Git.CommitBody)
+	assert.Equal(t, "git", cfg.Git.BinaryStorage)
+	assert.Equal(t, SessionConfig{Strategy: "squash"}, cfg.Git.Session)
 	assert.Equal(t, true, cfg.Converter.PreserveFormulas)
 	assert.Equal(t, 1000000, cfg.Converter.MaxCellsPerSheet)
 	assert.Contains(t, cfg.Watcher.FileExtensions, ".xlsx")
@@ -37,6 +41,12 @@ git:
   branch: develop
   auto_push: true
   user_name: "Test User"
+  commit_batch_window: 10s
+  commit_body: true
+  signing:
+    format: ssh
+    key: ~/.ssh/id_ed25519.pub
+    use_agent: true
 converter:
   preserve_formulas: false
   max_cells_per_sheet: 5000
@@ -55,6 +65,9 @@ converter:
 	assert.Equal(t, "develop", cfg.Git.Branch)
 	assert.Equal(t, true, cfg.Git.AutoPush)
 	assert.Equal(t, "Test User", cfg.Git.UserName)
+	assert.Equal(t, 10*time.Second, cfg.Git.CommitBatchWindow)
+	assert.Equal(t, true, cfg.Git.CommitBody)
+	assert.Equal(t, SigningConfig{Format: "ssh", Key: "~/.ssh/id_ed25519.pub", UseAgent: true}, cfg.Git.Signing)
 	assert.Equal(t, false, cfg.Converter.PreserveFormulas)
 	assert.Equal(t, 5000, cfg.Converter.MaxCellsPerSheet)
 }
@@ -223,3 +236,50 @@ func TestConfigSave(t *testing.T) {
 		assert.Equal(t, cfg.Updates.NotifyOnUpdate, loadedCfg.Updates.NotifyOnUpdate)
 	})
 }
+
+func TestValidate(t *testing.T) {
+	cfg, err := Load("")
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, GetDefault().Validate())
+
+	// Anything a .gitignore file accepts is accepted, as are the enum values
+	// in any of their spellings
+	cfg.Watcher.IgnorePatterns = []string{"# drafts", "", "!keep.xlsx", "/build/", "reports/**/draft-*.xlsx", `\#notes.xlsx`, "[Tt]emp*"}
+	cfg.Git.Signing.Format = "GPG"
+	cfg.Git.Attribution.Mode = "workbook"
+	cfg.Git.Session.Strategy = "merge"
+	cfg.Git.BinaryStorage = "LFS"
+	require.NoError(t, cfg.Validate())
+
+	tests := []struct {
+		name   string
+		change func(*Config)
+		want   string
+	}{
+		{"negative debounce delay", func(c *Config) { c.Watcher.DebounceDelay = -time.Second }, "watcher.debounce_delay"},
+		{"no extensions", func(c *Config) { c.Watcher.FileExtensions = nil }, "watcher.file_extensions"},
+		{"extension without dot", func(c *Config) { c.Watcher.FileExtensions = []string{"xlsx"} }, `"xlsx"`},
+		{"malformed ignore pattern", func(c *Config) { c.Watcher.IgnorePatterns = []string{"[abc"} }, `"[abc"`},
+		{"unknown delete action", func(c *Config) { c.Watcher.OnDelete = "keep" }, "watcher.on_delete"},
+		{"unknown backend", func(c *Config) { c.Watcher.Backend = "inotify" }, "watcher.backend"},
+		{"negative concurrency", func(c *Config) { c.Watcher.MaxConcurrency = -1 }, "watcher.max_concurrency"},
+		{"negative cell limit", func(c *Config) { c.Converter.MaxCellsPerSheet = -1 }, "converter.max_cells_per_sheet"},
+		{"negation of nothing", func(c *Config) { c.Watcher.IgnorePatterns = []string{"!"} }, "watcher.ignore_patterns"},
+		{"malformed part of a path pattern", func(c *Config) { c.Watcher.IgnorePatterns = []string{"reports/[2024/*.xlsx"} }, `"[2024"`},
+		{"unknown signing format", func(c *Config) { c.Git.Signing.Format = "x509" }, "git.signing.format"},
+		{"unknown attribution mode", func(c *Config) { c.Git.Attribution.Mode = "blame" }, "git.attribution.mode"},
+		{"unknown session strategy", func(c *Config) { c.Git.Session.Strategy = "rebase" }, "git.session.strategy"},
+		{"unknown binary storage", func(c *Config) { c.Git.BinaryStorage = "s3" }, "git.binary_storage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load("")
+			require.NoError(t, err)
+			tt.change(cfg)
+			err = cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -16,6 +16,19 @@ git:
   user_name: "" + constants.DefaultGitUserName + ""
   user_email: "" + constants.DefaultGitUserEmail + ""
   commit_template: "" + constants.DefaultCommitTemplate + ""
+  commit_batch_window: 0s
+  commit_body: false
+  binary_storage: git
+  signing:
+    format: ""
+    key: ""
+    use_agent: false
+  session:
+    enabled: false
+    strategy: squash
+  attribution:
+    mode: fixed
+    authors_file: ""
 
 watcher:
   directories: []
@@ -28,6 +41,16 @@ watcher:
     - "" + constants.ExtXLSX + ""
     - "" + constants.ExtXLS + ""
     - "" + constants.ExtXLSM + ""
+  on_delete: remove
+  wait_for_unlock: false
+  backend: auto
+  poll_interval: 2s
+  poll_hash: false
+  retry_attempts: 5
+  retry_delay: 2s
+  reconcile_on_start: true
+  reconcile_interval: 0s
+  max_concurrency: 2
 
 converter:
   preserve_formulas: true
@@ -55,18 +78,37 @@ func GetDefault() *Config {
 	return &Config{
 		Version: "1.0",
 		Git: GitConfig{
-			Branch:         "main",
-			AutoPush:       false,
-			AutoPull:       true,
-			UserName:       constants.DefaultGitUserName,
-			UserEmail:      constants.DefaultGitUserEmail,
-			CommitTemplate: constants.DefaultCommitTemplate,
+			Branch:            "main",
+			AutoPush:          false,
+			AutoPull:          true,
+			UserName:          constants.DefaultGitUserName,
+			UserEmail:         constants.DefaultGitUserEmail,
+			CommitTemplate:    constants.DefaultCommitTemplate,
+			CommitBatchWindow: 0,
+			CommitBody:        false,
+			BinaryStorage:     "git",
+			Session: SessionConfig{
+				Strategy: "squash",
+			},
+			Attribution: AttributionConfig{
+				Mode: "fixed",
+			},
 		},
 		Watcher: WatcherConfig{
-			Directories:    []string{},
-			IgnorePatterns: constants.DefaultIgnorePatterns,
-			DebounceDelay:  2 * time.Second,
-			FileExtensions: constants.ExcelExtensions,
+			Directories:       []string{},
+			IgnorePatterns:    constants.DefaultIgnorePatterns,
+			DebounceDelay:     2 * time.Second,
+			FileExtensions:    constants.ExcelExtensions,
+			OnDelete:          "remove",
+			WaitForUnlock:     false,
+			Backend:           "auto",
+			PollInterval:      2 * time.Second,
+			PollHash:          false,
+			RetryAttempts:     5,
+			RetryDelay:        2 * time.Second,
+			ReconcileOnStart:  true,
+			ReconcileInterval: 0,
+			MaxConcurrency:    2,
 		},
 		Converter: ConverterConfig{
 			PreserveFormulas: true,
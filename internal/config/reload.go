@@ -0,0 +1,117 @@
+package config
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/fsnotify/fsnotify"
+)
+
+// ReloadDelay is how long a configuration file must stay unchanged before it
+// is reloaded, so that a save is read once the editor has finished writing
+const ReloadDelay = 500 * time.Millisecond
+
+// Reloader loads a configuration file again whenever it changes
+type Reloader struct {
+	path     string
+	watcher  *fsnotify.Watcher
+	onChange func(*Config, error)
+
+	mu    sync.Mutex
+	timer *time.Timer
+	done  chan struct{}
+}
+
+// WatchFile calls onChange with the configuration loaded from path each time
+// the file is written or replaced. If the file cannot be read or fails
+// Validate, onChange gets the error instead. The directory is watched rather
+// than the file, since many editors save by replacing it.
+func WatchFile(path string, onChange func(*Config, error)) (*Reloader, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeConfig, "WatchFile", path, "failed to resolve config path")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeConfig, "WatchFile", "failed to create config watcher")
+	}
+	if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+		_ = watcher.Close()
+		return nil, utils.WrapFileError(err, utils.ErrorTypeConfig, "WatchFile", absPath, "failed to watch config directory")
+	}
+
+	r := &Reloader{
+		path:     absPath,
+		watcher:  watcher,
+		onChange: onChange,
+		done:     make(chan struct{}),
+	}
+	go r.run()
+	return r, nil
+}
+
+func (r *Reloader) run() {
+	for {
+		select {
+		case <-r.done:
+			return
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != r.path || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			r.schedule()
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			r.onChange(nil, utils.WrapError(err, utils.ErrorTypeConfig, "WatchFile", "config watcher error"))
+		}
+	}
+}
+
+// schedule reloads the file once it has not changed for ReloadDelay
+func (r *Reloader) schedule() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.timer = time.AfterFunc(ReloadDelay, r.reload)
+}
+
+func (r *Reloader) reload() {
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+
+	cfg, err := Load(r.path)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		r.onChange(nil, err)
+		return
+	}
+	r.onChange(cfg, nil)
+}
+
+// Close stops watching the file
+func (r *Reloader) Close() error {
+	r.mu.Lock()
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.mu.Unlock()
+
+	close(r.done)
+	return r.watcher.Close()
+}
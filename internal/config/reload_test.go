@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".gitcells.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("watcher:\n  debounce_delay: 1s\n"), 0600))
+
+	var mu sync.Mutex
+	var loaded []*Config
+	var errs []error
+	reloader, err := WatchFile(configPath, func(cfg *Config, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		loaded = append(loaded, cfg)
+	})
+	require.NoError(t, err)
+	defer reloader.Close()
+
+	t.Run("reloads a changed file once it is written", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte("watcher:\n  debounce_delay: 5s\n"), 0600))
+
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(loaded) == 1
+		}, 5*time.Second, 50*time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, 5*time.Second, loaded[0].Watcher.DebounceDelay)
+	})
+
+	t.Run("reports a file that fails validation", func(t *testing.T) {
+		// Saved by replacing the file, as many editors do
+		replacement := configPath + ".tmp"
+		require.NoError(t, os.WriteFile(replacement, []byte("watcher:\n  backend: inotify\n"), 0600))
+		require.NoError(t, os.Rename(replacement, configPath))
+
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(errs) == 1
+		}, 5*time.Second, 50*time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Contains(t, errs[0].Error(), "watcher.backend")
+		assert.Len(t, loaded, 1)
+	})
+}
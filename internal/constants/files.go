@@ -22,6 +22,17 @@ const (
 	GitCellsLogsDir  = ".gitcells/logs"
 	GitCellsCacheDir = ".gitcells.cache"
 
+	// GitCellsArchiveDir keeps the chunks of deleted workbooks
+	GitCellsArchiveDir = ".gitcells/archive"
+
+	// GitCellsQueueFile keeps the watcher's pending and failed events
+	GitCellsQueueFile = ".gitcells/queue.json"
+
+	// Files of the background watcher started by 'gitcells daemon start'
+	GitCellsDaemonPIDFile = ".gitcells/daemon.pid"
+	GitCellsDaemonLogFile = ".gitcells/daemon.log"
+	GitCellsDaemonSocket  = ".gitcells/daemon.sock"
+
 	// Generic directories
 	LogsDir = "logs"
 
@@ -55,28 +55,19 @@ func NewSheetBasedChunking(logger Logger) ChunkingStrategy {
 }
 
 func (s *SheetBasedChunking) WriteChunks(doc *models.ExcelDocument, basePath string, options ConvertOptions) ([]string, error) {
-	// Determine the root directory and relative path for the Excel file
-	excelDir := filepath.Dir(basePath)
-	excelFile := filepath.Base(basePath)
-
-	// Remove .json extension if present
-	excelFile = strings.TrimSuffix(excelFile, ".json")
+	var chunkDir string
 
-	// Find the git root or use current directory
-	gitRoot, err := git.FindRepositoryRoot(excelDir)
-	if err != nil {
-		// If not in a git repo, use the excel directory
-		gitRoot = excelDir
+	// If basePath is already a chunk directory, write to it directly
+	if isChunkDir(basePath) {
+		chunkDir = basePath
+	} else {
+		chunkDir = chunkDirPath(basePath)
 	}
 
-	// Calculate relative path from git root to excel file
-	relPath, err := filepath.Rel(gitRoot, excelDir)
-	if err != nil {
-		relPath = ""
-	}
+	// Remember the previous chunk files so that sheets which no longer
+	// exist can be removed afterwards
+	previousFiles := s.readChunkFiles(chunkDir)
 
-	// Create the .gitcells/data directory structure mirroring the source structure
-	chunkDir := filepath.Join(gitRoot, constants.GitCellsDataDir, relPath, excelFile+constants.ChunksDirSuffix)
 	if err := os.MkdirAll(chunkDir, constants.DirPermissions); err != nil {
 		return nil, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "WriteChunks", chunkDir, "failed to create chunk directory")
 	}
@@ -144,6 +135,8 @@ func (s *SheetBasedChunking) WriteChunks(doc *models.ExcelDocument, basePath str
 		return nil, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "WriteChunks", metadataFile, "failed to write chunk metadata")
 	}
 
+	s.removeStaleChunks(chunkDir, previousFiles, metadata.ChunkFiles)
+
 	s.logger.Infof("Successfully wrote %d chunk files to %s", len(chunkFiles), chunkDir)
 	return chunkFiles, nil
 }
@@ -153,26 +146,11 @@ func (s *SheetBasedChunking) ReadChunks(basePath string) (*models.ExcelDocument,
 	var chunkDir string
 
 	// If basePath is already a chunk directory, use it directly
-	if strings.Contains(basePath, constants.GitCellsDataDir+string(filepath.Separator)) && strings.HasSuffix(basePath, constants.ChunksDirSuffix) {
+	if isChunkDir(basePath) {
 		chunkDir = basePath
 	} else {
 		// Otherwise, calculate the chunk directory location
-		excelDir := filepath.Dir(basePath)
-		excelFile := filepath.Base(basePath)
-		excelFile = strings.TrimSuffix(excelFile, ".json")
-		excelFile = strings.TrimSuffix(excelFile, ".xlsx")
-
-		gitRoot, err := git.FindRepositoryRoot(excelDir)
-		if err != nil {
-			// If not in a git repo, use the excel directory
-			gitRoot = excelDir
-		}
-		relPath, err := filepath.Rel(gitRoot, excelDir)
-		if err != nil {
-			relPath = ""
-		}
-
-		chunkDir = filepath.Join(gitRoot, constants.GitCellsDataDir, relPath, excelFile+constants.ChunksDirSuffix)
+		chunkDir = ChunkDir(basePath)
 	}
 
 	// Read chunk metadata
@@ -238,25 +216,10 @@ func (s *SheetBasedChunking) GetChunkPaths(basePath string) ([]string, error) {
 	// Determine where chunks are stored
 	var chunkDir string
 
-	if strings.Contains(basePath, constants.GitCellsDataDir+string(filepath.Separator)) && strings.HasSuffix(basePath, constants.ChunksDirSuffix) {
+	if isChunkDir(basePath) {
 		chunkDir = basePath
 	} else {
-		excelDir := filepath.Dir(basePath)
-		excelFile := filepath.Base(basePath)
-		excelFile = strings.TrimSuffix(excelFile, ".json")
-		excelFile = strings.TrimSuffix(excelFile, ".xlsx")
-
-		gitRoot, err := git.FindRepositoryRoot(excelDir)
-		if err != nil {
-			// If not in a git repo, use the excel directory
-			gitRoot = excelDir
-		}
-		relPath, err := filepath.Rel(gitRoot, excelDir)
-		if err != nil {
-			relPath = ""
-		}
-
-		chunkDir = filepath.Join(gitRoot, constants.GitCellsDataDir, relPath, excelFile+constants.ChunksDirSuffix)
+		chunkDir = ChunkDir(basePath)
 	}
 
 	// Check if chunk directory exists
@@ -294,6 +257,159 @@ type SheetChunk struct {
 
 // Helper methods
 
+// isChunkDir reports whether path names a chunk directory below .gitcells/data
+func isChunkDir(path string) bool {
+	return strings.Contains(path, constants.GitCellsDataDir+string(filepath.Separator)) && strings.HasSuffix(path, constants.ChunksDirSuffix)
+}
+
+// chunkDirPath returns the chunk directory for an Excel or JSON path. It
+// mirrors the file's location below .gitcells/data in the git root, or in
+// the file's own directory outside a repository.
+func chunkDirPath(basePath string) string {
+	excelDir := filepath.Dir(basePath)
+	// A relative directory cannot be made relative to the git root below
+	if abs, err := filepath.Abs(excelDir); err == nil {
+		excelDir = abs
+	}
+
+	// Remove .json extension if present
+	excelFile := strings.TrimSuffix(filepath.Base(basePath), ".json")
+
+	// Find the git root or use current directory
+	gitRoot, err := git.FindRepositoryRoot(excelDir)
+	if err != nil {
+		// If not in a git repo, use the excel directory
+		gitRoot = excelDir
+	}
+
+	// Calculate relative path from git root to excel file
+	relPath, err := filepath.Rel(gitRoot, excelDir)
+	if err != nil {
+		relPath = ""
+	}
+
+	return filepath.Join(gitRoot, constants.GitCellsDataDir, relPath, excelFile+constants.ChunksDirSuffix)
+}
+
+// ChunkDir returns the chunk directory to read for an Excel or JSON path.
+// Older versions named the directory without the Excel extension, so that
+// name is used when only it exists.
+func ChunkDir(basePath string) string {
+	chunkDir := chunkDirPath(basePath)
+	if _, err := os.Stat(chunkDir); err == nil {
+		return chunkDir
+	}
+
+	ext := filepath.Ext(strings.TrimSuffix(basePath, ".json"))
+	if ext == "" {
+		return chunkDir
+	}
+	legacyDir := strings.TrimSuffix(chunkDir, ext+constants.ChunksDirSuffix) + constants.ChunksDirSuffix
+	if _, err := os.Stat(legacyDir); err == nil {
+		return legacyDir
+	}
+	return chunkDir
+}
+
+// MoveChunks moves the chunk directory of a renamed workbook to the one for
+// its new path and returns both. The old directory is empty if the workbook
+// had no chunks, in which case nothing is moved.
+func MoveChunks(oldPath, newPath string) (string, string, error) {
+	oldDir := ChunkDir(oldPath)
+	newDir := chunkDirPath(newPath)
+	if _, err := os.Stat(oldDir); os.IsNotExist(err) {
+		return "", newDir, nil
+	}
+	if oldDir == newDir {
+		return oldDir, newDir, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newDir), constants.DirPermissions); err != nil {
+		return "", "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "MoveChunks", newDir, "failed to create chunk directory")
+	}
+	// A workbook renamed over another replaces its chunks
+	if err := os.RemoveAll(newDir); err != nil {
+		return "", "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "MoveChunks", newDir, "failed to remove chunk directory")
+	}
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return "", "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "MoveChunks", oldDir, "failed to move chunk directory")
+	}
+	return oldDir, newDir, nil
+}
+
+// RemoveChunks deletes the chunk directory of a deleted workbook and
+// returns it, or an empty string if the workbook had no chunks
+func RemoveChunks(workbookPath string) (string, error) {
+	chunkDir := ChunkDir(workbookPath)
+	if _, err := os.Stat(chunkDir); os.IsNotExist(err) {
+		return "", nil
+	}
+	if err := os.RemoveAll(chunkDir); err != nil {
+		return "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "RemoveChunks", chunkDir, "failed to remove chunk directory")
+	}
+	return chunkDir, nil
+}
+
+// ArchiveChunks moves the chunk directory of a deleted workbook from
+// .gitcells/data to the same place below .gitcells/archive, replacing any
+// earlier archive. It returns both directories, or empty strings if the
+// workbook had no chunks.
+func ArchiveChunks(workbookPath string) (string, string, error) {
+	chunkDir := ChunkDir(workbookPath)
+	if _, err := os.Stat(chunkDir); os.IsNotExist(err) {
+		return "", "", nil
+	}
+
+	dataDir := filepath.FromSlash(constants.GitCellsDataDir) + string(filepath.Separator)
+	i := strings.LastIndex(chunkDir, dataDir)
+	if i < 0 {
+		return "", "", utils.NewError(utils.ErrorTypeFileSystem, "ArchiveChunks", "not a chunk directory: "+chunkDir)
+	}
+	archiveDir := chunkDir[:i] + filepath.Join(filepath.FromSlash(constants.GitCellsArchiveDir), chunkDir[i+len(dataDir):])
+
+	if err := os.MkdirAll(filepath.Dir(archiveDir), constants.DirPermissions); err != nil {
+		return "", "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "ArchiveChunks", archiveDir, "failed to create archive directory")
+	}
+	if err := os.RemoveAll(archiveDir); err != nil {
+		return "", "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "ArchiveChunks", archiveDir, "failed to remove earlier archive")
+	}
+	if err := os.Rename(chunkDir, archiveDir); err != nil {
+		return "", "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "ArchiveChunks", chunkDir, "failed to archive chunk directory")
+	}
+	return chunkDir, archiveDir, nil
+}
+
+// readChunkFiles returns the chunk files listed in a chunk directory's
+// metadata, or nil if there is none
+func (s *SheetBasedChunking) readChunkFiles(chunkDir string) []string {
+	data, err := os.ReadFile(filepath.Join(chunkDir, constants.ChunkMetadataFile))
+	if err != nil {
+		return nil
+	}
+	var metadata ChunkMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil
+	}
+	return metadata.ChunkFiles
+}
+
+// removeStaleChunks deletes chunk files that were written previously but are
+// no longer part of the document, e.g. after a sheet was removed
+func (s *SheetBasedChunking) removeStaleChunks(chunkDir string, previous, current []string) {
+	keep := make(map[string]bool, len(current))
+	for _, file := range current {
+		keep[file] = true
+	}
+	for _, file := range previous {
+		if keep[file] || filepath.IsAbs(file) || strings.Contains(file, "..") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(chunkDir, file)); err != nil && !os.IsNotExist(err) {
+			s.logger.Warnf("Failed to remove stale chunk %s: %v", file, err)
+		}
+	}
+}
+
 func (s *SheetBasedChunking) writeJSONFile(path string, data interface{}, compact bool) error {
 	var jsonData []byte
 	var err error
@@ -126,6 +126,57 @@ func TestSheetBasedChunking(t *testing.T) {
 		}
 	})
 
+	t.Run("WriteToChunkDirRemovesStaleSheets", func(t *testing.T) {
+		tempDir := t.TempDir()
+		err := os.Mkdir(filepath.Join(tempDir, ".git"), constants.DirPermissions)
+		require.NoError(t, err)
+
+		_, err = chunker.WriteChunks(doc, filepath.Join(tempDir, "book.xlsx"), ConvertOptions{})
+		require.NoError(t, err)
+
+		chunkDir := filepath.Join(tempDir, ".gitcells", "data", "book.xlsx_chunks")
+		smaller := *doc
+		smaller.Sheets = doc.Sheets[:1]
+
+		files, err := chunker.WriteChunks(&smaller, chunkDir, ConvertOptions{})
+		require.NoError(t, err)
+		assert.Len(t, files, 2)
+
+		_, err = os.Stat(filepath.Join(chunkDir, "sheet_Sheet2.json"))
+		assert.True(t, os.IsNotExist(err))
+
+		readDoc, err := chunker.ReadChunks(chunkDir)
+		require.NoError(t, err)
+		assert.Len(t, readDoc.Sheets, 1)
+	})
+
+	t.Run("ReadChunksForExcelPath", func(t *testing.T) {
+		tempDir := t.TempDir()
+		err := os.Mkdir(filepath.Join(tempDir, ".git"), constants.DirPermissions)
+		require.NoError(t, err)
+
+		excelPath := filepath.Join(tempDir, "book.xlsx")
+		_, err = chunker.WriteChunks(doc, excelPath, ConvertOptions{})
+		require.NoError(t, err)
+
+		readDoc, err := chunker.ReadChunks(excelPath)
+		require.NoError(t, err)
+		assert.Len(t, readDoc.Sheets, 2)
+
+		paths, err := chunker.GetChunkPaths(excelPath)
+		require.NoError(t, err)
+		assert.Len(t, paths, 3)
+
+		// Directories named without the extension are still found
+		dataDir := filepath.Join(tempDir, ".gitcells", "data")
+		err = os.Rename(filepath.Join(dataDir, "book.xlsx_chunks"), filepath.Join(dataDir, "book_chunks"))
+		require.NoError(t, err)
+
+		readDoc, err = chunker.ReadChunks(excelPath)
+		require.NoError(t, err)
+		assert.Len(t, readDoc.Sheets, 2)
+	})
+
 	t.Run("SanitizeFilename", func(t *testing.T) {
 		testCases := []struct {
 			input    string
@@ -198,3 +249,70 @@ func TestSheetChunk(t *testing.T) {
 	assert.Equal(t, chunk.Sheet.Name, loaded.Sheet.Name)
 	assert.Len(t, loaded.Sheet.Cells, 1)
 }
+
+func TestMoveAndRemoveChunks(t *testing.T) {
+	logger := logrus.New()
+	chunker := NewSheetBasedChunking(logger)
+	doc := &models.ExcelDocument{
+		Version: "1.0",
+		Sheets:  []models.Sheet{{Name: "Sheet1", Cells: map[string]models.Cell{"A1": {Value: "x"}}}},
+	}
+
+	setup := func(t *testing.T) (string, string) {
+		tempDir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(tempDir, ".git"), constants.DirPermissions))
+		workbook := filepath.Join(tempDir, "reports", "budget.xlsx")
+		_, err := chunker.WriteChunks(doc, workbook, ConvertOptions{})
+		require.NoError(t, err)
+		return tempDir, workbook
+	}
+
+	t.Run("MoveChunks", func(t *testing.T) {
+		tempDir, workbook := setup(t)
+		renamed := filepath.Join(tempDir, "archive", "budget 2025.xlsx")
+
+		oldDir, newDir, err := MoveChunks(workbook, renamed)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(tempDir, ".gitcells", "data", "reports", "budget.xlsx_chunks"), oldDir)
+		assert.Equal(t, filepath.Join(tempDir, ".gitcells", "data", "archive", "budget 2025.xlsx_chunks"), newDir)
+		assert.NoDirExists(t, oldDir)
+
+		moved, err := chunker.ReadChunks(renamed)
+		require.NoError(t, err)
+		assert.Equal(t, "x", moved.Sheets[0].Cells["A1"].Value)
+
+		// A workbook without chunks has nothing to move
+		oldDir, _, err = MoveChunks(workbook, renamed)
+		require.NoError(t, err)
+		assert.Empty(t, oldDir)
+	})
+
+	t.Run("RemoveChunks", func(t *testing.T) {
+		_, workbook := setup(t)
+
+		chunkDir, err := RemoveChunks(workbook)
+		require.NoError(t, err)
+		assert.NoDirExists(t, chunkDir)
+
+		chunkDir, err = RemoveChunks(workbook)
+		require.NoError(t, err)
+		assert.Empty(t, chunkDir)
+	})
+
+	t.Run("ArchiveChunks", func(t *testing.T) {
+		tempDir, workbook := setup(t)
+
+		chunkDir, archiveDir, err := ArchiveChunks(workbook)
+		require.NoError(t, err)
+		assert.NoDirExists(t, chunkDir)
+		assert.Equal(t, filepath.Join(tempDir, ".gitcells", "archive", "reports", "budget.xlsx_chunks"), archiveDir)
+		assert.FileExists(t, filepath.Join(archiveDir, constants.ChunkMetadataFile))
+
+		// Archiving again replaces the earlier archive
+		_, err = chunker.WriteChunks(doc, workbook, ConvertOptions{})
+		require.NoError(t, err)
+		_, archiveDir, err = ArchiveChunks(workbook)
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(archiveDir, constants.ChunkMetadataFile))
+	})
+}
@@ -2,6 +2,7 @@
 package converter
 
 import (
+	"github.com/Classic-Homes/gitcells/internal/config"
 	"github.com/Classic-Homes/gitcells/pkg/models"
 	"github.com/sirupsen/logrus"
 )
@@ -14,6 +15,8 @@ type Converter interface {
 	// File-based operations with automatic chunking
 	ExcelToJSONFile(inputPath, outputPath string, options ConvertOptions) error
 	JSONFileToExcel(inputPath, outputPath string, options ConvertOptions) error
+	ReadChunks(basePath string) (*models.ExcelDocument, error)
+	WriteChunks(doc *models.ExcelDocument, basePath string, options ConvertOptions) ([]string, error)
 
 	// Utility operations
 	GetExcelSheetNames(filePath string) ([]string, error)
@@ -56,3 +59,17 @@ func NewConverter(logger *logrus.Logger) Converter {
 	c.chunkingStrategy = NewSheetBasedChunking(c.logger)
 	return c
 }
+
+// OptionsFromConfig returns the options the converter settings of the
+// configuration file describe, as the watcher converts workbooks with
+func OptionsFromConfig(cfg config.ConverterConfig) ConvertOptions {
+	return ConvertOptions{
+		PreserveFormulas: cfg.PreserveFormulas,
+		PreserveStyles:   cfg.PreserveStyles,
+		PreserveComments: cfg.PreserveComments,
+		CompactJSON:      cfg.CompactJSON,
+		IgnoreEmptyCells: cfg.IgnoreEmptyCells,
+		MaxCellsPerSheet: cfg.MaxCellsPerSheet,
+		ChunkingStrategy: "sheet-based",
+	}
+}
@@ -277,6 +277,12 @@ func (c *converter) processSheet(f *excelize.File, sheetName string, index int,
 }
 
 func (c *converter) calculateChecksum(filePath string) (string, error) {
+	return FileChecksum(filePath)
+}
+
+// FileChecksum returns the checksum recorded in document metadata for the
+// workbook at filePath
+func FileChecksum(filePath string) (string, error) {
 	file, err := os.Open(filePath) // #nosec G304 - file path is user input
 	if err != nil {
 		return "", err
@@ -287,8 +293,13 @@ func (c *converter) calculateChecksum(filePath string) (string, error) {
 		}
 	}()
 
+	return Checksum(file)
+}
+
+// Checksum returns the SHA-256 checksum of workbook content read from r
+func Checksum(r io.Reader) (string, error) {
 	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
+	if _, err := io.Copy(hash, r); err != nil {
 		return "", err
 	}
 
@@ -2,6 +2,7 @@ package converter
 
 import (
 	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/Classic-Homes/gitcells/pkg/models"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -40,6 +41,18 @@ func (c *converter) JSONFileToExcel(inputPath, outputPath string, options Conver
 	return nil
 }
 
+// ReadChunks reassembles a document from the chunk directory for the given
+// Excel path or chunk directory
+func (c *converter) ReadChunks(basePath string) (*models.ExcelDocument, error) {
+	return c.chunkingStrategy.ReadChunks(basePath)
+}
+
+// WriteChunks writes a document to the chunk directory for the given Excel
+// path or chunk directory
+func (c *converter) WriteChunks(doc *models.ExcelDocument, basePath string, options ConvertOptions) ([]string, error) {
+	return c.chunkingStrategy.WriteChunks(doc, basePath, options)
+}
+
 // GetExcelSheetNames returns the sheet names from an Excel file without processing the data
 func (c *converter) GetExcelSheetNames(filePath string) ([]string, error) {
 	// This method is implemented in excel_to_json.go, but since Go doesn't allow forward declarations,
@@ -126,11 +126,12 @@ func (c *converter) JSONToExcel(doc *models.ExcelDocument, outputPath string, op
 	// Set document properties if available
 	if doc.Properties != (models.DocumentProperties{}) {
 		err := f.SetDocProps(&excelize.DocProperties{
-			Title:       doc.Properties.Title,
-			Subject:     doc.Properties.Subject,
-			Creator:     doc.Properties.Author,
-			Keywords:    doc.Properties.Keywords,
-			Description: doc.Properties.Description,
+			Title:          doc.Properties.Title,
+			Subject:        doc.Properties.Subject,
+			Creator:        doc.Properties.Author,
+			Keywords:       doc.Properties.Keywords,
+			Description:    doc.Properties.Description,
+			LastModifiedBy: doc.Properties.LastModifiedBy,
 			// Note: Company field is not available in excelize.DocProperties
 			// It's stored in our model but cannot be set back in Excel
 		})
@@ -237,12 +237,13 @@ func (c *converter) extractProperties(props interface{}) models.DocumentProperti
 	// Type assert to excelize.DocProperties
 	if docProps, ok := props.(*excelize.DocProperties); ok {
 		return models.DocumentProperties{
-			Title:       docProps.Title,
-			Subject:     docProps.Subject,
-			Author:      docProps.Creator,
-			Company:     docProps.Category,
-			Keywords:    docProps.Keywords,
-			Description: docProps.Description,
+			Title:          docProps.Title,
+			Subject:        docProps.Subject,
+			Author:         docProps.Creator,
+			Company:        docProps.Category,
+			Keywords:       docProps.Keywords,
+			Description:    docProps.Description,
+			LastModifiedBy: docProps.LastModifiedBy,
 		}
 	}
 
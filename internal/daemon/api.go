@@ -0,0 +1,273 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/Classic-Homes/gitcells/internal/watcher"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// requestTimeout bounds the requests that only read or flip state
+	requestTimeout = 5 * time.Second
+	// syncTimeout bounds a forced sync, which converts and commits
+	syncTimeout = 10 * time.Minute
+)
+
+// Status is the state of a running daemon
+type Status struct {
+	PID           int       `json:"pid"`
+	Started       time.Time `json:"started"`
+	Paused        bool      `json:"paused"`
+	Directories   []string  `json:"directories"`
+	LastEvent     string    `json:"last_event,omitempty"`
+	LastEventTime time.Time `json:"last_event_time"`
+	// HeldChanges counts the changes held while paused
+	HeldChanges    int `json:"held_changes"`
+	PendingChanges int `json:"pending_changes"`
+	FailedChanges  int `json:"failed_changes"`
+	// QueuedEvents counts the events waiting for a conversion slot, and
+	// ActiveEvents those being converted
+	QueuedEvents int `json:"queued_events"`
+	ActiveEvents int `json:"active_events"`
+}
+
+// Controller is the watcher the control API drives
+type Controller interface {
+	Status() Status
+	// Pause holds changes until Resume processes them
+	Pause()
+	Resume()
+	// Sync converts and commits every workbook that changed without the
+	// watcher noticing, returning how many there were
+	Sync() (int, error)
+	Queue() watcher.QueueState
+	// ClearFailed forgets the changes that failed every retry
+	ClearFailed() (int, error)
+	// Shutdown asks the daemon to commit pending changes and exit
+	Shutdown()
+}
+
+// Server serves the control API on a Unix domain socket
+type Server struct {
+	listener net.Listener
+	server   *http.Server
+	socket   string
+}
+
+// Serve starts serving the control API for ctl on socket
+func Serve(socket string, ctl Controller, logger *logrus.Logger) (*Server, error) {
+	// A socket left behind by a daemon that died refuses connections
+	_ = os.Remove(socket)
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeWatcher, "serve", socket, "failed to listen on control socket")
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		_ = listener.Close()
+		return nil, utils.WrapFileError(err, utils.ErrorTypePermission, "serve", socket, "failed to restrict control socket")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if requireMethod(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, ctl.Status())
+		}
+	})
+	mux.HandleFunc("/queue", func(w http.ResponseWriter, r *http.Request) {
+		if requireMethod(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, ctl.Queue())
+		}
+	})
+	mux.HandleFunc("/pause", func(w http.ResponseWriter, r *http.Request) {
+		if requireMethod(w, r, http.MethodPost) {
+			ctl.Pause()
+			writeJSON(w, http.StatusOK, ctl.Status())
+		}
+	})
+	mux.HandleFunc("/resume", func(w http.ResponseWriter, r *http.Request) {
+		if requireMethod(w, r, http.MethodPost) {
+			ctl.Resume()
+			writeJSON(w, http.StatusOK, ctl.Status())
+		}
+	})
+	mux.HandleFunc("/sync", func(w http.ResponseWriter, r *http.Request) {
+		if !requireMethod(w, r, http.MethodPost) {
+			return
+		}
+		synced, err := ctl.Sync()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, syncResponse{Synced: synced})
+	})
+	mux.HandleFunc("/clear-failed", func(w http.ResponseWriter, r *http.Request) {
+		if !requireMethod(w, r, http.MethodPost) {
+			return
+		}
+		cleared, err := ctl.ClearFailed()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, clearResponse{Cleared: cleared})
+	})
+	mux.HandleFunc("/stop", func(w http.ResponseWriter, r *http.Request) {
+		if requireMethod(w, r, http.MethodPost) {
+			writeJSON(w, http.StatusAccepted, ctl.Status())
+			ctl.Shutdown()
+		}
+	})
+
+	s := &Server{
+		listener: listener,
+		server:   &http.Server{Handler: mux, ReadHeaderTimeout: requestTimeout},
+		socket:   socket,
+	}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("Control API stopped: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// Close stops serving and removes the socket
+func (s *Server) Close() error {
+	err := s.server.Close()
+	_ = os.Remove(s.socket)
+	return err
+}
+
+type syncResponse struct {
+	Synced int `json:"synced"`
+}
+
+type clearResponse struct {
+	Cleared int `json:"cleared"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// Client calls the control API of a daemon
+type Client struct {
+	http *http.Client
+}
+
+// NewClient returns a client for the daemon serving on socket
+func NewClient(socket string) *Client {
+	return &Client{
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+// Status returns the state of the daemon
+func (c *Client) Status() (Status, error) {
+	var status Status
+	err := c.call(http.MethodGet, "/status", requestTimeout, &status)
+	return status, err
+}
+
+// Queue returns the changes waiting to be processed and those that failed
+func (c *Client) Queue() (watcher.QueueState, error) {
+	var queue watcher.QueueState
+	err := c.call(http.MethodGet, "/queue", requestTimeout, &queue)
+	return queue, err
+}
+
+// Pause holds changes until Resume
+func (c *Client) Pause() (Status, error) {
+	var status Status
+	err := c.call(http.MethodPost, "/pause", requestTimeout, &status)
+	return status, err
+}
+
+// Resume processes the changes held while paused and continues watching
+func (c *Client) Resume() (Status, error) {
+	var status Status
+	err := c.call(http.MethodPost, "/resume", requestTimeout, &status)
+	return status, err
+}
+
+// Sync converts and commits the workbooks that changed without the daemon
+// noticing, returning how many there were
+func (c *Client) Sync() (int, error) {
+	var response syncResponse
+	err := c.call(http.MethodPost, "/sync", syncTimeout, &response)
+	return response.Synced, err
+}
+
+// ClearFailed forgets the changes that failed every retry, returning how
+// many there were
+func (c *Client) ClearFailed() (int, error) {
+	var response clearResponse
+	err := c.call(http.MethodPost, "/clear-failed", requestTimeout, &response)
+	return response.Cleared, err
+}
+
+// Stop asks the daemon to commit pending changes and exit. It returns
+// before the daemon has exited.
+func (c *Client) Stop() error {
+	return c.call(http.MethodPost, "/stop", requestTimeout, nil)
+}
+
+func (c *Client) call(method, path string, timeout time.Duration, out interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The host is ignored; requests go to the socket
+	req, err := http.NewRequestWithContext(ctx, method, "http://gitcells"+path, nil)
+	if err != nil {
+		return utils.WrapError(err, utils.ErrorTypeWatcher, "daemonAPI", "failed to create request")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return utils.WrapError(err, utils.ErrorTypeWatcher, "daemonAPI", "failed to reach daemon")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var failure errorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&failure)
+		return utils.NewError(utils.ErrorTypeWatcher, "daemonAPI", path+": "+failure.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return utils.WrapError(err, utils.ErrorTypeWatcher, "daemonAPI", "failed to read daemon response")
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// Package daemon runs the watcher as a background process and controls it
+// over a Unix domain socket.
+package daemon
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+)
+
+const (
+	// StartTimeout is how long Start waits for a new daemon to serve its API
+	StartTimeout = 10 * time.Second
+	// StopTimeout is how long Stop waits for the daemon to commit pending
+	// changes and exit
+	StopTimeout = 30 * time.Second
+
+	// maxSocketPath keeps socket paths within the limit of every platform
+	maxSocketPath = 100
+)
+
+// Paths locates the files of the daemon of a repository
+type Paths struct {
+	PIDFile string
+	LogFile string
+	Socket  string
+}
+
+// PathsFor returns the daemon files of the repository containing dir, or of
+// dir itself outside a repository
+func PathsFor(dir string) Paths {
+	root, err := git.FindRepositoryRoot(dir)
+	if err != nil {
+		root = dir
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+
+	return Paths{
+		PIDFile: filepath.Join(root, constants.GitCellsDaemonPIDFile),
+		LogFile: filepath.Join(root, constants.GitCellsDaemonLogFile),
+		Socket:  socketPath(root),
+	}
+}
+
+// socketPath places the socket in the repository unless the path is too
+// long for a socket, as in deeply nested repositories, which use one in the
+// temporary directory named after the repository instead
+func socketPath(root string) string {
+	path := filepath.Join(root, constants.GitCellsDaemonSocket)
+	if len(path) <= maxSocketPath {
+		return path
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gitcells-%x.sock", sum[:8]))
+}
+
+// ReadPID returns the process ID of the running daemon, or 0 if none runs.
+// A PID file left behind by a daemon that died is removed.
+func ReadPID(paths Paths) int {
+	data, err := os.ReadFile(paths.PIDFile)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 || !processRunning(pid) {
+		RemovePID(paths)
+		return 0
+	}
+	return pid
+}
+
+// WritePID records the current process as the daemon
+func WritePID(paths Paths) error {
+	if err := os.MkdirAll(filepath.Dir(paths.PIDFile), constants.SecureDirPermissions); err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "writePID", paths.PIDFile, "failed to create directory")
+	}
+	if err := os.WriteFile(paths.PIDFile, []byte(strconv.Itoa(os.Getpid())+"\n"), constants.SecureFilePermissions); err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "writePID", paths.PIDFile, "failed to write PID file")
+	}
+	return nil
+}
+
+// RemovePID removes the PID file
+func RemovePID(paths Paths) {
+	_ = os.Remove(paths.PIDFile)
+}
+
+// Running returns a client for the daemon of the repository containing dir,
+// or false if no daemon runs there
+func Running(dir string) (*Client, bool) {
+	paths := PathsFor(dir)
+	if ReadPID(paths) == 0 {
+		return nil, false
+	}
+	return NewClient(paths.Socket), true
+}
+
+// Start runs the current executable with args as a detached process that
+// logs to the log file, and waits until it serves the control API. It
+// returns the process ID of the daemon.
+func Start(paths Paths, args []string) (int, error) {
+	if pid := ReadPID(paths); pid != 0 {
+		return pid, utils.NewError(utils.ErrorTypeValidation, "daemonStart", fmt.Sprintf("daemon is already running (pid %d)", pid))
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, utils.WrapError(err, utils.ErrorTypeFileSystem, "daemonStart", "failed to find the gitcells executable")
+	}
+	if err := os.MkdirAll(filepath.Dir(paths.LogFile), constants.SecureDirPermissions); err != nil {
+		return 0, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "daemonStart", paths.LogFile, "failed to create directory")
+	}
+	logFile, err := os.OpenFile(paths.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, constants.SecureFilePermissions) // #nosec G304 - path within the repository
+	if err != nil {
+		return 0, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "daemonStart", paths.LogFile, "failed to open daemon log")
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(executable, args...) // #nosec G204 - runs gitcells itself
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return 0, utils.WrapError(err, utils.ErrorTypeWatcher, "daemonStart", "failed to start daemon")
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	client := NewClient(paths.Socket)
+	deadline := time.After(StartTimeout)
+	for {
+		if _, err := client.Status(); err == nil {
+			return cmd.Process.Pid, nil
+		}
+
+		select {
+		case <-exited:
+			return 0, utils.NewError(utils.ErrorTypeWatcher, "daemonStart", "daemon exited while starting; see "+paths.LogFile)
+		case <-deadline:
+			return cmd.Process.Pid, utils.NewError(utils.ErrorTypeWatcher, "daemonStart", "daemon did not start serving its control API; see "+paths.LogFile)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Stop asks the daemon to commit pending changes and exit, and waits until
+// it has. A daemon that does not answer is terminated. It returns the
+// process ID of the daemon.
+func Stop(paths Paths) (int, error) {
+	pid := ReadPID(paths)
+	if pid == 0 {
+		return 0, utils.NewError(utils.ErrorTypeValidation, "daemonStop", "daemon is not running")
+	}
+
+	if err := NewClient(paths.Socket).Stop(); err != nil {
+		if err := terminate(pid); err != nil {
+			return pid, utils.WrapError(err, utils.ErrorTypeWatcher, "daemonStop", fmt.Sprintf("failed to stop daemon (pid %d)", pid))
+		}
+	}
+
+	deadline := time.Now().Add(StopTimeout)
+	for processRunning(pid) {
+		if time.Now().After(deadline) {
+			return pid, utils.NewError(utils.ErrorTypeWatcher, "daemonStop", fmt.Sprintf("daemon (pid %d) did not stop", pid))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	RemovePID(paths)
+	return pid, nil
+}
@@ -0,0 +1,165 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/watcher"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeController struct {
+	mu       sync.Mutex
+	paused   bool
+	synced   int
+	syncErr  error
+	failed   []watcher.QueueItem
+	shutdown chan struct{}
+}
+
+func (f *fakeController) Status() Status {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return Status{PID: 42, Paused: f.paused, Directories: []string{"."}, FailedChanges: len(f.failed)}
+}
+
+func (f *fakeController) Pause() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paused = true
+}
+
+func (f *fakeController) Resume() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paused = false
+}
+
+func (f *fakeController) Sync() (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.synced, f.syncErr
+}
+
+func (f *fakeController) Queue() watcher.QueueState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return watcher.QueueState{Failed: f.failed}
+}
+
+func (f *fakeController) ClearFailed() (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cleared := len(f.failed)
+	f.failed = nil
+	return cleared, nil
+}
+
+func (f *fakeController) Shutdown() {
+	close(f.shutdown)
+}
+
+func TestControlAPI(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	socket := filepath.Join(t.TempDir(), "daemon.sock")
+	ctl := &fakeController{
+		synced:   3,
+		failed:   []watcher.QueueItem{{ID: 1, Event: watcher.FileEvent{Path: "a.xlsx", Type: watcher.EventTypeModify}}},
+		shutdown: make(chan struct{}),
+	}
+	server, err := Serve(socket, ctl, logger)
+	require.NoError(t, err)
+	defer server.Close()
+
+	client := NewClient(socket)
+
+	status, err := client.Status()
+	require.NoError(t, err)
+	assert.Equal(t, 42, status.PID)
+	assert.Equal(t, []string{"."}, status.Directories)
+
+	status, err = client.Pause()
+	require.NoError(t, err)
+	assert.True(t, status.Paused)
+	status, err = client.Resume()
+	require.NoError(t, err)
+	assert.False(t, status.Paused)
+
+	synced, err := client.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, 3, synced)
+
+	ctl.mu.Lock()
+	ctl.syncErr = errors.New("index.lock exists")
+	ctl.mu.Unlock()
+	_, err = client.Sync()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "index.lock exists")
+
+	queue, err := client.Queue()
+	require.NoError(t, err)
+	require.Len(t, queue.Failed, 1)
+	assert.Equal(t, watcher.EventTypeModify, queue.Failed[0].Event.Type)
+
+	cleared, err := client.ClearFailed()
+	require.NoError(t, err)
+	assert.Equal(t, 1, cleared)
+
+	require.NoError(t, client.Stop())
+	select {
+	case <-ctl.shutdown:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not shut the daemon down")
+	}
+
+	require.NoError(t, server.Close())
+	_, err = client.Status()
+	assert.Error(t, err)
+	assert.NoFileExists(t, socket)
+}
+
+func TestPIDFile(t *testing.T) {
+	tempDir := t.TempDir()
+	paths := PathsFor(tempDir)
+	assert.Equal(t, filepath.Join(tempDir, ".gitcells", "daemon.pid"), paths.PIDFile)
+
+	assert.Equal(t, 0, ReadPID(paths))
+	_, ok := Running(tempDir)
+	assert.False(t, ok)
+
+	require.NoError(t, WritePID(paths))
+	assert.Equal(t, os.Getpid(), ReadPID(paths))
+	_, ok = Running(tempDir)
+	assert.True(t, ok)
+
+	_, err := Start(paths, []string{"watch"})
+	assert.Error(t, err, "a second daemon must not start")
+
+	// A PID file left behind by a daemon that died is removed
+	require.NoError(t, os.WriteFile(paths.PIDFile, []byte("999999999\n"), 0600))
+	assert.Equal(t, 0, ReadPID(paths))
+	assert.NoFileExists(t, paths.PIDFile)
+
+	_, err = Stop(paths)
+	assert.Error(t, err)
+}
+
+func TestSocketPath(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "repo")
+	assert.Equal(t, filepath.Join(root, ".gitcells", "daemon.sock"), socketPath(root))
+
+	deep := filepath.Join(root, strings.Repeat("nested/", 20))
+	path := socketPath(deep)
+	assert.Equal(t, os.TempDir(), filepath.Dir(path))
+	assert.Equal(t, path, socketPath(deep), "the socket of a repository must not change")
+	assert.NotEqual(t, path, socketPath(deep+"other"))
+}
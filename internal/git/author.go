@@ -0,0 +1,159 @@
+package git
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"gopkg.in/yaml.v3"
+)
+
+// Attribution modes choose who is recorded as the author of a commit
+const (
+	// AttributionFixed uses the configured user name and email
+	AttributionFixed = "fixed"
+	// AttributionWorkbook uses the workbook's LastModifiedBy property
+	AttributionWorkbook = "workbook"
+	// AttributionOS uses the user running GitCells
+	AttributionOS = "os"
+	// AttributionGit uses user.name and user.email from git config
+	AttributionGit = "git"
+)
+
+// AttributionConfig configures commit authors. The configured user name and
+// email remain the committer, and the author whenever no other can be found.
+type AttributionConfig struct {
+	// Mode is one of the Attribution constants; empty means AttributionFixed
+	Mode string
+	// AuthorsFile is a YAML file mapping display names to email addresses,
+	// relative to the repository root
+	AuthorsFile string
+}
+
+// AuthorMap maps display names, such as a workbook's LastModifiedBy or an
+// OS user, to email addresses. Lookups ignore case.
+type AuthorMap map[string]string
+
+// LoadAuthorMap reads an authors file of "Display Name: email" lines
+func LoadAuthorMap(path string) (AuthorMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeConfig, "loadAuthorMap", path, "failed to read authors file")
+	}
+
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeConfig, "loadAuthorMap", path, "failed to parse authors file")
+	}
+
+	authors := make(AuthorMap, len(raw))
+	for name, email := range raw {
+		authors[normalizeAuthorName(name)] = strings.TrimSpace(email)
+	}
+	return authors, nil
+}
+
+// Email returns the email address mapped to name
+func (m AuthorMap) Email(name string) (string, bool) {
+	email, ok := m[normalizeAuthorName(name)]
+	return email, ok && email != ""
+}
+
+func normalizeAuthorName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// validateAttribution checks the attribution mode and loads its authors file
+func validateAttribution(cfg AttributionConfig, root string) (AuthorMap, error) {
+	switch cfg.Mode {
+	case "", AttributionFixed, AttributionWorkbook, AttributionOS, AttributionGit:
+	default:
+		return nil, utils.NewError(utils.ErrorTypeConfig, "newClient", "unknown attribution mode: "+cfg.Mode)
+	}
+
+	if cfg.AuthorsFile == "" {
+		return AuthorMap{}, nil
+	}
+	path := cfg.AuthorsFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	return LoadAuthorMap(path)
+}
+
+// commitAuthors returns the authors of a commit of changes, the main author
+// first. Workbook attribution credits each distinct LastModifiedBy; the
+// other modes give a single author.
+func (c *Client) commitAuthors(changes []CommitChange) []object.Signature {
+	now := time.Now()
+	fixed := object.Signature{Name: c.config.UserName, Email: c.config.UserEmail, When: now}
+
+	var authors []object.Signature
+	switch c.config.Attribution.Mode {
+	case AttributionWorkbook:
+		seen := map[string]bool{}
+		for _, change := range changes {
+			name := strings.TrimSpace(change.ModifiedBy)
+			if name == "" || seen[normalizeAuthorName(name)] {
+				continue
+			}
+			seen[normalizeAuthorName(name)] = true
+			authors = append(authors, c.signature(name, "", now))
+		}
+	case AttributionOS:
+		if current, err := user.Current(); err == nil {
+			name := current.Name
+			if name == "" {
+				name = current.Username
+			}
+			// The authors file may list the account name rather than the
+			// display name
+			email, _ := c.authors.Email(current.Username)
+			authors = append(authors, c.signature(name, email, now))
+		} else if name := os.Getenv("USER"); name != "" {
+			authors = append(authors, c.signature(name, "", now))
+		}
+	case AttributionGit:
+		if cfg, err := c.repo.ConfigScoped(gitconfig.GlobalScope); err == nil && cfg.User.Name != "" {
+			authors = append(authors, c.signature(cfg.User.Name, cfg.User.Email, now))
+		}
+	}
+
+	if len(authors) == 0 {
+		return []object.Signature{fixed}
+	}
+	return authors
+}
+
+// signature returns the signature for name, taking the email from the
+// authors file unless one is given. Names without an email get the
+// configured one.
+func (c *Client) signature(name, email string, when time.Time) object.Signature {
+	if email == "" {
+		var ok bool
+		if email, ok = c.authors.Email(name); !ok {
+			email = c.config.UserEmail
+		}
+	}
+	return object.Signature{Name: name, Email: email, When: when}
+}
+
+// coAuthorTrailers credits the authors after the first with Co-authored-by
+// trailers
+func coAuthorTrailers(message string, authors []object.Signature) string {
+	if len(authors) < 2 {
+		return message
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(message, "\n"))
+	b.WriteString("\n\n")
+	for _, author := range authors[1:] {
+		b.WriteString("Co-authored-by: " + author.Name + " <" + author.Email + ">\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAuthorMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "authors.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("\"Jane Doe\": jane@example.com\njdoe: \" jane@example.com \"\n"), 0600))
+
+	authors, err := LoadAuthorMap(path)
+	require.NoError(t, err)
+	email, ok := authors.Email("  jane doe")
+	assert.True(t, ok)
+	assert.Equal(t, "jane@example.com", email)
+	email, _ = authors.Email("JDOE")
+	assert.Equal(t, "jane@example.com", email)
+	_, ok = authors.Email("Bob")
+	assert.False(t, ok)
+
+	_, err = LoadAuthorMap(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestClient_CommitAttribution(t *testing.T) {
+	logger := logrus.New()
+
+	setup := func(t *testing.T, attribution AttributionConfig) (*Client, *git.Repository, string) {
+		tempDir := t.TempDir()
+		repo, err := git.PlainInit(tempDir, false)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "authors.yaml"), []byte("Jane Doe: jane@example.com\n"), 0600))
+
+		client, err := NewClient(tempDir, &Config{
+			UserName:       "GitCells",
+			UserEmail:      "gitcells@localhost",
+			CommitTemplate: "{action} {filename} by {user}",
+			Attribution:    attribution,
+		}, logger)
+		require.NoError(t, err)
+		return client, repo, tempDir
+	}
+
+	change := func(t *testing.T, dir, name, modifiedBy string) CommitChange {
+		chunk := filepath.Join(dir, name+".json")
+		require.NoError(t, os.WriteFile(chunk, []byte(`{}`), 0600))
+		return CommitChange{Path: filepath.Join(dir, name), Action: "modify", Files: []string{chunk}, ModifiedBy: modifiedBy}
+	}
+
+	t.Run("workbook attribution credits each editor", func(t *testing.T) {
+		client, repo, dir := setup(t, AttributionConfig{Mode: AttributionWorkbook, AuthorsFile: "authors.yaml"})
+		require.NoError(t, client.CommitChanges([]CommitChange{
+			change(t, dir, "a.xlsx", "Jane Doe"),
+			change(t, dir, "b.xlsx", "Bob"),
+			change(t, dir, "c.xlsx", "jane doe"),
+		}))
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+		commit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, "Jane Doe", commit.Author.Name)
+		assert.Equal(t, "jane@example.com", commit.Author.Email)
+		assert.Equal(t, "GitCells", commit.Committer.Name)
+		assert.Equal(t, "gitcells@localhost", commit.Committer.Email)
+		assert.Equal(t, "modify a.xlsx, b.xlsx, c.xlsx by Jane Doe\n\nCo-authored-by: Bob <gitcells@localhost>\n", commit.Message)
+
+		// Undo recognises the commit by its committer
+		assert.True(t, client.isGitCellsCommit(commit, "{action} {filename} by {user}"))
+	})
+
+	t.Run("workbook attribution without LastModifiedBy uses the configured author", func(t *testing.T) {
+		client, repo, dir := setup(t, AttributionConfig{Mode: AttributionWorkbook})
+		require.NoError(t, client.CommitChanges([]CommitChange{change(t, dir, "a.xlsx", "")}))
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+		commit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, "GitCells", commit.Author.Name)
+		assert.Equal(t, "modify a.xlsx by GitCells", commit.Message)
+	})
+
+	t.Run("git attribution uses the repository's user", func(t *testing.T) {
+		client, repo, dir := setup(t, AttributionConfig{Mode: AttributionGit})
+		cfg, err := repo.Config()
+		require.NoError(t, err)
+		cfg.User.Name = "Alice"
+		cfg.User.Email = "alice@example.com"
+		require.NoError(t, repo.SetConfig(cfg))
+
+		require.NoError(t, client.AutoCommit([]string{change(t, dir, "a.xlsx", "").Files[0]}, "update"))
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+		commit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", commit.Author.Name)
+		assert.Equal(t, "alice@example.com", commit.Author.Email)
+		assert.Equal(t, "GitCells", commit.Committer.Name)
+	})
+
+	t.Run("rejects unknown modes and missing authors files", func(t *testing.T) {
+		tempDir := t.TempDir()
+		_, err := git.PlainInit(tempDir, false)
+		require.NoError(t, err)
+
+		_, err = NewClient(tempDir, &Config{Attribution: AttributionConfig{Mode: "excel"}}, logger)
+		assert.Error(t, err)
+		_, err = NewClient(tempDir, &Config{Attribution: AttributionConfig{Mode: AttributionOS, AuthorsFile: "missing.yaml"}}, logger)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,196 @@
+package git
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// CommitFunc commits a batch of changes
+type CommitFunc func(changes []CommitChange) error
+
+// CommitBatcher groups changes made within a time window into one commit.
+// The window starts with the first change of a batch, so a steady stream of
+// saves still commits at least once per window.
+type CommitBatcher struct {
+	window time.Duration
+	commit CommitFunc
+	logger *logrus.Logger
+
+	mu      sync.Mutex
+	pending []CommitChange
+	timer   *time.Timer
+}
+
+// NewCommitBatcher creates a batcher that passes batches to commit. A window
+// of zero or less commits every change as soon as it is added.
+func NewCommitBatcher(window time.Duration, commit CommitFunc, logger *logrus.Logger) *CommitBatcher {
+	return &CommitBatcher{
+		window: window,
+		commit: commit,
+		logger: logger,
+	}
+}
+
+// Add queues a change for the current batch. A later change to a workbook
+// already in the batch is merged into the earlier one. Without a window the
+// change is committed immediately and any commit error is returned.
+func (b *CommitBatcher) Add(change CommitChange) error {
+	if b.window <= 0 {
+		return b.commit([]CommitChange{change})
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending = mergeCommitChange(b.pending, change)
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.window, func() {
+			if err := b.Flush(); err != nil {
+				b.logger.Errorf("Failed to commit batched changes: %v", err)
+			}
+		})
+	}
+	return nil
+}
+
+// Flush commits any pending changes immediately
+func (b *CommitBatcher) Flush() error {
+	b.mu.Lock()
+	changes := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return b.commit(changes)
+}
+
+// Pending returns the number of changes waiting to be committed
+func (b *CommitBatcher) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// mergeCommitChange adds change to pending, combining it with an earlier
+// change to the same workbook. The combined change keeps the first action
+// unless the workbook was deleted or renamed, and combines the diffs of both.
+func mergeCommitChange(pending []CommitChange, change CommitChange) []CommitChange {
+	for i := range pending {
+		existing := &pending[i]
+		switch {
+		case change.OldPath != "" && existing.Path == change.OldPath:
+			// The workbook was renamed after the earlier change. A workbook
+			// created in the same batch is still reported as created.
+			existing.Path = change.Path
+			if existing.Action != "create" {
+				existing.Action = change.Action
+				if existing.OldPath == "" {
+					existing.OldPath = change.OldPath
+				}
+			}
+		case existing.Path != change.Path:
+			continue
+		case change.Action == "delete":
+			existing.Action = change.Action
+			// A workbook renamed and then deleted is reported by its
+			// original name
+			if existing.OldPath != "" {
+				existing.Path, existing.OldPath = existing.OldPath, ""
+			}
+		}
+		// Paths the later change removed can no longer be staged
+		existing.Files = appendMissing(removeItems(existing.Files, change.Removed), change.Files)
+		existing.Removed = appendMissing(existing.Removed, change.Removed)
+
+		switch {
+		case existing.Diff == nil:
+			existing.Diff = change.Diff
+		case change.Diff != nil:
+			merged := *change.Diff
+			merged.SheetDiffs = mergeSheetDiffs(append(append([]models.SheetDiff{}, existing.Diff.SheetDiffs...), change.Diff.SheetDiffs...))
+			existing.Diff = &merged
+		}
+		return pending
+	}
+	return append(pending, change)
+}
+
+// mergeSheetDiffs combines sheet diffs with the same sheet name. A cell
+// changed twice keeps its first old value and takes its latest new value.
+func mergeSheetDiffs(sheetDiffs []models.SheetDiff) []models.SheetDiff {
+	sheetIndex := make(map[string]int)
+	cellIndex := make(map[string]int)
+	var merged []models.SheetDiff
+
+	for _, sheetDiff := range sheetDiffs {
+		i, ok := sheetIndex[sheetDiff.SheetName]
+		if !ok {
+			i = len(merged)
+			sheetIndex[sheetDiff.SheetName] = i
+			merged = append(merged, models.SheetDiff{SheetName: sheetDiff.SheetName, Action: sheetDiff.Action})
+		} else if merged[i].Action == "" {
+			merged[i].Action = sheetDiff.Action
+		}
+
+		for _, change := range sheetDiff.Changes {
+			key := sheetDiff.SheetName + "!" + change.Cell
+			j, seen := cellIndex[key]
+			if !seen {
+				cellIndex[key] = len(merged[i].Changes)
+				merged[i].Changes = append(merged[i].Changes, change)
+				continue
+			}
+
+			existing := &merged[i].Changes[j]
+			existing.NewValue = change.NewValue
+			existing.NewFormula = change.NewFormula
+			existing.Description = change.Description
+			if change.Type == models.ChangeTypeDelete || existing.Type != models.ChangeTypeAdd {
+				existing.Type = change.Type
+			}
+		}
+	}
+	return merged
+}
+
+// appendMissing appends the items of extra not already in list
+func appendMissing(list, extra []string) []string {
+	seen := make(map[string]bool, len(list))
+	for _, item := range list {
+		seen[item] = true
+	}
+	for _, item := range extra {
+		if !seen[item] {
+			seen[item] = true
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// removeItems returns list without the items in drop
+func removeItems(list, drop []string) []string {
+	if len(drop) == 0 {
+		return list
+	}
+	dropped := make(map[string]bool, len(drop))
+	for _, item := range drop {
+		dropped[item] = true
+	}
+	var kept []string
+	for _, item := range list {
+		if !dropped[item] {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,132 @@
+package git
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/pkg/models"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRecorder collects the batches passed to a CommitFunc
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]CommitChange
+}
+
+func (r *batchRecorder) commit(changes []CommitChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, changes)
+	return nil
+}
+
+func (r *batchRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.batches)
+}
+
+func TestCommitBatcher(t *testing.T) {
+	logger := logrus.New()
+
+	t.Run("without a window every change commits immediately", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		batcher := NewCommitBatcher(0, recorder.commit, logger)
+
+		require.NoError(t, batcher.Add(createCommitChange("a.xlsx", "modify", "A1")))
+		require.NoError(t, batcher.Add(createCommitChange("b.xlsx", "modify", "A1")))
+
+		assert.Equal(t, 2, recorder.count())
+		assert.Zero(t, batcher.Pending())
+	})
+
+	t.Run("changes within the window share a commit", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		batcher := NewCommitBatcher(50*time.Millisecond, recorder.commit, logger)
+
+		require.NoError(t, batcher.Add(createCommitChange("a.xlsx", "modify", "A1")))
+		require.NoError(t, batcher.Add(createCommitChange("b.xlsx", "create", "A1")))
+		assert.Equal(t, 2, batcher.Pending())
+		assert.Zero(t, recorder.count())
+
+		assert.Eventually(t, func() bool { return recorder.count() == 1 }, time.Second, 10*time.Millisecond)
+		assert.Len(t, recorder.batches[0], 2)
+		assert.Zero(t, batcher.Pending())
+	})
+
+	t.Run("flush commits pending changes", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		batcher := NewCommitBatcher(time.Hour, recorder.commit, logger)
+
+		require.NoError(t, batcher.Add(createCommitChange("a.xlsx", "modify", "A1")))
+		require.NoError(t, batcher.Flush())
+		require.NoError(t, batcher.Flush())
+
+		assert.Equal(t, 1, recorder.count())
+	})
+
+	t.Run("repeated saves of a workbook are merged", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		batcher := NewCommitBatcher(time.Hour, recorder.commit, logger)
+
+		first := createCommitChange("a.xlsx", "create", "A1")
+		first.Diff.SheetDiffs[0].Changes[0].OldValue = "old"
+		first.Diff.SheetDiffs[0].Changes[0].NewValue = "middle"
+		second := createCommitChange("a.xlsx", "modify", "A1", "B1")
+		second.Diff.SheetDiffs[0].Changes[0].NewValue = "new"
+
+		require.NoError(t, batcher.Add(first))
+		require.NoError(t, batcher.Add(second))
+		require.NoError(t, batcher.Flush())
+
+		require.Equal(t, 1, recorder.count())
+		batch := recorder.batches[0]
+		require.Len(t, batch, 1)
+		assert.Equal(t, "create", batch[0].Action)
+		assert.Equal(t, []string{"a.xlsx.json"}, batch[0].Files)
+		assert.Equal(t, 2, batch[0].CellsChanged())
+
+		cell := batch[0].Diff.SheetDiffs[0].Changes[0]
+		assert.Equal(t, "old", cell.OldValue)
+		assert.Equal(t, "new", cell.NewValue)
+		assert.Equal(t, models.ChangeTypeModify, cell.Type)
+	})
+
+	t.Run("a rename follows an earlier change", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		batcher := NewCommitBatcher(time.Hour, recorder.commit, logger)
+
+		renamed := createCommitChange("b.xlsx", "rename")
+		renamed.OldPath = "a.xlsx"
+		renamed.Removed = []string{"a.xlsx.json"}
+
+		require.NoError(t, batcher.Add(createCommitChange("a.xlsx", "modify", "A1")))
+		require.NoError(t, batcher.Add(renamed))
+		require.NoError(t, batcher.Flush())
+
+		batch := recorder.batches[0]
+		require.Len(t, batch, 1)
+		assert.Equal(t, "rename", batch[0].Action)
+		assert.Equal(t, "b.xlsx", batch[0].Path)
+		assert.Equal(t, "a.xlsx", batch[0].OldPath)
+		assert.Equal(t, []string{"b.xlsx.json"}, batch[0].Files)
+		assert.Equal(t, []string{"a.xlsx.json"}, batch[0].Removed)
+
+		// Deleting the renamed workbook reports the original name
+		deleted := CommitChange{Path: "b.xlsx", Action: "delete", Removed: []string{"b.xlsx.json"}}
+		require.NoError(t, batcher.Add(renamed))
+		require.NoError(t, batcher.Add(deleted))
+		require.NoError(t, batcher.Flush())
+
+		batch = recorder.batches[1]
+		require.Len(t, batch, 1)
+		assert.Equal(t, "delete", batch[0].Action)
+		assert.Equal(t, "a.xlsx", batch[0].Path)
+		assert.Empty(t, batch[0].Files)
+		assert.Equal(t, []string{"a.xlsx.json", "b.xlsx.json"}, batch[0].Removed)
+	})
+}
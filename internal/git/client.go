@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/Classic-Homes/gitcells/internal/utils"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/sirupsen/logrus"
 )
@@ -20,12 +22,25 @@ type Client struct {
 	worktree *git.Worktree
 	config   *Config
 	logger   *logrus.Logger
+	signer   git.Signer
+	authors  AuthorMap
+
+	// mu serializes operations that change the index and commit, so that
+	// concurrent conversions do not commit each other's files
+	mu sync.Mutex
 }
 
 type Config struct {
 	UserName       string
 	UserEmail      string
 	CommitTemplate string
+	// CommitBody adds a summary of the changes to commit messages
+	CommitBody bool
+	// Signing signs commits with an OpenPGP or SSH key
+	Signing SigningConfig
+	// Attribution chooses the author of commits; UserName and UserEmail
+	// remain the committer
+	Attribution AttributionConfig
 }
 
 func NewClient(repoPath string, config *Config, logger *logrus.Logger) (*Client, error) {
@@ -46,11 +61,23 @@ func NewClient(repoPath string, config *Config, logger *logrus.Logger) (*Client,
 		return nil, utils.WrapError(err, utils.ErrorTypeGit, "getWorktree", "failed to get git worktree")
 	}
 
+	signer, err := NewSigner(config.Signing)
+	if err != nil {
+		return nil, err
+	}
+
+	authors, err := validateAttribution(config.Attribution, worktree.Filesystem.Root())
+	if err != nil {
+		return nil, err
+	}
+
 	return &Client{
 		repo:     repo,
 		worktree: worktree,
 		config:   config,
 		logger:   logger,
+		signer:   signer,
+		authors:  authors,
 	}, nil
 }
 
@@ -60,13 +87,32 @@ func (c *Client) AutoCommit(files []string, message string) error {
 		// No git repository - skip commit
 		return nil
 	}
+	return c.commitFiles(files, nil, message, c.commitAuthors(nil))
+}
+
+// commitFiles stages files and the deletion of removed paths and commits
+// them, crediting the first of authors as the author and the rest as
+// co-authors. Changes staged to other paths stay staged and are not part
+// of the commit.
+func (c *Client) commitFiles(files, removed []string, message string, authors []object.Signature) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	// Stage files
+	var paths []string
 	for _, file := range files {
 		relPath, _ := filepath.Rel(c.worktree.Filesystem.Root(), file)
 		if _, err := c.worktree.Add(relPath); err != nil {
 			return utils.WrapFileError(err, utils.ErrorTypeGit, "stageFile", file, "failed to stage file")
 		}
+		paths = append(paths, filepath.ToSlash(relPath))
+	}
+	for _, path := range removed {
+		relPath, _ := filepath.Rel(c.worktree.Filesystem.Root(), path)
+		if err := c.stageRemoval(relPath); err != nil {
+			return utils.WrapFileError(err, utils.ErrorTypeGit, "stageFile", path, "failed to stage removal")
+		}
+		paths = append(paths, filepath.ToSlash(relPath))
 	}
 
 	// Check if there are changes to commit
@@ -75,7 +121,15 @@ func (c *Client) AutoCommit(files []string, message string) error {
 		return utils.WrapError(err, utils.ErrorTypeGit, "getStatus", "failed to get git status")
 	}
 
-	if status.IsClean() {
+	owns := func(name string) bool {
+		for _, path := range paths {
+			if name == path || strings.HasPrefix(name, path+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasStaged(status, owns) {
 		c.logger.Debug("No changes to commit")
 		return nil
 	}
@@ -85,13 +139,20 @@ func (c *Client) AutoCommit(files []string, message string) error {
 		message = fmt.Sprintf("GitCells: Update JSON representations (%d files)", len(files))
 	}
 
-	// Create commit
-	commit, err := c.worktree.Commit(message, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  c.config.UserName,
-			Email: c.config.UserEmail,
-			When:  time.Now(),
-		},
+	// Create commit. GitCells is always the committer, so its commits can
+	// be told apart whoever authored the change.
+	var commit plumbing.Hash
+	err = c.commitOnly(status, owns, func() (err error) {
+		commit, err = c.worktree.Commit(coAuthorTrailers(message, authors), &git.CommitOptions{
+			Author: &authors[0],
+			Committer: &object.Signature{
+				Name:  c.config.UserName,
+				Email: c.config.UserEmail,
+				When:  authors[0].When,
+			},
+			Signer: c.signer,
+		})
+		return err
 	})
 
 	if err != nil {
@@ -102,6 +163,72 @@ func (c *Client) AutoCommit(files []string, message string) error {
 	return nil
 }
 
+// stageRemoval stages the deletion of the tracked files at or below a
+// removed path, such as the chunk directory of a deleted workbook. Paths
+// that were never committed are ignored.
+func (c *Client) stageRemoval(relPath string) error {
+	status, err := c.worktree.Status()
+	if err != nil {
+		return err
+	}
+	prefix := filepath.ToSlash(relPath)
+	for name, fileStatus := range status {
+		if fileStatus.Worktree == git.Deleted && (name == prefix || strings.HasPrefix(name, prefix+"/")) {
+			if _, err := c.worktree.Add(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CommitChanges commits a batch of workbook changes with a message rendered
+// from the configured commit template
+func (c *Client) CommitChanges(changes []CommitChange) error {
+	if c == nil || len(changes) == 0 {
+		return nil
+	}
+
+	// Describe workbooks by their path in the repository
+	root := c.worktree.Filesystem.Root()
+	described := make([]CommitChange, len(changes))
+	var files, removed []string
+	for i, change := range changes {
+		files = appendMissing(files, change.Files)
+		removed = appendMissing(removed, change.Removed)
+		described[i] = change
+		described[i].Path = repositoryPath(root, change.Path)
+		if change.OldPath != "" {
+			described[i].OldPath = repositoryPath(root, change.OldPath)
+		}
+	}
+	changes = described
+
+	authors := c.commitAuthors(changes)
+	template := c.config.CommitTemplate
+	if template != "" {
+		template = strings.ReplaceAll(template, "{user}", authors[0].Name)
+	}
+
+	message := RenderCommitMessage(template, changes, time.Now())
+	if c.config.CommitBody {
+		message += "\n\n" + CommitBody(changes)
+	}
+
+	return c.commitFiles(files, removed, message, authors)
+}
+
+// repositoryPath returns path relative to the repository root, or path
+// unchanged if it is outside the repository
+func repositoryPath(root, path string) string {
+	if absPath, err := filepath.Abs(path); err == nil {
+		if relPath, err := filepath.Rel(root, absPath); err == nil && !strings.HasPrefix(relPath, "..") {
+			return filepath.ToSlash(relPath)
+		}
+	}
+	return path
+}
+
 // IsClean returns true if the working directory is clean
 func (c *Client) IsClean() (bool, error) {
 	if c == nil {
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -426,3 +428,188 @@ func TestIntegrationWorkflow(t *testing.T) {
 	assert.Equal(t, "Integration Test", commit.Author.Name)
 	assert.Equal(t, "integration@example.com", commit.Author.Email)
 }
+
+func TestClient_CommitChanges(t *testing.T) {
+	logger := logrus.New()
+	tempDir := t.TempDir()
+
+	repo, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+
+	config := &Config{
+		UserName:       "Test User",
+		UserEmail:      "test@example.com",
+		CommitTemplate: "{user}: {action} {filename}, {cells_changed} cells",
+		CommitBody:     true,
+	}
+	client, err := NewClient(tempDir, config, logger)
+	require.NoError(t, err)
+
+	var changes []CommitChange
+	for _, name := range []string{"a.xlsx", "b.xlsx"} {
+		chunk := filepath.Join(tempDir, name+".json")
+		require.NoError(t, os.WriteFile(chunk, []byte(`{}`), 0600))
+
+		change := createCommitChange(name, "modify", "A1")
+		change.Files = []string{chunk}
+		changes = append(changes, change)
+	}
+
+	require.NoError(t, client.CommitChanges(changes))
+
+	ref, err := repo.Head()
+	require.NoError(t, err)
+	commit, err := repo.CommitObject(ref.Hash())
+	require.NoError(t, err)
+
+	expected := "Test User: modify a.xlsx, b.xlsx, 2 cells\n\n" +
+		"a.xlsx (modify): 1 sheet(s), 1 cell(s) changed\n" +
+		"  - Sheet1: 0 added, 1 modified, 0 deleted\n" +
+		"b.xlsx (modify): 1 sheet(s), 1 cell(s) changed\n" +
+		"  - Sheet1: 0 added, 1 modified, 0 deleted"
+	assert.Equal(t, expected, commit.Message)
+
+	stats, err := commit.Stats()
+	require.NoError(t, err)
+	assert.Len(t, stats, 2)
+}
+
+func TestClient_CommitChangesRemoved(t *testing.T) {
+	logger := logrus.New()
+	tempDir := t.TempDir()
+
+	repo, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+	client, err := NewClient(tempDir, &Config{UserName: "Test User", UserEmail: "test@example.com"}, logger)
+	require.NoError(t, err)
+
+	oldDir := filepath.Join(tempDir, ".gitcells", "data", "a.xlsx_chunks")
+	require.NoError(t, os.MkdirAll(oldDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(oldDir, "workbook.json"), []byte(`{}`), 0600))
+	require.NoError(t, client.AutoCommit([]string{oldDir}, "add a.xlsx"))
+
+	// Rename the chunks of a.xlsx to b.xlsx; the never committed chunks of
+	// c.xlsx are ignored
+	newDir := filepath.Join(tempDir, ".gitcells", "data", "b.xlsx_chunks")
+	require.NoError(t, os.Rename(oldDir, newDir))
+	require.NoError(t, client.CommitChanges([]CommitChange{{
+		Path:    filepath.Join(tempDir, "b.xlsx"),
+		OldPath: filepath.Join(tempDir, "a.xlsx"),
+		Action:  "rename",
+		Files:   []string{newDir},
+		Removed: []string{oldDir, filepath.Join(tempDir, ".gitcells", "data", "c.xlsx_chunks")},
+	}}))
+
+	ref, err := repo.Head()
+	require.NoError(t, err)
+	commit, err := repo.CommitObject(ref.Hash())
+	require.NoError(t, err)
+	assert.Contains(t, commit.Message, "GitCells: rename a.xlsx -> b.xlsx at ")
+
+	tree, err := commit.Tree()
+	require.NoError(t, err)
+	_, err = tree.File(".gitcells/data/a.xlsx_chunks/workbook.json")
+	assert.Error(t, err)
+	_, err = tree.File(".gitcells/data/b.xlsx_chunks/workbook.json")
+	assert.NoError(t, err)
+
+	clean, err := client.IsClean()
+	require.NoError(t, err)
+	assert.True(t, clean)
+}
+
+func TestClient_CommitChangesConcurrent(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+	client, err := NewClient(tempDir, &Config{UserName: "Test User", UserEmail: "test@example.com"}, logrus.New())
+	require.NoError(t, err)
+
+	names := []string{"a.xlsx", "b.xlsx", "c.xlsx", "d.xlsx"}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		dir := filepath.Join(tempDir, ".gitcells", "data", name+"_chunks")
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		var files []string
+		for _, chunk := range []string{"workbook.json", "sheet_Sheet1.json"} {
+			file := filepath.Join(dir, chunk)
+			require.NoError(t, os.WriteFile(file, []byte(name), 0600))
+			files = append(files, file)
+		}
+
+		wg.Add(1)
+		go func(name string, files []string) {
+			defer wg.Done()
+			change := createCommitChange(name, "add", "A1")
+			change.Files = files
+			assert.NoError(t, client.CommitChanges([]CommitChange{change}))
+		}(name, files)
+	}
+	wg.Wait()
+
+	// Each workbook has a commit of its own chunks and nothing else
+	log, err := repo.Log(&git.LogOptions{})
+	require.NoError(t, err)
+	committed := map[string]bool{}
+	require.NoError(t, log.ForEach(func(commit *object.Commit) error {
+		stats, err := commit.Stats()
+		require.NoError(t, err)
+		require.Len(t, stats, 2, commit.Message)
+		for _, stat := range stats {
+			name := strings.TrimSuffix(strings.Split(stat.Name, "/")[2], "_chunks")
+			assert.Contains(t, commit.Message, name)
+			committed[name] = true
+		}
+		return nil
+	}))
+	assert.Len(t, committed, len(names))
+
+	clean, err := client.IsClean()
+	require.NoError(t, err)
+	assert.True(t, clean)
+}
+
+func TestClient_CommitChangesLeavesOtherStagedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+	client, err := NewClient(tempDir, &Config{UserName: "Test User", UserEmail: "test@example.com"}, logrus.New())
+	require.NoError(t, err)
+
+	notes := filepath.Join(tempDir, "notes.txt")
+	require.NoError(t, os.WriteFile(notes, []byte("first"), 0600))
+	require.NoError(t, client.AutoCommit([]string{notes}, "add notes"))
+
+	// The user stages an edit and a new file of their own
+	require.NoError(t, os.WriteFile(notes, []byte("second"), 0600))
+	todo := filepath.Join(tempDir, "todo.txt")
+	require.NoError(t, os.WriteFile(todo, []byte("todo"), 0600))
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Add("notes.txt")
+	require.NoError(t, err)
+	_, err = worktree.Add("todo.txt")
+	require.NoError(t, err)
+
+	chunk := filepath.Join(tempDir, "a.xlsx.json")
+	require.NoError(t, os.WriteFile(chunk, []byte(`{}`), 0600))
+	change := createCommitChange("a.xlsx", "add", "A1")
+	change.Files = []string{chunk}
+	require.NoError(t, client.CommitChanges([]CommitChange{change}))
+
+	ref, err := repo.Head()
+	require.NoError(t, err)
+	commit, err := repo.CommitObject(ref.Hash())
+	require.NoError(t, err)
+	stats, err := commit.Stats()
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "a.xlsx.json", stats[0].Name)
+
+	status, err := client.Status()
+	require.NoError(t, err)
+	assert.Equal(t, git.Modified, status.File("notes.txt").Staging)
+	assert.Equal(t, git.Added, status.File("todo.txt").Staging)
+	_, changed := status["a.xlsx.json"]
+	assert.False(t, changed)
+}
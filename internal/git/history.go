@@ -0,0 +1,118 @@
+package git
+
+import (
+	"sort"
+
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// WorkbookCommit is a commit that changed the chunk data of workbooks
+type WorkbookCommit struct {
+	Commit *object.Commit
+	// Parent is the commit the changes are relative to, or nil for a root
+	// commit
+	Parent *object.Commit
+	// Workbooks are the repository-relative paths of the changed workbooks
+	Workbooks []string
+}
+
+// ChunkHistory returns the commits reachable from to but not from from that
+// changed chunk data, oldest first. An empty from covers the whole history.
+// Merge commits are skipped; the commits they merge are listed instead.
+func (c *Client) ChunkHistory(from, to string) ([]WorkbookCommit, error) {
+	tip, err := c.ResolveRevision(to)
+	if err != nil {
+		return nil, err
+	}
+	var base *object.Commit
+	if from != "" {
+		if base, err = c.ResolveRevision(from); err != nil {
+			return nil, err
+		}
+	}
+
+	commits, err := commitsBetween(base, tip)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []WorkbookCommit
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		if commit.NumParents() > 1 {
+			continue
+		}
+
+		entry := WorkbookCommit{Commit: commit}
+		var parentTree *object.Tree
+		if commit.NumParents() == 1 {
+			if entry.Parent, err = commit.Parent(0); err != nil {
+				return nil, utils.WrapError(err, utils.ErrorTypeGit, "chunkHistory", "failed to load parent of "+commit.Hash.String())
+			}
+			if parentTree, err = entry.Parent.Tree(); err != nil {
+				return nil, utils.WrapError(err, utils.ErrorTypeGit, "chunkHistory", "failed to read tree of "+entry.Parent.Hash.String())
+			}
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, utils.WrapError(err, utils.ErrorTypeGit, "chunkHistory", "failed to read tree of "+commit.Hash.String())
+		}
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return nil, utils.WrapError(err, utils.ErrorTypeGit, "chunkHistory", "failed to compare "+commit.Hash.String()+" with its parent")
+		}
+
+		if entry.Workbooks = c.changedWorkbooks(changes, false); len(entry.Workbooks) > 0 {
+			history = append(history, entry)
+		}
+	}
+	return history, nil
+}
+
+// commitsBetween returns the commits reachable from tip but not from base,
+// newest first. A nil base returns the whole history of tip.
+func commitsBetween(base, tip *object.Commit) ([]*object.Commit, error) {
+	seen := map[plumbing.Hash]bool{}
+	if base != nil {
+		err := object.NewCommitPreorderIter(base, nil, nil).ForEach(func(commit *object.Commit) error {
+			seen[commit.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, utils.WrapError(err, utils.ErrorTypeGit, "commitsBetween", "failed to read history of "+base.Hash.String())
+		}
+	}
+
+	var commits []*object.Commit
+	err := object.NewCommitPreorderIter(tip, seen, nil).ForEach(func(commit *object.Commit) error {
+		commits = append(commits, commit)
+		return nil
+	})
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "commitsBetween", "failed to read history of "+tip.Hash.String())
+	}
+	return commits, nil
+}
+
+// changedWorkbooks returns the sorted workbooks whose chunk data is part of
+// changes, and with includeExcel also those whose Excel file is
+func (c *Client) changedWorkbooks(changes object.Changes, includeExcel bool) []string {
+	seen := map[string]bool{}
+	var workbooks []string
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			workbook, ok := WorkbookForChunkPath(c.Root(), name)
+			if !ok && includeExcel && isExcelPath(name) {
+				workbook, ok = name, true
+			}
+			if ok && !seen[workbook] {
+				seen[workbook] = true
+				workbooks = append(workbooks, workbook)
+			}
+		}
+	}
+	sort.Strings(workbooks)
+	return workbooks
+}
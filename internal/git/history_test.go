@@ -0,0 +1,61 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ChunkHistory(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+	client, err := NewClient(tempDir, &Config{UserName: "GitCells", UserEmail: "gitcells@localhost"}, logrus.New())
+	require.NoError(t, err)
+
+	commitFile := func(relPath, content, message string) {
+		file := filepath.Join(tempDir, filepath.FromSlash(relPath))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+		require.NoError(t, client.AutoCommit([]string{file}, message))
+	}
+
+	commitFile(".gitcells/data/Budget.xlsx_chunks/workbook.json", "1", "first")
+	head, err := repo.Head()
+	require.NoError(t, err)
+	_, err = repo.CreateTag("v1", head.Hash(), nil)
+	require.NoError(t, err)
+	commitFile("README.md", "docs", "docs only")
+	commitFile(".gitcells/data/reports/Q1.xlsx_chunks/workbook.json", "1", "second")
+	commitFile(".gitcells/data/Budget.xlsx_chunks/sheet_Sheet1.json", "2", "third")
+
+	t.Run("whole history", func(t *testing.T) {
+		history, err := client.ChunkHistory("", "HEAD")
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+
+		assert.Equal(t, "first", history[0].Commit.Message)
+		assert.Nil(t, history[0].Parent)
+		assert.Equal(t, []string{"Budget.xlsx"}, history[0].Workbooks)
+		assert.Equal(t, []string{"reports/Q1.xlsx"}, history[1].Workbooks)
+		assert.Equal(t, "third", history[2].Commit.Message)
+		assert.NotNil(t, history[2].Parent)
+	})
+
+	t.Run("range", func(t *testing.T) {
+		history, err := client.ChunkHistory("v1", "HEAD~1")
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, "second", history[0].Commit.Message)
+	})
+
+	t.Run("unknown revision", func(t *testing.T) {
+		_, err := client.ChunkHistory("nope", "HEAD")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,132 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/Classic-Homes/gitcells/internal/utils"
+)
+
+const (
+	// HookMarker identifies hook scripts written by GitCells
+	HookMarker = "# gitcells-hook"
+	// ChainedHookSuffix is appended to a hook that existed before GitCells
+	// installed its own. The GitCells hook runs it first.
+	ChainedHookSuffix = ".pre-gitcells"
+
+	hookPermissions = 0755
+)
+
+// HookState describes what InstallHook and UninstallHook did
+type HookState string
+
+const (
+	HookInstalled HookState = "installed"
+	HookUpdated   HookState = "updated"
+	HookChained   HookState = "installed, existing hook chained"
+	HookRemoved   HookState = "removed"
+	HookRestored  HookState = "removed, previous hook restored"
+	HookNotOurs   HookState = "not installed by gitcells, left unchanged"
+	HookMissing   HookState = "not installed"
+)
+
+// HooksDir returns the directory git runs hooks from, honouring
+// core.hooksPath
+func (c *Client) HooksDir() (string, error) {
+	if c == nil {
+		return "", utils.NewError(utils.ErrorTypeGit, "hooksDir", "not a git repository")
+	}
+
+	cfg, err := c.repo.Config()
+	if err != nil {
+		return "", utils.WrapError(err, utils.ErrorTypeGit, "hooksDir", "failed to read git config")
+	}
+
+	if hooksPath := cfg.Raw.Section("core").Option("hooksPath"); hooksPath != "" {
+		if filepath.IsAbs(hooksPath) {
+			return hooksPath, nil
+		}
+		return filepath.Join(c.Root(), hooksPath), nil
+	}
+	return filepath.Join(c.Root(), ".git", "hooks"), nil
+}
+
+// InstallHook writes a GitCells hook script. A hook installed by GitCells
+// is replaced; any other existing hook is renamed with ChainedHookSuffix so
+// the new script can run it first. The script must contain HookMarker.
+func (c *Client) InstallHook(name, script string) (HookState, error) {
+	dir, err := c.HooksDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, hookPermissions); err != nil {
+		return "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "installHook", dir, "failed to create hooks directory")
+	}
+
+	hookPath := filepath.Join(dir, name)
+	state := HookInstalled
+
+	existing, err := os.ReadFile(hookPath) // #nosec G304 - hook path within the repository
+	switch {
+	case err == nil && bytes.Contains(existing, []byte(HookMarker)):
+		state = HookUpdated
+	case err == nil:
+		chainedPath := hookPath + ChainedHookSuffix
+		if _, statErr := os.Stat(chainedPath); statErr == nil {
+			return "", utils.NewError(utils.ErrorTypeGit, "installHook",
+				"cannot chain existing "+name+" hook: "+chainedPath+" already exists")
+		}
+		if err := os.Rename(hookPath, chainedPath); err != nil {
+			return "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "installHook", hookPath, "failed to move existing hook")
+		}
+		state = HookChained
+	case !os.IsNotExist(err):
+		return "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "installHook", hookPath, "failed to read existing hook")
+	}
+
+	// #nosec G306 - hooks must be executable
+	if err := os.WriteFile(hookPath, []byte(script), hookPermissions); err != nil {
+		return "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "installHook", hookPath, "failed to write hook")
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(hookPath, hookPermissions); err != nil {
+		return "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "installHook", hookPath, "failed to make hook executable")
+	}
+
+	return state, nil
+}
+
+// UninstallHook removes a hook installed by GitCells and restores the hook
+// it chained, if any. Hooks written by anything else are left alone.
+func (c *Client) UninstallHook(name string) (HookState, error) {
+	dir, err := c.HooksDir()
+	if err != nil {
+		return "", err
+	}
+
+	hookPath := filepath.Join(dir, name)
+	existing, err := os.ReadFile(hookPath) // #nosec G304 - hook path within the repository
+	if os.IsNotExist(err) {
+		return HookMissing, nil
+	}
+	if err != nil {
+		return "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "uninstallHook", hookPath, "failed to read hook")
+	}
+	if !bytes.Contains(existing, []byte(HookMarker)) {
+		return HookNotOurs, nil
+	}
+
+	if err := os.Remove(hookPath); err != nil {
+		return "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "uninstallHook", hookPath, "failed to remove hook")
+	}
+
+	chainedPath := hookPath + ChainedHookSuffix
+	if _, err := os.Stat(chainedPath); err == nil {
+		if err := os.Rename(chainedPath, hookPath); err != nil {
+			return "", utils.WrapFileError(err, utils.ErrorTypeFileSystem, "uninstallHook", chainedPath, "failed to restore previous hook")
+		}
+		return HookRestored, nil
+	}
+	return HookRemoved, nil
+}
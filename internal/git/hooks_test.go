@@ -0,0 +1,99 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_InstallHook(t *testing.T) {
+	logger := logrus.New()
+	script := "#!/bin/sh\n" + HookMarker + ": pre-commit\nexit 0\n"
+
+	setup := func(t *testing.T) (*Client, string) {
+		tempDir := t.TempDir()
+		_, err := git.PlainInit(tempDir, false)
+		require.NoError(t, err)
+
+		client, err := NewClient(tempDir, &Config{}, logger)
+		require.NoError(t, err)
+		return client, filepath.Join(tempDir, ".git", "hooks")
+	}
+
+	t.Run("installs and updates", func(t *testing.T) {
+		client, hooksDir := setup(t)
+
+		state, err := client.InstallHook("pre-commit", script)
+		require.NoError(t, err)
+		assert.Equal(t, HookInstalled, state)
+
+		info, err := os.Stat(filepath.Join(hooksDir, "pre-commit"))
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode()&0100, "hook should be executable")
+
+		state, err = client.InstallHook("pre-commit", script)
+		require.NoError(t, err)
+		assert.Equal(t, HookUpdated, state)
+	})
+
+	t.Run("chains an existing hook and restores it", func(t *testing.T) {
+		client, hooksDir := setup(t)
+		hookPath := filepath.Join(hooksDir, "pre-commit")
+		require.NoError(t, os.MkdirAll(hooksDir, 0755))
+		require.NoError(t, os.WriteFile(hookPath, []byte("#!/bin/sh\necho mine\n"), 0755)) // #nosec G306
+
+		state, err := client.InstallHook("pre-commit", script)
+		require.NoError(t, err)
+		assert.Equal(t, HookChained, state)
+
+		chained, err := os.ReadFile(hookPath + ChainedHookSuffix)
+		require.NoError(t, err)
+		assert.Contains(t, string(chained), "echo mine")
+
+		state, err = client.UninstallHook("pre-commit")
+		require.NoError(t, err)
+		assert.Equal(t, HookRestored, state)
+
+		restored, err := os.ReadFile(hookPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(restored), "echo mine")
+		assert.NoFileExists(t, hookPath+ChainedHookSuffix)
+	})
+
+	t.Run("leaves foreign hooks alone on uninstall", func(t *testing.T) {
+		client, hooksDir := setup(t)
+		require.NoError(t, os.MkdirAll(hooksDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(hooksDir, "post-merge"), []byte("#!/bin/sh\n"), 0755)) // #nosec G306
+
+		state, err := client.UninstallHook("post-merge")
+		require.NoError(t, err)
+		assert.Equal(t, HookNotOurs, state)
+		assert.FileExists(t, filepath.Join(hooksDir, "post-merge"))
+
+		state, err = client.UninstallHook("post-checkout")
+		require.NoError(t, err)
+		assert.Equal(t, HookMissing, state)
+	})
+
+	t.Run("honours core.hooksPath", func(t *testing.T) {
+		client, _ := setup(t)
+
+		cfg, err := client.repo.Config()
+		require.NoError(t, err)
+		cfg.Raw.Section("core").SetOption("hooksPath", ".githooks")
+		require.NoError(t, client.repo.SetConfig(cfg))
+
+		dir, err := client.HooksDir()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(client.Root(), ".githooks"), dir)
+
+		_, err = client.InstallHook("pre-commit", script)
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(client.Root(), ".githooks", "pre-commit"))
+	})
+}
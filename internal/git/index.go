@@ -0,0 +1,175 @@
+package git
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Status returns the staged and working tree state of every changed file,
+// keyed by slash-separated path relative to the repository root
+func (c *Client) Status() (git.Status, error) {
+	if c == nil {
+		return git.Status{}, nil
+	}
+	status, err := c.worktree.Status()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "getStatus", "failed to get git status")
+	}
+	return status, nil
+}
+
+// IsTracked reports whether a repository-relative path is in the index
+func (c *Client) IsTracked(relPath string) bool {
+	if c == nil {
+		return false
+	}
+	_, err := c.indexEntry(relPath)
+	return err == nil
+}
+
+// ReadStaged returns the staged content of a repository-relative path
+func (c *Client) ReadStaged(relPath string) (io.ReadCloser, error) {
+	if c == nil {
+		return nil, utils.NewError(utils.ErrorTypeGit, "readStaged", "not a git repository")
+	}
+
+	entry, err := c.indexEntry(relPath)
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeGit, "readStaged", relPath, "file is not staged")
+	}
+
+	blob, err := c.repo.BlobObject(entry.Hash)
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeGit, "readStaged", relPath, "failed to read staged content")
+	}
+	return blob.Reader()
+}
+
+// ReadFileAt returns the content of a repository-relative path at rev
+func (c *Client) ReadFileAt(rev, relPath string) (io.ReadCloser, error) {
+	commit, err := c.ResolveCommit(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := commit.File(filepath.ToSlash(relPath))
+	if err == object.ErrFileNotFound {
+		return nil, utils.NewError(utils.ErrorTypeGit, "readFileAt", relPath+" does not exist at "+rev)
+	}
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeGit, "readFileAt", relPath, "failed to read file at "+rev)
+	}
+	return file.Reader()
+}
+
+// TrackedFiles returns the slash-separated paths of every file in the index
+func (c *Client) TrackedFiles() ([]string, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	idx, err := c.repo.Storer.Index()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "trackedFiles", "failed to read git index")
+	}
+
+	paths := make([]string, 0, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		paths = append(paths, entry.Name)
+	}
+	return paths, nil
+}
+
+// indexEntry returns the index entry of a repository-relative path
+func (c *Client) indexEntry(relPath string) (*index.Entry, error) {
+	idx, err := c.repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	return idx.Entry(filepath.ToSlash(relPath))
+}
+
+// staged reports whether a file status has a change in the index
+func staged(fileStatus *git.FileStatus) bool {
+	return fileStatus.Staging != git.Unmodified && fileStatus.Staging != git.Untracked
+}
+
+// hasStaged reports whether any path that owns accepts has a staged change
+func hasStaged(status git.Status, owns func(name string) bool) bool {
+	for name, fileStatus := range status {
+		if owns(name) && staged(fileStatus) {
+			return true
+		}
+	}
+	return false
+}
+
+// commitOnly runs commit with only the staged changes of paths that owns
+// accepts in the index. Changes staged to other paths, such as by the
+// user, are set aside while commit runs and staged again afterwards.
+func (c *Client) commitOnly(status git.Status, owns func(name string) bool, commit func() error) error {
+	var others []string
+	for name, fileStatus := range status {
+		if !owns(name) && staged(fileStatus) {
+			others = append(others, name)
+		}
+	}
+	if len(others) == 0 {
+		return commit()
+	}
+
+	idx, err := c.repo.Storer.Index()
+	if err != nil {
+		return utils.WrapError(err, utils.ErrorTypeGit, "commit", "failed to read git index")
+	}
+	var headTree *object.Tree
+	if head, err := c.ResolveCommit("HEAD"); err == nil {
+		if headTree, err = head.Tree(); err != nil {
+			return utils.WrapError(err, utils.ErrorTypeGit, "commit", "failed to read commit tree")
+		}
+	}
+
+	// Put back the committed version of each other path, or drop it from
+	// the index if HEAD does not have it
+	saved := make(map[string]*index.Entry, len(others))
+	for _, name := range others {
+		if entry, err := idx.Remove(name); err == nil {
+			saved[name] = entry
+		}
+		if headTree == nil {
+			continue
+		}
+		if committed, err := headTree.FindEntry(name); err == nil {
+			entry := idx.Add(name)
+			entry.Hash = committed.Hash
+			entry.Mode = committed.Mode
+		}
+	}
+	if err := c.repo.Storer.SetIndex(idx); err != nil {
+		return utils.WrapError(err, utils.ErrorTypeGit, "commit", "failed to write git index")
+	}
+
+	commitErr := commit()
+
+	if idx, err = c.repo.Storer.Index(); err == nil {
+		for _, name := range others {
+			_, _ = idx.Remove(name)
+			if entry := saved[name]; entry != nil {
+				idx.Entries = append(idx.Entries, entry)
+			}
+		}
+		err = c.repo.Storer.SetIndex(idx)
+	}
+	if commitErr != nil {
+		return commitErr
+	}
+	if err != nil {
+		return utils.WrapError(err, utils.ErrorTypeGit, "commit", "failed to restore staged changes")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Index(t *testing.T) {
+	tempDir := t.TempDir()
+	_, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+
+	client, err := NewClient(tempDir, &Config{UserName: "Test", UserEmail: "test@example.com"}, logrus.New())
+	require.NoError(t, err)
+
+	file := filepath.Join(tempDir, "data.json")
+	require.NoError(t, os.WriteFile(file, []byte("committed"), 0600))
+	require.NoError(t, client.AutoCommit([]string{file}, "first"))
+
+	// Stage a change, then edit the working copy again
+	require.NoError(t, os.WriteFile(file, []byte("staged"), 0600))
+	_, err = client.worktree.Add("data.json")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, []byte("working"), 0600))
+
+	readAll := func(r io.ReadCloser, err error) string {
+		require.NoError(t, err)
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.True(t, client.IsTracked("data.json"))
+	assert.False(t, client.IsTracked("other.json"))
+	assert.Equal(t, "staged", readAll(client.ReadStaged("data.json")))
+	assert.Equal(t, "committed", readAll(client.ReadFileAt("HEAD", "data.json")))
+
+	_, err = client.ReadFileAt("HEAD", "other.json")
+	assert.Error(t, err)
+
+	status, err := client.Status()
+	require.NoError(t, err)
+	assert.Equal(t, git.Modified, status.File("data.json").Staging)
+	assert.Equal(t, git.Modified, status.File("data.json").Worktree)
+}
@@ -0,0 +1,147 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/pkg/models"
+)
+
+// maxListedFiles is the number of file names {filename} lists before
+// falling back to a count
+const maxListedFiles = 3
+
+// CommitChange describes a change to one workbook that goes into a commit
+type CommitChange struct {
+	// Path is the workbook that changed
+	Path string
+	// OldPath is the previous path of a renamed workbook
+	OldPath string
+	// Action is the kind of change, e.g. create, modify or delete
+	Action string
+	// Files are the paths to stage for this change
+	Files []string
+	// Removed are deleted paths, such as the chunk directory of a deleted
+	// or renamed workbook, whose tracked files are removed from the index
+	Removed []string
+	// Diff is the change to the workbook's content, if known
+	Diff *models.ExcelDiff
+	// ModifiedBy is the workbook's LastModifiedBy property, used by
+	// workbook attribution
+	ModifiedBy string
+}
+
+// SheetsChanged returns the number of sheets touched by the change
+func (c CommitChange) SheetsChanged() int {
+	if c.Diff == nil {
+		return 0
+	}
+	return len(c.Diff.SheetDiffs)
+}
+
+// CellsChanged returns the number of cells touched by the change
+func (c CommitChange) CellsChanged() int {
+	if c.Diff == nil {
+		return 0
+	}
+	cells := 0
+	for _, sheetDiff := range c.Diff.SheetDiffs {
+		cells += len(sheetDiff.Changes)
+	}
+	return cells
+}
+
+// RenderCommitMessage fills a commit template for a set of changes.
+// Supported placeholders are {action}, {filename}, {timestamp},
+// {sheets_changed} and {cells_changed}. Counts are totals across all
+// changes; {action} becomes "update" when the changes are of mixed kinds.
+func RenderCommitMessage(template string, changes []CommitChange, when time.Time) string {
+	if template == "" {
+		template = constants.DefaultCommitTemplate
+	}
+
+	sheets, cells := 0, 0
+	for _, change := range changes {
+		sheets += change.SheetsChanged()
+		cells += change.CellsChanged()
+	}
+
+	replacer := strings.NewReplacer(
+		"{action}", commitAction(changes),
+		"{filename}", commitFilenames(changes),
+		"{timestamp}", when.Format("2006-01-02 15:04:05"),
+		"{sheets_changed}", fmt.Sprintf("%d", sheets),
+		"{cells_changed}", fmt.Sprintf("%d", cells),
+	)
+	return replacer.Replace(template)
+}
+
+// CommitBody summarizes each change for the body of a commit message
+func CommitBody(changes []CommitChange) string {
+	var body strings.Builder
+
+	for _, change := range changes {
+		if change.OldPath != "" {
+			body.WriteString(fmt.Sprintf("%s (%s from %s)", change.Path, change.Action, change.OldPath))
+		} else {
+			body.WriteString(fmt.Sprintf("%s (%s)", change.Path, change.Action))
+		}
+		if change.Diff == nil {
+			body.WriteString("\n")
+			continue
+		}
+		body.WriteString(fmt.Sprintf(": %d sheet(s), %d cell(s) changed\n", change.SheetsChanged(), change.CellsChanged()))
+
+		for _, sheetDiff := range change.Diff.SheetDiffs {
+			counts := make(map[models.ChangeType]int)
+			for _, cellChange := range sheetDiff.Changes {
+				counts[cellChange.Type]++
+			}
+
+			line := fmt.Sprintf("  - %s: %d added, %d modified, %d deleted",
+				sheetDiff.SheetName, counts[models.ChangeTypeAdd], counts[models.ChangeTypeModify], counts[models.ChangeTypeDelete])
+			switch sheetDiff.Action {
+			case models.ChangeTypeAdd:
+				line += " (new sheet)"
+			case models.ChangeTypeDelete:
+				line += " (sheet removed)"
+			}
+			body.WriteString(line + "\n")
+		}
+	}
+
+	return strings.TrimRight(body.String(), "\n")
+}
+
+// commitAction returns the shared action of all changes, or "update"
+func commitAction(changes []CommitChange) string {
+	if len(changes) == 0 {
+		return "update"
+	}
+	action := changes[0].Action
+	for _, change := range changes[1:] {
+		if change.Action != action {
+			return "update"
+		}
+	}
+	return action
+}
+
+// commitFilenames lists the changed workbooks by name, or counts them when
+// there are too many to list
+func commitFilenames(changes []CommitChange) string {
+	if len(changes) > maxListedFiles {
+		return fmt.Sprintf("%d files", len(changes))
+	}
+	names := make([]string, len(changes))
+	for i, change := range changes {
+		names[i] = filepath.Base(change.Path)
+		if change.OldPath != "" {
+			names[i] = change.OldPath + " -> " + change.Path
+		}
+	}
+	return strings.Join(names, ", ")
+}
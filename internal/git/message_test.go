@@ -0,0 +1,85 @@
+package git
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func createCommitChange(path, action string, cells ...string) CommitChange {
+	sheetDiff := models.SheetDiff{SheetName: "Sheet1"}
+	for _, cell := range cells {
+		sheetDiff.Changes = append(sheetDiff.Changes, models.CellChange{Cell: cell, Type: models.ChangeTypeModify})
+	}
+	return CommitChange{
+		Path:   path,
+		Action: action,
+		Files:  []string{path + ".json"},
+		Diff:   &models.ExcelDiff{SheetDiffs: []models.SheetDiff{sheetDiff}},
+	}
+}
+
+func TestRenderCommitMessage(t *testing.T) {
+	when := time.Date(2024, 5, 31, 17, 30, 0, 0, time.UTC)
+	template := "{action} {filename} ({sheets_changed} sheets, {cells_changed} cells) at {timestamp}"
+
+	t.Run("single change", func(t *testing.T) {
+		changes := []CommitChange{createCommitChange("reports/Budget.xlsx", "modify", "A1", "B2")}
+
+		message := RenderCommitMessage(template, changes, when)
+		assert.Equal(t, "modify Budget.xlsx (1 sheets, 2 cells) at 2024-05-31 17:30:00", message)
+	})
+
+	t.Run("batch of mixed actions", func(t *testing.T) {
+		changes := []CommitChange{
+			createCommitChange("a.xlsx", "modify", "A1"),
+			createCommitChange("b.xlsx", "create", "A1", "A2"),
+		}
+
+		message := RenderCommitMessage(template, changes, when)
+		assert.Equal(t, "update a.xlsx, b.xlsx (2 sheets, 3 cells) at 2024-05-31 17:30:00", message)
+	})
+
+	t.Run("many files are counted", func(t *testing.T) {
+		var changes []CommitChange
+		for _, name := range []string{"a.xlsx", "b.xlsx", "c.xlsx", "d.xlsx"} {
+			changes = append(changes, createCommitChange(name, "modify"))
+		}
+
+		assert.Equal(t, "modify 4 files", RenderCommitMessage("{action} {filename}", changes, when))
+	})
+
+	t.Run("renames show both paths", func(t *testing.T) {
+		change := createCommitChange("reports/Budget 2025.xlsx", "rename")
+		change.OldPath = "reports/Budget.xlsx"
+
+		assert.Equal(t, "rename reports/Budget.xlsx -> reports/Budget 2025.xlsx", RenderCommitMessage("{action} {filename}", []CommitChange{change}, when))
+		assert.Equal(t, "reports/Budget 2025.xlsx (rename from reports/Budget.xlsx): 1 sheet(s), 0 cell(s) changed\n  - Sheet1: 0 added, 0 modified, 0 deleted", CommitBody([]CommitChange{change}))
+	})
+
+	t.Run("empty template uses the default", func(t *testing.T) {
+		changes := []CommitChange{createCommitChange("a.xlsx", "create")}
+
+		message := RenderCommitMessage("", changes, when)
+		assert.Equal(t, "GitCells: create a.xlsx at 2024-05-31 17:30:00", message)
+	})
+}
+
+func TestCommitBody(t *testing.T) {
+	change := createCommitChange("Budget.xlsx", "modify", "A1", "B2")
+	change.Diff.SheetDiffs = append(change.Diff.SheetDiffs, models.SheetDiff{
+		SheetName: "Notes",
+		Action:    models.ChangeTypeAdd,
+		Changes:   []models.CellChange{{Cell: "A1", Type: models.ChangeTypeAdd}},
+	})
+
+	body := CommitBody([]CommitChange{change, {Path: "Old.xlsx", Action: "delete"}})
+
+	expected := "Budget.xlsx (modify): 2 sheet(s), 3 cell(s) changed\n" +
+		"  - Sheet1: 0 added, 2 modified, 0 deleted\n" +
+		"  - Notes: 1 added, 0 modified, 0 deleted (new sheet)\n" +
+		"Old.xlsx (delete)"
+	assert.Equal(t, expected, body)
+}
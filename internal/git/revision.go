@@ -0,0 +1,175 @@
+package git
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// revisionDateLayouts are the date formats accepted in place of a revision
+var revisionDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Root returns the root directory of the repository worktree
+func (c *Client) Root() string {
+	if c == nil {
+		return ""
+	}
+	return c.worktree.Filesystem.Root()
+}
+
+// ResolveCommit resolves a revision such as a branch, tag, commit hash or
+// an expression like HEAD~2 to its commit
+func (c *Client) ResolveCommit(rev string) (*object.Commit, error) {
+	if c == nil {
+		return nil, utils.NewError(utils.ErrorTypeGit, "resolveCommit", "not a git repository")
+	}
+
+	hash, err := c.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "resolveCommit", "unknown revision "+rev)
+	}
+
+	commit, err := c.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "resolveCommit", "failed to load commit "+hash.String())
+	}
+	return commit, nil
+}
+
+// ResolveRevision resolves rev like ResolveCommit and, if that fails and rev
+// is a date, returns the last commit on HEAD made at or before that date.
+// A date without a time of day covers the whole day.
+func (c *Client) ResolveRevision(rev string) (*object.Commit, error) {
+	commit, err := c.ResolveCommit(rev)
+	if err == nil || c == nil {
+		return commit, err
+	}
+
+	at, ok := parseRevisionDate(rev)
+	if !ok {
+		return nil, err
+	}
+	return c.CommitAt(at)
+}
+
+// CommitAt returns the most recent commit reachable from HEAD whose commit
+// time is not after t
+func (c *Client) CommitAt(t time.Time) (*object.Commit, error) {
+	if c == nil {
+		return nil, utils.NewError(utils.ErrorTypeGit, "commitAt", "not a git repository")
+	}
+
+	iter, err := c.repo.Log(&git.LogOptions{Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "commitAt", "failed to read commit history")
+	}
+	defer iter.Close()
+
+	var found *object.Commit
+	err = iter.ForEach(func(commit *object.Commit) error {
+		if !commit.Committer.When.After(t) {
+			found = commit
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "commitAt", "failed to read commit history")
+	}
+	if found == nil {
+		return nil, utils.NewError(utils.ErrorTypeGit, "commitAt", "no commit at or before "+t.Format(time.RFC3339))
+	}
+	return found, nil
+}
+
+// parseRevisionDate parses a date given in place of a revision. Dates
+// without a time of day resolve to the end of that day in local time.
+func parseRevisionDate(value string) (time.Time, bool) {
+	for _, layout := range revisionDateLayouts {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+		if layout == "2006-01-02" {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// ExportTree writes every file below dir (a slash-separated path relative to
+// the repository root) as it existed at rev into destRoot, preserving the
+// repository-relative layout. It returns the number of files written; a
+// directory that does not exist at rev yields zero files and no error.
+func (c *Client) ExportTree(rev, dir, destRoot string) (int, error) {
+	commit, err := c.ResolveCommit(rev)
+	if err != nil {
+		return 0, err
+	}
+	return exportCommitTree(commit, dir, destRoot)
+}
+
+// exportCommitTree writes the files below dir in commit into destRoot
+func exportCommitTree(commit *object.Commit, dir, destRoot string) (int, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return 0, utils.WrapError(err, utils.ErrorTypeGit, "exportTree", "failed to read commit tree")
+	}
+
+	dir = path.Clean(filepath.ToSlash(dir))
+	if dir != "." {
+		tree, err = tree.Tree(dir)
+		if errors.Is(err, object.ErrDirectoryNotFound) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, utils.WrapError(err, utils.ErrorTypeGit, "exportTree", "failed to read tree "+dir)
+		}
+	}
+
+	count := 0
+	err = tree.Files().ForEach(func(f *object.File) error {
+		destPath := filepath.Join(destRoot, filepath.FromSlash(path.Join(dir, f.Name)))
+		if err := os.MkdirAll(filepath.Dir(destPath), constants.SecureDirPermissions); err != nil {
+			return err
+		}
+
+		reader, err := f.Reader()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		out, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, constants.SecureFilePermissions)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, reader); err != nil {
+			out.Close()
+			return err
+		}
+		count++
+		return out.Close()
+	})
+	if err != nil {
+		return count, utils.WrapFileError(err, utils.ErrorTypeGit, "exportTree", destRoot, "failed to export files")
+	}
+
+	return count, nil
+}
@@ -0,0 +1,152 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ResolveCommit(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	t.Run("nil client returns error", func(t *testing.T) {
+		var client *Client
+		_, err := client.ResolveCommit("HEAD")
+		assert.Error(t, err)
+	})
+
+	t.Run("resolves HEAD and relative revisions", func(t *testing.T) {
+		tempDir := t.TempDir()
+		_, err := git.PlainInit(tempDir, false)
+		require.NoError(t, err)
+
+		client, err := NewClient(tempDir, &Config{UserName: "Test", UserEmail: "test@example.com"}, logger)
+		require.NoError(t, err)
+
+		file := filepath.Join(tempDir, "a.json")
+		require.NoError(t, os.WriteFile(file, []byte("1"), 0600))
+		require.NoError(t, client.AutoCommit([]string{file}, "first"))
+		require.NoError(t, os.WriteFile(file, []byte("2"), 0600))
+		require.NoError(t, client.AutoCommit([]string{file}, "second"))
+
+		head, err := client.ResolveCommit("HEAD")
+		require.NoError(t, err)
+		assert.Equal(t, "second", head.Message)
+
+		parent, err := client.ResolveCommit("HEAD~1")
+		require.NoError(t, err)
+		assert.Equal(t, "first", parent.Message)
+
+		_, err = client.ResolveCommit("does-not-exist")
+		assert.Error(t, err)
+	})
+}
+
+func TestClient_ExportTree(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	tempDir := t.TempDir()
+	_, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+
+	client, err := NewClient(tempDir, &Config{UserName: "Test", UserEmail: "test@example.com"}, logger)
+	require.NoError(t, err)
+	assert.Equal(t, tempDir, client.Root())
+
+	chunkDir := filepath.Join(tempDir, ".gitcells", "data", "reports", "budget_chunks")
+	require.NoError(t, os.MkdirAll(chunkDir, 0750))
+	workbook := filepath.Join(chunkDir, "workbook.json")
+	other := filepath.Join(tempDir, "README.md")
+	require.NoError(t, os.WriteFile(workbook, []byte(`{"version":"1"}`), 0600))
+	require.NoError(t, os.WriteFile(other, []byte("readme"), 0600))
+	require.NoError(t, client.AutoCommit([]string{workbook, other}, "initial"))
+
+	require.NoError(t, os.WriteFile(workbook, []byte(`{"version":"2"}`), 0600))
+	require.NoError(t, client.AutoCommit([]string{workbook}, "update"))
+
+	t.Run("exports directory at older revision", func(t *testing.T) {
+		dest := t.TempDir()
+		count, err := client.ExportTree("HEAD~1", ".gitcells/data", dest)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		data, err := os.ReadFile(filepath.Join(dest, ".gitcells", "data", "reports", "budget_chunks", "workbook.json"))
+		require.NoError(t, err)
+		assert.Equal(t, `{"version":"1"}`, string(data))
+
+		_, err = os.Stat(filepath.Join(dest, "README.md"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("missing directory yields no files", func(t *testing.T) {
+		count, err := client.ExportTree("HEAD", "does/not/exist", t.TempDir())
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
+
+func TestClient_ResolveRevision(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commitAt := func(content, message string, when time.Time) {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "a.json"), []byte(content), 0600))
+		_, err := worktree.Add("a.json")
+		require.NoError(t, err)
+		_, err = worktree.Commit(message, &git.CommitOptions{
+			Author: &object.Signature{Name: "Test", Email: "test@example.com", When: when},
+		})
+		require.NoError(t, err)
+	}
+
+	commitAt("1", "march", time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local))
+	commitAt("2", "april", time.Date(2024, 4, 15, 9, 30, 0, 0, time.Local))
+	commitAt("3", "may", time.Date(2024, 5, 1, 8, 0, 0, 0, time.Local))
+
+	client, err := NewClient(tempDir, &Config{}, logger)
+	require.NoError(t, err)
+
+	tests := []struct {
+		rev     string
+		message string
+	}{
+		{"HEAD", "may"},
+		{"HEAD~2", "march"},
+		{"2024-04-15", "april"},
+		{"2024-04-30", "april"},
+		{"2024-04-15 09:00", "march"},
+		{"2024-05-01T08:00:00" + time.Date(2024, 5, 1, 8, 0, 0, 0, time.Local).Format("Z07:00"), "may"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rev, func(t *testing.T) {
+			commit, err := client.ResolveRevision(tt.rev)
+			require.NoError(t, err)
+			assert.Equal(t, tt.message, commit.Message)
+		})
+	}
+
+	t.Run("date before history", func(t *testing.T) {
+		_, err := client.ResolveRevision("2020-01-01")
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown revision", func(t *testing.T) {
+		_, err := client.ResolveRevision("not-a-branch")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,398 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// SessionBranchPrefix starts the name of every session branch
+const SessionBranchPrefix = "gitcells/"
+
+// Session finish strategies
+const (
+	// SessionSquash adds the whole session to the target as one commit
+	SessionSquash = "squash"
+	// SessionMerge adds the session to the target with a merge commit,
+	// keeping the session commits in history
+	SessionMerge = "merge"
+)
+
+// sessionConfigSection holds the target branch of each session in the
+// repository's git config
+const sessionConfigSection = "gitcells-session"
+
+// Session is a branch that collects GitCells commits until it is finished
+// into its target branch
+type Session struct {
+	Branch string
+	Target string
+	// Commits is the number of commits on the session not yet on the target
+	Commits int
+}
+
+// SessionBranchName returns the session branch for a user on a day
+func SessionBranchName(userName string, day time.Time) string {
+	return SessionBranchPrefix + sanitizeRefComponent(userName) + "/" + day.Format("2006-01-02")
+}
+
+// SessionUser returns the name of the person running GitCells, for session
+// branch names
+func SessionUser() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "gitcells"
+}
+
+// sanitizeRefComponent makes a string safe to use as part of a branch name
+func sanitizeRefComponent(s string) string {
+	// Domain accounts look like DOMAIN\user
+	if i := strings.LastIndexAny(s, `\/`); i >= 0 {
+		s = s[i+1:]
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteRune('-')
+		}
+	}
+	if name := strings.Trim(b.String(), "-"); name != "" {
+		return name
+	}
+	return "gitcells"
+}
+
+// CurrentBranch returns the short name of the checked out branch
+func (c *Client) CurrentBranch() (string, error) {
+	if c == nil {
+		return "", utils.NewError(utils.ErrorTypeGit, "currentBranch", "not a git repository")
+	}
+
+	head, err := c.repo.Head()
+	if err != nil {
+		return "", utils.WrapError(err, utils.ErrorTypeGit, "currentBranch", "failed to read HEAD")
+	}
+	if !head.Name().IsBranch() {
+		return "", utils.NewError(utils.ErrorTypeGit, "currentBranch", "HEAD is detached; check out a branch first")
+	}
+	return head.Name().Short(), nil
+}
+
+// StartSession checks out the session branch for userName. A session
+// already checked out is continued. Otherwise the branch is created from
+// the current branch, which becomes its target, or checked out if it
+// already exists. A branch kept by an earlier finished session is left
+// alone and the new session gets a numbered branch beside it.
+func (c *Client) StartSession(userName string) (*Session, error) {
+	current, err := c.CurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+	if session, err := c.SessionInfo(current); err == nil {
+		return session, nil
+	}
+
+	name := SessionBranchName(userName, time.Now())
+	branch := name
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	for n := 2; ; n++ {
+		_, err = c.repo.Reference(branchRef, false)
+		if err != nil {
+			break
+		}
+		if _, infoErr := c.SessionInfo(branch); infoErr == nil {
+			break
+		}
+		branch = fmt.Sprintf("%s-%d", name, n)
+		branchRef = plumbing.NewBranchReferenceName(branch)
+	}
+
+	switch {
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+		// The new branch points at HEAD, so the working tree can stay as is
+		err = c.worktree.Checkout(&git.CheckoutOptions{Branch: branchRef, Create: true, Keep: true})
+		if err != nil {
+			return nil, utils.WrapError(err, utils.ErrorTypeGit, "startSession", "failed to create session branch "+branch)
+		}
+		if err := c.setSessionTarget(branch, current); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "startSession", "failed to look up session branch "+branch)
+	default:
+		if err := c.worktree.Checkout(&git.CheckoutOptions{Branch: branchRef}); err != nil {
+			return nil, utils.WrapError(err, utils.ErrorTypeGit, "startSession",
+				"failed to check out session branch "+branch+"; commit or stash your changes first")
+		}
+	}
+
+	return c.SessionInfo(branch)
+}
+
+// SessionInfo describes a session branch. It fails if branch is not a
+// session started by GitCells.
+func (c *Client) SessionInfo(branch string) (*Session, error) {
+	if c == nil {
+		return nil, utils.NewError(utils.ErrorTypeGit, "sessionInfo", "not a git repository")
+	}
+
+	cfg, err := c.repo.Config()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "sessionInfo", "failed to read git config")
+	}
+	target := cfg.Raw.Section(sessionConfigSection).Subsection(branch).Option("target")
+	if target == "" {
+		return nil, utils.NewError(utils.ErrorTypeGit, "sessionInfo", branch+" is not a GitCells session branch")
+	}
+
+	session := &Session{Branch: branch, Target: target}
+	commits, err := c.sessionCommits(branch, target)
+	if err != nil {
+		return nil, err
+	}
+	session.Commits = len(commits)
+	return session, nil
+}
+
+// FinishSession adds a session to its target branch using strategy,
+// checks out the target and ends the session. The session branch is
+// deleted unless keepBranch is set. It returns the new commit on the target, or the zero hash if the
+// session had no commits. The target must not have moved on since the
+// session started, as that would need a three-way merge.
+func (c *Client) FinishSession(branch, strategy string, keepBranch bool) (plumbing.Hash, error) {
+	session, err := c.SessionInfo(branch)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if strategy != SessionSquash && strategy != SessionMerge {
+		return plumbing.ZeroHash, utils.NewError(utils.ErrorTypeValidation, "finishSession",
+			"unknown strategy "+strategy+" (expected squash or merge)")
+	}
+
+	if err := c.checkNoTrackedChanges(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	targetRef := plumbing.NewBranchReferenceName(session.Target)
+	target, err := c.ResolveCommit(targetRef.String())
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	tip, err := c.ResolveCommit(plumbing.NewBranchReferenceName(branch).String())
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	newHash := plumbing.ZeroHash
+	if session.Commits > 0 {
+		if isAncestor, err := target.IsAncestor(tip); err != nil || !isAncestor {
+			return plumbing.ZeroHash, utils.NewError(utils.ErrorTypeGit, "finishSession",
+				session.Target+" has commits that are not in "+branch+"; merge "+session.Target+" into the session with git first")
+		}
+
+		message, err := c.SessionSummary(branch)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		parents := []plumbing.Hash{target.Hash}
+		if strategy == SessionMerge {
+			parents = append(parents, tip.Hash)
+		}
+		newHash, err = c.writeCommit(tip.TreeHash, parents, message)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if err := c.repo.Storer.SetReference(plumbing.NewHashReference(targetRef, newHash)); err != nil {
+			return plumbing.ZeroHash, utils.WrapError(err, utils.ErrorTypeGit, "finishSession", "failed to update "+session.Target)
+		}
+	}
+
+	// The target now has the session's tree, so checking it out changes no files
+	if err := c.worktree.Checkout(&git.CheckoutOptions{Branch: targetRef}); err != nil {
+		return newHash, utils.WrapError(err, utils.ErrorTypeGit, "finishSession", "failed to check out "+session.Target)
+	}
+
+	if err := c.endSession(branch, !keepBranch); err != nil {
+		return newHash, err
+	}
+	return newHash, nil
+}
+
+// SessionSummary returns the message FinishSession commits with: the
+// session, the workbooks it changed and the subjects of its commits
+func (c *Client) SessionSummary(branch string) (string, error) {
+	session, err := c.SessionInfo(branch)
+	if err != nil {
+		return "", err
+	}
+	commits, err := c.sessionCommits(branch, session.Target)
+	if err != nil {
+		return "", err
+	}
+	workbooks, err := c.sessionWorkbooks(branch, session.Target)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "GitCells session %s: %d commit(s), %d workbook(s) changed\n", branch, len(commits), len(workbooks))
+	if len(workbooks) > 0 {
+		b.WriteString("\nWorkbooks:\n")
+		for _, workbook := range workbooks {
+			fmt.Fprintf(&b, "- %s\n", workbook)
+		}
+	}
+	if len(commits) > 0 {
+		b.WriteString("\nCommits:\n")
+		// Oldest first
+		for i := len(commits) - 1; i >= 0; i-- {
+			subject, _, _ := strings.Cut(commits[i].Message, "\n")
+			fmt.Fprintf(&b, "- %s\n", subject)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// sessionCommits returns the commits on branch that are not on target,
+// newest first
+func (c *Client) sessionCommits(branch, target string) ([]*object.Commit, error) {
+	tip, err := c.ResolveCommit(plumbing.NewBranchReferenceName(branch).String())
+	if err != nil {
+		return nil, err
+	}
+	base, err := c.ResolveCommit(plumbing.NewBranchReferenceName(target).String())
+	if err != nil {
+		return nil, err
+	}
+	return commitsBetween(base, tip)
+}
+
+// sessionWorkbooks returns the workbooks whose files or chunks differ
+// between target and branch
+func (c *Client) sessionWorkbooks(branch, target string) ([]string, error) {
+	tip, err := c.ResolveCommit(plumbing.NewBranchReferenceName(branch).String())
+	if err != nil {
+		return nil, err
+	}
+	base, err := c.ResolveCommit(plumbing.NewBranchReferenceName(target).String())
+	if err != nil {
+		return nil, err
+	}
+
+	tipTree, err := tip.Tree()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "sessionWorkbooks", "failed to read tree of "+branch)
+	}
+	baseTree, err := base.Tree()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "sessionWorkbooks", "failed to read tree of "+target)
+	}
+	changes, err := object.DiffTree(baseTree, tipTree)
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "sessionWorkbooks", "failed to compare "+branch+" with "+target)
+	}
+
+	return c.changedWorkbooks(changes, true), nil
+}
+
+// writeCommit stores a commit by the configured author, signed if signing
+// is configured, and returns its hash
+func (c *Client) writeCommit(tree plumbing.Hash, parents []plumbing.Hash, message string) (plumbing.Hash, error) {
+	signature := object.Signature{Name: c.config.UserName, Email: c.config.UserEmail, When: time.Now()}
+	commit := &object.Commit{
+		Author:       signature,
+		Committer:    signature,
+		Message:      message,
+		TreeHash:     tree,
+		ParentHashes: parents,
+	}
+
+	sig, err := c.signObject(commit.EncodeWithoutSignature)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	commit.PGPSignature = sig
+
+	obj := c.repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return plumbing.ZeroHash, utils.WrapError(err, utils.ErrorTypeGit, "writeCommit", "failed to encode commit")
+	}
+	hash, err := c.repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, utils.WrapError(err, utils.ErrorTypeGit, "writeCommit", "failed to store commit")
+	}
+	return hash, nil
+}
+
+// checkNoTrackedChanges fails if tracked files have staged or unstaged
+// changes. Untracked files, such as ignored workbooks, are allowed.
+func (c *Client) checkNoTrackedChanges() error {
+	status, err := c.Status()
+	if err != nil {
+		return err
+	}
+	for path, fileStatus := range status {
+		if fileStatus.Worktree == git.Untracked {
+			continue
+		}
+		if fileStatus.Staging != git.Unmodified || fileStatus.Worktree != git.Unmodified {
+			return utils.NewError(utils.ErrorTypeGit, "finishSession",
+				path+" has uncommitted changes; commit or stash them first")
+		}
+	}
+	return nil
+}
+
+// setSessionTarget records the branch a session finishes into
+func (c *Client) setSessionTarget(branch, target string) error {
+	cfg, err := c.repo.Config()
+	if err != nil {
+		return utils.WrapError(err, utils.ErrorTypeGit, "startSession", "failed to read git config")
+	}
+	cfg.Raw.Section(sessionConfigSection).Subsection(branch).SetOption("target", target)
+	if err := c.repo.SetConfig(cfg); err != nil {
+		return utils.WrapError(err, utils.ErrorTypeGit, "startSession", "failed to write git config")
+	}
+	return nil
+}
+
+// endSession forgets a session's target so the branch is no longer
+// continued, and deletes the branch if deleteBranch is set
+func (c *Client) endSession(branch string, deleteBranch bool) error {
+	if deleteBranch {
+		if err := c.repo.Storer.RemoveReference(plumbing.NewBranchReferenceName(branch)); err != nil {
+			return utils.WrapError(err, utils.ErrorTypeGit, "finishSession", "failed to delete "+branch)
+		}
+	}
+
+	cfg, err := c.repo.Config()
+	if err != nil {
+		return utils.WrapError(err, utils.ErrorTypeGit, "finishSession", "failed to read git config")
+	}
+	section := cfg.Raw.Section(sessionConfigSection)
+	section.RemoveSubsection(branch)
+	if len(section.Subsections) == 0 && len(section.Options) == 0 {
+		cfg.Raw.RemoveSection(sessionConfigSection)
+	}
+	if err := c.repo.SetConfig(cfg); err != nil {
+		return utils.WrapError(err, utils.ErrorTypeGit, "finishSession", "failed to write git config")
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionBranchName(t *testing.T) {
+	day := time.Date(2026, 3, 9, 15, 0, 0, 0, time.UTC)
+	assert.Equal(t, "gitcells/alice/2026-03-09", SessionBranchName("alice", day))
+	assert.Equal(t, "gitcells/jane-doe/2026-03-09", SessionBranchName(`CORP\Jane Doe`, day))
+	assert.Equal(t, "gitcells/gitcells/2026-03-09", SessionBranchName("..", day))
+}
+
+func TestClient_Session(t *testing.T) {
+	logger := logrus.New()
+
+	// setup returns a repository with one commit on its default branch
+	setup := func(t *testing.T) (*Client, *git.Repository, string) {
+		tempDir := t.TempDir()
+		repo, err := git.PlainInit(tempDir, false)
+		require.NoError(t, err)
+
+		client, err := NewClient(tempDir, &Config{UserName: "GitCells", UserEmail: "gitcells@localhost"}, logger)
+		require.NoError(t, err)
+
+		readme := filepath.Join(tempDir, "README.md")
+		require.NoError(t, os.WriteFile(readme, []byte("budget"), 0600))
+		require.NoError(t, client.AutoCommit([]string{readme}, "Initial commit"))
+
+		target, err := client.CurrentBranch()
+		require.NoError(t, err)
+		return client, repo, target
+	}
+
+	// commitChunk commits a change to the chunks of a workbook
+	commitChunk := func(t *testing.T, client *Client, workbook, content string) {
+		chunk := filepath.Join(client.Root(), ".gitcells", "data", workbook+"_chunks", "workbook.json")
+		require.NoError(t, os.MkdirAll(filepath.Dir(chunk), 0755))
+		require.NoError(t, os.WriteFile(chunk, []byte(content), 0600))
+		require.NoError(t, client.AutoCommit([]string{chunk}, "GitCells: modify "+workbook))
+	}
+
+	branchHash := func(t *testing.T, repo *git.Repository, branch string) plumbing.Hash {
+		ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+		require.NoError(t, err)
+		return ref.Hash()
+	}
+
+	t.Run("squashes a session into its target", func(t *testing.T) {
+		client, repo, target := setup(t)
+		base := branchHash(t, repo, target)
+
+		session, err := client.StartSession("alice")
+		require.NoError(t, err)
+		assert.Equal(t, target, session.Target)
+		assert.Equal(t, SessionBranchName("alice", time.Now()), session.Branch)
+		assert.Zero(t, session.Commits)
+
+		current, err := client.CurrentBranch()
+		require.NoError(t, err)
+		assert.Equal(t, session.Branch, current)
+
+		commitChunk(t, client, "Budget.xlsx", `{"v":1}`)
+		commitChunk(t, client, "reports/Q1.xlsx", `{"v":1}`)
+		commitChunk(t, client, "Budget.xlsx", `{"v":2}`)
+
+		// Starting again continues the checked out session
+		continued, err := client.StartSession("alice")
+		require.NoError(t, err)
+		assert.Equal(t, 3, continued.Commits)
+
+		tip := branchHash(t, repo, session.Branch)
+		hash, err := client.FinishSession(session.Branch, SessionSquash, false)
+		require.NoError(t, err)
+
+		commit, err := repo.CommitObject(hash)
+		require.NoError(t, err)
+		assert.Equal(t, []plumbing.Hash{base}, commit.ParentHashes)
+		tipCommit, err := repo.CommitObject(tip)
+		require.NoError(t, err)
+		assert.Equal(t, tipCommit.TreeHash, commit.TreeHash)
+
+		assert.Contains(t, commit.Message, "GitCells session "+session.Branch+": 3 commit(s), 2 workbook(s) changed")
+		assert.Contains(t, commit.Message, "- Budget.xlsx\n- reports/Q1.xlsx")
+		assert.Contains(t, commit.Message, "Commits:\n- GitCells: modify Budget.xlsx\n- GitCells: modify reports/Q1.xlsx")
+
+		assert.Equal(t, hash, branchHash(t, repo, target))
+		current, err = client.CurrentBranch()
+		require.NoError(t, err)
+		assert.Equal(t, target, current)
+
+		_, err = repo.Reference(plumbing.NewBranchReferenceName(session.Branch), false)
+		assert.ErrorIs(t, err, plumbing.ErrReferenceNotFound)
+		_, err = client.SessionInfo(session.Branch)
+		assert.Error(t, err)
+	})
+
+	t.Run("merges a session and keeps the branch", func(t *testing.T) {
+		client, repo, target := setup(t)
+		base := branchHash(t, repo, target)
+
+		session, err := client.StartSession("alice")
+		require.NoError(t, err)
+		commitChunk(t, client, "Budget.xlsx", `{"v":1}`)
+		tip := branchHash(t, repo, session.Branch)
+
+		hash, err := client.FinishSession(session.Branch, SessionMerge, true)
+		require.NoError(t, err)
+
+		commit, err := repo.CommitObject(hash)
+		require.NoError(t, err)
+		assert.Equal(t, []plumbing.Hash{base, tip}, commit.ParentHashes)
+
+		assert.Equal(t, tip, branchHash(t, repo, session.Branch))
+		_, err = client.SessionInfo(session.Branch)
+		assert.Error(t, err, "a finished session is not continued")
+	})
+
+	t.Run("starts a new branch beside a kept finished session", func(t *testing.T) {
+		client, repo, target := setup(t)
+
+		first, err := client.StartSession("alice")
+		require.NoError(t, err)
+		commitChunk(t, client, "Budget.xlsx", `{"v":1}`)
+		_, err = client.FinishSession(first.Branch, SessionMerge, true)
+		require.NoError(t, err)
+		kept := branchHash(t, repo, first.Branch)
+
+		second, err := client.StartSession("alice")
+		require.NoError(t, err)
+		assert.Equal(t, first.Branch+"-2", second.Branch)
+		assert.Equal(t, target, second.Target)
+		assert.Equal(t, 0, second.Commits)
+		assert.Equal(t, kept, branchHash(t, repo, first.Branch))
+
+		current, err := client.CurrentBranch()
+		require.NoError(t, err)
+		assert.Equal(t, second.Branch, current)
+
+		// Starting again continues the new session
+		_, err = client.FinishSession(second.Branch, SessionMerge, true)
+		require.NoError(t, err)
+		third, err := client.StartSession("alice")
+		require.NoError(t, err)
+		assert.Equal(t, first.Branch+"-3", third.Branch)
+		again, err := client.StartSession("alice")
+		require.NoError(t, err)
+		assert.Equal(t, third.Branch, again.Branch)
+	})
+
+	t.Run("refuses a target that has moved on", func(t *testing.T) {
+		client, _, target := setup(t)
+
+		session, err := client.StartSession("alice")
+		require.NoError(t, err)
+		commitChunk(t, client, "Budget.xlsx", `{"v":1}`)
+
+		require.NoError(t, client.worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(target)}))
+		commitChunk(t, client, "Other.xlsx", `{"v":1}`)
+
+		_, err = client.FinishSession(session.Branch, SessionSquash, false)
+		assert.Error(t, err)
+	})
+
+	t.Run("refuses uncommitted changes", func(t *testing.T) {
+		client, _, _ := setup(t)
+
+		session, err := client.StartSession("alice")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(client.Root(), "README.md"), []byte("changed"), 0600))
+
+		_, err = client.FinishSession(session.Branch, SessionSquash, false)
+		assert.Error(t, err)
+	})
+
+	t.Run("finishing an empty session only checks out the target", func(t *testing.T) {
+		client, repo, target := setup(t)
+		base := branchHash(t, repo, target)
+
+		session, err := client.StartSession("alice")
+		require.NoError(t, err)
+
+		hash, err := client.FinishSession(session.Branch, SessionSquash, false)
+		require.NoError(t, err)
+		assert.True(t, hash.IsZero())
+		assert.Equal(t, base, branchHash(t, repo, target))
+	})
+}
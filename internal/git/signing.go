@@ -0,0 +1,317 @@
+package git
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// Signing formats
+const (
+	SigningFormatOpenPGP = "openpgp"
+	SigningFormatSSH     = "ssh"
+)
+
+// SigningPassphraseEnv names the environment variable holding the
+// passphrase of an encrypted signing key
+const SigningPassphraseEnv = "GITCELLS_SIGNING_PASSPHRASE"
+
+const (
+	sshSigMagic     = "SSHSIG"
+	sshSigNamespace = "git"
+	sshSigHash      = "sha512"
+)
+
+// SigningConfig configures commit signing. An empty Format leaves commits
+// unsigned.
+type SigningConfig struct {
+	// Format is SigningFormatOpenPGP or SigningFormatSSH
+	Format string
+	// Key is the path to the private key. With UseAgent it selects the
+	// agent key instead, as a public key file or an authorized_keys line.
+	Key string
+	// UseAgent signs with a key held by ssh-agent (SSH only)
+	UseAgent bool
+}
+
+// NewSigner returns the commit signer described by cfg, or nil if signing
+// is disabled
+func NewSigner(cfg SigningConfig) (git.Signer, error) {
+	switch strings.ToLower(cfg.Format) {
+	case "":
+		return nil, nil
+	case SigningFormatOpenPGP, "gpg":
+		if cfg.UseAgent {
+			return nil, utils.NewError(utils.ErrorTypeConfig, "newSigner", "agent signing is only supported for ssh keys")
+		}
+		return newOpenPGPSigner(expandHome(cfg.Key))
+	case SigningFormatSSH:
+		if cfg.UseAgent {
+			return newSSHAgentSigner(expandHome(cfg.Key))
+		}
+		return newSSHKeySigner(expandHome(cfg.Key))
+	default:
+		return nil, utils.NewError(utils.ErrorTypeConfig, "newSigner",
+			"unknown signing format "+cfg.Format+" (expected openpgp or ssh)")
+	}
+}
+
+// expandHome replaces a leading ~/ in a key path with the home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// openPGPSigner signs commits with an OpenPGP key
+type openPGPSigner struct {
+	entity *openpgp.Entity
+}
+
+func newOpenPGPSigner(keyPath string) (*openPGPSigner, error) {
+	if keyPath == "" {
+		return nil, utils.NewError(utils.ErrorTypeConfig, "newSigner", "openpgp signing requires a key path")
+	}
+
+	data, err := os.ReadFile(keyPath) // #nosec G304 - key path from configuration
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeConfig, "newSigner", keyPath, "failed to read signing key")
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeConfig, "newSigner", keyPath, "failed to parse OpenPGP key")
+	}
+
+	for _, entity := range entities {
+		if entity.PrivateKey == nil {
+			continue
+		}
+		if entity.PrivateKey.Encrypted {
+			passphrase := os.Getenv(SigningPassphraseEnv)
+			if passphrase == "" {
+				return nil, utils.NewError(utils.ErrorTypeConfig, "newSigner",
+					"OpenPGP key "+keyPath+" is encrypted; set "+SigningPassphraseEnv)
+			}
+			if err := entity.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+				return nil, utils.WrapFileError(err, utils.ErrorTypeConfig, "newSigner", keyPath, "failed to decrypt OpenPGP key")
+			}
+		}
+		return &openPGPSigner{entity: entity}, nil
+	}
+
+	return nil, utils.NewError(utils.ErrorTypeConfig, "newSigner", keyPath+" contains no OpenPGP private key")
+}
+
+func (s *openPGPSigner) Sign(message io.Reader) ([]byte, error) {
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&signature, s.entity, message, nil); err != nil {
+		return nil, err
+	}
+	return signature.Bytes(), nil
+}
+
+// sshKeySigner signs commits with an SSH private key file
+type sshKeySigner struct {
+	signer ssh.Signer
+}
+
+func newSSHKeySigner(keyPath string) (*sshKeySigner, error) {
+	if keyPath == "" {
+		return nil, utils.NewError(utils.ErrorTypeConfig, "newSigner", "ssh signing requires a key path or use_agent")
+	}
+
+	data, err := os.ReadFile(keyPath) // #nosec G304 - key path from configuration
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeConfig, "newSigner", keyPath, "failed to read signing key")
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		passphrase := os.Getenv(SigningPassphraseEnv)
+		if passphrase == "" {
+			return nil, utils.NewError(utils.ErrorTypeConfig, "newSigner",
+				"SSH key "+keyPath+" is encrypted; set "+SigningPassphraseEnv+" or use_agent")
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	}
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeConfig, "newSigner", keyPath, "failed to parse SSH key")
+	}
+
+	return &sshKeySigner{signer: signer}, nil
+}
+
+func (s *sshKeySigner) Sign(message io.Reader) ([]byte, error) {
+	return signSSH(s.signer, message)
+}
+
+// sshAgentSigner signs commits with a key held by ssh-agent. It connects to
+// the agent for every signature so a long-running watch survives agent
+// restarts.
+type sshAgentSigner struct {
+	publicKey ssh.PublicKey
+}
+
+func newSSHAgentSigner(key string) (*sshAgentSigner, error) {
+	s := &sshAgentSigner{}
+
+	if key != "" {
+		data := []byte(key)
+		if content, err := os.ReadFile(key); err == nil { // #nosec G304 - key path from configuration
+			data = content
+		}
+		publicKey, _, _, _, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, utils.WrapError(err, utils.ErrorTypeConfig, "newSigner", "failed to parse SSH public key "+key)
+		}
+		s.publicKey = publicKey
+	}
+
+	// Fail early if the agent or key is unavailable
+	signer, closeAgent, err := s.agentSigner()
+	if err != nil {
+		return nil, err
+	}
+	closeAgent()
+	s.publicKey = signer.PublicKey()
+
+	return s, nil
+}
+
+func (s *sshAgentSigner) Sign(message io.Reader) ([]byte, error) {
+	signer, closeAgent, err := s.agentSigner()
+	if err != nil {
+		return nil, err
+	}
+	defer closeAgent()
+	return signSSH(signer, message)
+}
+
+// agentSigner connects to ssh-agent and returns the signer for the
+// configured key, or the first key if none is configured
+func (s *sshAgentSigner) agentSigner() (ssh.Signer, func(), error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, nil, utils.NewError(utils.ErrorTypeConfig, "sshAgent", "SSH_AUTH_SOCK is not set; is ssh-agent running?")
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, nil, utils.WrapError(err, utils.ErrorTypeConfig, "sshAgent", "failed to connect to ssh-agent")
+	}
+	closeAgent := func() { conn.Close() }
+
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		closeAgent()
+		return nil, nil, utils.WrapError(err, utils.ErrorTypeConfig, "sshAgent", "failed to list ssh-agent keys")
+	}
+
+	for _, signer := range signers {
+		if s.publicKey == nil || bytes.Equal(signer.PublicKey().Marshal(), s.publicKey.Marshal()) {
+			return signer, closeAgent, nil
+		}
+	}
+
+	closeAgent()
+	if s.publicKey == nil {
+		return nil, nil, utils.NewError(utils.ErrorTypeConfig, "sshAgent", "ssh-agent has no keys")
+	}
+	return nil, nil, utils.NewError(utils.ErrorTypeConfig, "sshAgent", "signing key is not loaded in ssh-agent")
+}
+
+// signSSH creates an armored SSH signature (SSHSIG) in the git namespace, as
+// produced by 'ssh-keygen -Y sign -n git'
+func signSSH(signer ssh.Signer, message io.Reader) ([]byte, error) {
+	hash := sha512.New()
+	if _, err := io.Copy(hash, message); err != nil {
+		return nil, err
+	}
+
+	signedData := struct {
+		Namespace string
+		Reserved  string
+		Hash      string
+		Digest    []byte
+	}{sshSigNamespace, "", sshSigHash, hash.Sum(nil)}
+	data := append([]byte(sshSigMagic), ssh.Marshal(signedData)...)
+
+	var signature *ssh.Signature
+	var err error
+	if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		// ssh-rsa (SHA-1) signatures are rejected by ssh-keygen
+		signature, err = algorithmSigner.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+	} else {
+		signature, err = signer.Sign(rand.Reader, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	blob := struct {
+		Version   uint32
+		PublicKey []byte
+		Namespace string
+		Reserved  string
+		Hash      string
+		Signature []byte
+	}{1, signer.PublicKey().Marshal(), sshSigNamespace, "", sshSigHash, ssh.Marshal(signature)}
+
+	encoded := base64.StdEncoding.EncodeToString(append([]byte(sshSigMagic), ssh.Marshal(blob)...))
+	var armored bytes.Buffer
+	armored.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(encoded) > 70 {
+		armored.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+	armored.WriteString(encoded + "\n-----END SSH SIGNATURE-----\n")
+	return armored.Bytes(), nil
+}
+
+// signObject signs an object encoded without its signature with the
+// configured signer. It returns "" if signing is not configured.
+func (c *Client) signObject(encode func(plumbing.EncodedObject) error) (string, error) {
+	if c.signer == nil {
+		return "", nil
+	}
+
+	unsigned := c.repo.Storer.NewEncodedObject()
+	if err := encode(unsigned); err != nil {
+		return "", utils.WrapError(err, utils.ErrorTypeGit, "signObject", "failed to encode object")
+	}
+	reader, err := unsigned.Reader()
+	if err != nil {
+		return "", utils.WrapError(err, utils.ErrorTypeGit, "signObject", "failed to encode object")
+	}
+	defer reader.Close()
+
+	sig, err := c.signer.Sign(reader)
+	if err != nil {
+		return "", utils.WrapError(err, utils.ErrorTypeGit, "signObject", "failed to sign object")
+	}
+	return string(sig), nil
+}
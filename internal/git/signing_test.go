@@ -0,0 +1,230 @@
+package git
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// writeSSHKey writes a new ed25519 private key and returns its path and
+// public key
+func writeSSHKey(t *testing.T, passphrase string) (string, ssh.PublicKey) {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(privateKey, "test")
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(privateKey, "test", []byte(passphrase))
+	}
+	require.NoError(t, err)
+
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600))
+
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	require.NoError(t, err)
+	return keyPath, sshPublicKey
+}
+
+// verifySSHSignature checks an armored SSHSIG signature of message
+func verifySSHSignature(t *testing.T, armored []byte, message string) ssh.PublicKey {
+	t.Helper()
+
+	text := strings.TrimSpace(string(armored))
+	require.True(t, strings.HasPrefix(text, "-----BEGIN SSH SIGNATURE-----\n"))
+	require.True(t, strings.HasSuffix(text, "\n-----END SSH SIGNATURE-----"))
+	body := strings.TrimSuffix(strings.TrimPrefix(text, "-----BEGIN SSH SIGNATURE-----\n"), "\n-----END SSH SIGNATURE-----")
+
+	raw, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\n", ""))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(raw, []byte(sshSigMagic)))
+
+	var blob struct {
+		Version   uint32
+		PublicKey []byte
+		Namespace string
+		Reserved  string
+		Hash      string
+		Signature []byte
+	}
+	require.NoError(t, ssh.Unmarshal(raw[len(sshSigMagic):], &blob))
+	assert.Equal(t, uint32(1), blob.Version)
+	assert.Equal(t, "git", blob.Namespace)
+	assert.Equal(t, "sha512", blob.Hash)
+
+	publicKey, err := ssh.ParsePublicKey(blob.PublicKey)
+	require.NoError(t, err)
+	var signature ssh.Signature
+	require.NoError(t, ssh.Unmarshal(blob.Signature, &signature))
+
+	digest := sha512.Sum512([]byte(message))
+	signedData := append([]byte(sshSigMagic), ssh.Marshal(struct {
+		Namespace string
+		Reserved  string
+		Hash      string
+		Digest    []byte
+	}{"git", "", "sha512", digest[:]})...)
+	require.NoError(t, publicKey.Verify(signedData, &signature))
+
+	return publicKey
+}
+
+func TestNewSigner(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		signer, err := NewSigner(SigningConfig{})
+		require.NoError(t, err)
+		assert.Nil(t, signer)
+	})
+
+	t.Run("rejects unknown formats and missing keys", func(t *testing.T) {
+		_, err := NewSigner(SigningConfig{Format: "x509"})
+		assert.Error(t, err)
+
+		_, err = NewSigner(SigningConfig{Format: SigningFormatSSH})
+		assert.Error(t, err)
+
+		_, err = NewSigner(SigningConfig{Format: SigningFormatOpenPGP, UseAgent: true})
+		assert.Error(t, err)
+	})
+
+	t.Run("signs with an SSH key file", func(t *testing.T) {
+		keyPath, publicKey := writeSSHKey(t, "")
+
+		signer, err := NewSigner(SigningConfig{Format: SigningFormatSSH, Key: keyPath})
+		require.NoError(t, err)
+
+		signature, err := signer.Sign(strings.NewReader("tree abc\n\nmessage\n"))
+		require.NoError(t, err)
+		signedBy := verifySSHSignature(t, signature, "tree abc\n\nmessage\n")
+		assert.Equal(t, publicKey.Marshal(), signedBy.Marshal())
+	})
+
+	t.Run("decrypts an SSH key with the passphrase variable", func(t *testing.T) {
+		keyPath, _ := writeSSHKey(t, "secret")
+
+		t.Setenv(SigningPassphraseEnv, "")
+		_, err := NewSigner(SigningConfig{Format: SigningFormatSSH, Key: keyPath})
+		assert.Error(t, err)
+
+		t.Setenv(SigningPassphraseEnv, "secret")
+		_, err = NewSigner(SigningConfig{Format: SigningFormatSSH, Key: keyPath})
+		assert.NoError(t, err)
+	})
+
+	t.Run("signs with an ssh-agent key", func(t *testing.T) {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		keyring := agent.NewKeyring()
+		require.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: privateKey}))
+
+		socket := filepath.Join(t.TempDir(), "agent.sock")
+		listener, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					_ = agent.ServeAgent(keyring, conn)
+				}()
+			}
+		}()
+		t.Setenv("SSH_AUTH_SOCK", socket)
+
+		signer, err := NewSigner(SigningConfig{Format: SigningFormatSSH, UseAgent: true})
+		require.NoError(t, err)
+		signature, err := signer.Sign(strings.NewReader("payload"))
+		require.NoError(t, err)
+		verifySSHSignature(t, signature, "payload")
+
+		_, otherKey := writeSSHKey(t, "")
+		_, err = NewSigner(SigningConfig{
+			Format:   SigningFormatSSH,
+			Key:      string(ssh.MarshalAuthorizedKey(otherKey)),
+			UseAgent: true,
+		})
+		assert.Error(t, err, "key not loaded in the agent")
+	})
+}
+
+func TestClient_AutoCommitSigned(t *testing.T) {
+	logger := logrus.New()
+
+	commitFile := func(t *testing.T, signing SigningConfig) *git.Repository {
+		t.Helper()
+		tempDir := t.TempDir()
+		repo, err := git.PlainInit(tempDir, false)
+		require.NoError(t, err)
+
+		client, err := NewClient(tempDir, &Config{UserName: "GitCells", UserEmail: "gitcells@localhost", Signing: signing}, logger)
+		require.NoError(t, err)
+
+		file := filepath.Join(tempDir, "data.json")
+		require.NoError(t, os.WriteFile(file, []byte("{}"), 0600))
+		require.NoError(t, client.AutoCommit([]string{file}, "Signed commit"))
+		return repo
+	}
+
+	t.Run("ssh", func(t *testing.T) {
+		keyPath, _ := writeSSHKey(t, "")
+		repo := commitFile(t, SigningConfig{Format: SigningFormatSSH, Key: keyPath})
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+		commit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+		assert.Contains(t, commit.PGPSignature, "BEGIN SSH SIGNATURE")
+	})
+
+	t.Run("openpgp", func(t *testing.T) {
+		entity, err := openpgp.NewEntity("GitCells", "", "gitcells@localhost", nil)
+		require.NoError(t, err)
+
+		var private, public bytes.Buffer
+		w, err := armor.Encode(&private, openpgp.PrivateKeyType, nil)
+		require.NoError(t, err)
+		require.NoError(t, entity.SerializePrivate(w, nil))
+		require.NoError(t, w.Close())
+		w, err = armor.Encode(&public, openpgp.PublicKeyType, nil)
+		require.NoError(t, err)
+		require.NoError(t, entity.Serialize(w))
+		require.NoError(t, w.Close())
+
+		keyPath := filepath.Join(t.TempDir(), "key.asc")
+		require.NoError(t, os.WriteFile(keyPath, private.Bytes(), 0600))
+
+		repo := commitFile(t, SigningConfig{Format: SigningFormatOpenPGP, Key: keyPath})
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+		commit, err := repo.CommitObject(head.Hash())
+		require.NoError(t, err)
+		_, err = commit.Verify(public.String())
+		assert.NoError(t, err)
+	})
+}
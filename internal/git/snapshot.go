@@ -0,0 +1,393 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// SnapshotTagPrefix starts the tag name of every snapshot
+const SnapshotTagPrefix = "snapshot/"
+
+// snapshotManifestVersion is the version of the manifest format written
+// into snapshot tags
+const snapshotManifestVersion = 1
+
+// SnapshotWorkbook records one workbook in a snapshot
+type SnapshotWorkbook struct {
+	Path string `json:"path"`
+	// Chunks is the repository-relative chunk directory of the workbook
+	Chunks string `json:"chunks"`
+	// SHA256 is a checksum of the chunk files, see chunkChecksum
+	SHA256 string `json:"sha256"`
+	Files  int    `json:"files"`
+}
+
+// SnapshotManifest is the JSON document stored in a snapshot tag message
+type SnapshotManifest struct {
+	Version   int                `json:"version"`
+	Name      string             `json:"name"`
+	Commit    string             `json:"commit"`
+	Created   time.Time          `json:"created"`
+	Note      string             `json:"note,omitempty"`
+	Workbooks []SnapshotWorkbook `json:"workbooks"`
+}
+
+// Snapshot is a named, frozen version of workbooks stored as an annotated tag
+type Snapshot struct {
+	Name     string
+	Tag      string
+	Commit   plumbing.Hash
+	Tagger   object.Signature
+	Manifest SnapshotManifest
+}
+
+// SnapshotTagName returns the tag name of a snapshot. Names that already
+// carry the prefix are returned unchanged.
+func SnapshotTagName(name string) string {
+	if strings.HasPrefix(name, SnapshotTagPrefix) {
+		return name
+	}
+	return SnapshotTagPrefix + name
+}
+
+// CreateSnapshot tags HEAD as a snapshot of the given workbooks, which are
+// slash-separated repository-relative workbook paths or directories. No
+// workbooks means every workbook with chunk data at HEAD. The chunk data
+// of the selected workbooks must be committed.
+func (c *Client) CreateSnapshot(name string, workbooks []string, note string) (*Snapshot, error) {
+	if c == nil {
+		return nil, utils.NewError(utils.ErrorTypeGit, "createSnapshot", "not a git repository")
+	}
+
+	tagName := SnapshotTagName(name)
+	refName := plumbing.NewTagReferenceName(tagName)
+	if name == "" || refName.Validate() != nil {
+		return nil, utils.NewError(utils.ErrorTypeValidation, "createSnapshot", "invalid snapshot name: "+name)
+	}
+	if _, err := c.repo.Reference(refName, false); err == nil {
+		return nil, utils.NewError(utils.ErrorTypeValidation, "createSnapshot", "snapshot "+strings.TrimPrefix(tagName, SnapshotTagPrefix)+" already exists")
+	}
+
+	head, err := c.repo.Head()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "createSnapshot", "repository has no commits")
+	}
+	commit, err := c.repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "createSnapshot", "failed to load HEAD")
+	}
+
+	all, err := c.snapshotWorkbooks(commit)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := selectWorkbooks(all, workbooks)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, utils.NewError(utils.ErrorTypeValidation, "createSnapshot", "no workbooks with committed chunk data at HEAD")
+	}
+	if err := c.checkChunksCommitted(selected); err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		Name:   strings.TrimPrefix(tagName, SnapshotTagPrefix),
+		Tag:    tagName,
+		Commit: commit.Hash,
+		Tagger: object.Signature{Name: c.config.UserName, Email: c.config.UserEmail, When: time.Now()},
+	}
+	snapshot.Manifest = SnapshotManifest{
+		Version:   snapshotManifestVersion,
+		Name:      snapshot.Name,
+		Commit:    commit.Hash.String(),
+		Created:   snapshot.Tagger.When.UTC().Truncate(time.Second),
+		Note:      note,
+		Workbooks: selected,
+	}
+
+	manifest, err := json.MarshalIndent(snapshot.Manifest, "", "  ")
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "createSnapshot", "failed to encode manifest")
+	}
+
+	tag := &object.Tag{
+		Name:       tagName,
+		Tagger:     snapshot.Tagger,
+		Message:    snapshotMessage(snapshot.Name, note, manifest),
+		TargetType: plumbing.CommitObject,
+		Target:     commit.Hash,
+	}
+	if tag.PGPSignature, err = c.signObject(tag.EncodeWithoutSignature); err != nil {
+		return nil, err
+	}
+
+	obj := c.repo.Storer.NewEncodedObject()
+	if err := tag.Encode(obj); err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "createSnapshot", "failed to encode tag")
+	}
+	tagHash, err := c.repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "createSnapshot", "failed to store tag")
+	}
+	if err := c.repo.Storer.SetReference(plumbing.NewHashReference(refName, tagHash)); err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "createSnapshot", "failed to create tag "+tagName)
+	}
+
+	return snapshot, nil
+}
+
+// Snapshots returns every snapshot in the repository, oldest first. Tags
+// below the snapshot prefix without a readable manifest are skipped.
+func (c *Client) Snapshots() ([]*Snapshot, error) {
+	if c == nil {
+		return nil, utils.NewError(utils.ErrorTypeGit, "listSnapshots", "not a git repository")
+	}
+
+	refs, err := c.repo.Tags()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "listSnapshots", "failed to read tags")
+	}
+	defer refs.Close()
+
+	var snapshots []*Snapshot
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if !strings.HasPrefix(ref.Name().Short(), SnapshotTagPrefix) {
+			return nil
+		}
+		snapshot, err := c.loadSnapshot(ref)
+		if err != nil {
+			c.logger.Debugf("Skipping tag %s: %v", ref.Name().Short(), err)
+			return nil
+		}
+		snapshots = append(snapshots, snapshot)
+		return nil
+	})
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "listSnapshots", "failed to read tags")
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].Manifest.Created.Equal(snapshots[j].Manifest.Created) {
+			return snapshots[i].Manifest.Created.Before(snapshots[j].Manifest.Created)
+		}
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots, nil
+}
+
+// Snapshot returns the snapshot with the given name
+func (c *Client) Snapshot(name string) (*Snapshot, error) {
+	if c == nil {
+		return nil, utils.NewError(utils.ErrorTypeGit, "loadSnapshot", "not a git repository")
+	}
+
+	ref, err := c.repo.Reference(plumbing.NewTagReferenceName(SnapshotTagName(name)), false)
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "loadSnapshot", "unknown snapshot "+name)
+	}
+	return c.loadSnapshot(ref)
+}
+
+// loadSnapshot reads the annotated tag a snapshot reference points to
+func (c *Client) loadSnapshot(ref *plumbing.Reference) (*Snapshot, error) {
+	tagName := ref.Name().Short()
+
+	tag, err := c.repo.TagObject(ref.Hash())
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "loadSnapshot", tagName+" is not an annotated tag")
+	}
+	if tag.TargetType != plumbing.CommitObject {
+		return nil, utils.NewError(utils.ErrorTypeGit, "loadSnapshot", tagName+" does not point to a commit")
+	}
+
+	manifest, err := parseSnapshotManifest(tag.Message)
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "loadSnapshot", tagName+" has no snapshot manifest")
+	}
+
+	return &Snapshot{
+		Name:     strings.TrimPrefix(tagName, SnapshotTagPrefix),
+		Tag:      tagName,
+		Commit:   tag.Target,
+		Tagger:   tag.Tagger,
+		Manifest: manifest,
+	}, nil
+}
+
+// snapshotMessage builds a tag message: a title, the optional note and the
+// JSON manifest
+func snapshotMessage(name, note string, manifest []byte) string {
+	var b strings.Builder
+	b.WriteString("GitCells snapshot " + name + "\n\n")
+	if note = strings.TrimSpace(note); note != "" {
+		b.WriteString(note + "\n\n")
+	}
+	b.Write(manifest)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// parseSnapshotManifest extracts the JSON manifest from a tag message
+func parseSnapshotManifest(message string) (SnapshotManifest, error) {
+	var manifest SnapshotManifest
+
+	start := strings.Index(message, "\n{")
+	if start < 0 {
+		return manifest, errors.New("manifest not found")
+	}
+	if err := json.Unmarshal([]byte(message[start+1:]), &manifest); err != nil {
+		return manifest, err
+	}
+	if manifest.Version == 0 {
+		return manifest, errors.New("manifest has no version")
+	}
+	return manifest, nil
+}
+
+// snapshotWorkbooks lists every workbook with chunk data in a commit
+func (c *Client) snapshotWorkbooks(commit *object.Commit) ([]SnapshotWorkbook, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "snapshotWorkbooks", "failed to read commit tree")
+	}
+	dataTree, err := tree.Tree(constants.GitCellsDataDir)
+	if errors.Is(err, object.ErrDirectoryNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "snapshotWorkbooks", "failed to read "+constants.GitCellsDataDir)
+	}
+
+	var workbooks []SnapshotWorkbook
+	var walk func(t *object.Tree, dir string) error
+	walk = func(t *object.Tree, dir string) error {
+		for _, entry := range t.Entries {
+			if entry.Mode.IsFile() {
+				continue
+			}
+			sub, err := t.Tree(entry.Name)
+			if err != nil {
+				return err
+			}
+			chunkDir := path.Join(dir, entry.Name)
+			if !strings.HasSuffix(entry.Name, constants.ChunksDirSuffix) {
+				if err := walk(sub, chunkDir); err != nil {
+					return err
+				}
+				continue
+			}
+
+			workbook, _ := WorkbookForChunkPath(c.Root(), chunkDir+"/")
+			checksum, files, err := chunkChecksum(sub)
+			if err != nil {
+				return err
+			}
+			workbooks = append(workbooks, SnapshotWorkbook{Path: workbook, Chunks: chunkDir, SHA256: checksum, Files: files})
+		}
+		return nil
+	}
+	if err := walk(dataTree, constants.GitCellsDataDir); err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "snapshotWorkbooks", "failed to read chunk data")
+	}
+
+	sort.Slice(workbooks, func(i, j int) bool { return workbooks[i].Path < workbooks[j].Path })
+	return workbooks, nil
+}
+
+// chunkChecksum returns the SHA-256 of a chunk directory and its file
+// count. Each file contributes its relative path, a NUL byte, its content
+// and another NUL byte, in path order.
+func chunkChecksum(tree *object.Tree) (string, int, error) {
+	var files []*object.File
+	err := tree.Files().ForEach(func(f *object.File) error {
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	h := sha256.New()
+	for _, f := range files {
+		reader, err := f.Reader()
+		if err != nil {
+			return "", 0, err
+		}
+		io.WriteString(h, f.Name+"\x00")
+		_, err = io.Copy(h, reader)
+		reader.Close()
+		if err != nil {
+			return "", 0, err
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), len(files), nil
+}
+
+// selectWorkbooks keeps the workbooks matching any of the given paths. Each
+// path must match at least one workbook.
+func selectWorkbooks(all []SnapshotWorkbook, paths []string) ([]SnapshotWorkbook, error) {
+	if len(paths) == 0 {
+		return all, nil
+	}
+
+	var selected []SnapshotWorkbook
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		p = path.Clean(p)
+		matched := false
+		for _, workbook := range all {
+			if p != "." && workbook.Path != p && !strings.HasPrefix(workbook.Path, p+"/") {
+				continue
+			}
+			matched = true
+			if !seen[workbook.Path] {
+				seen[workbook.Path] = true
+				selected = append(selected, workbook)
+			}
+		}
+		if !matched {
+			return nil, utils.NewError(utils.ErrorTypeValidation, "createSnapshot", "no committed chunk data for "+p)
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Path < selected[j].Path })
+	return selected, nil
+}
+
+// checkChunksCommitted fails if the chunk data of any workbook has
+// uncommitted changes, so a snapshot matches what is on disk
+func (c *Client) checkChunksCommitted(workbooks []SnapshotWorkbook) error {
+	status, err := c.Status()
+	if err != nil {
+		return err
+	}
+	for file, fileStatus := range status {
+		if fileStatus.Staging == git.Unmodified && fileStatus.Worktree == git.Unmodified {
+			continue
+		}
+		for _, workbook := range workbooks {
+			if strings.HasPrefix(file, workbook.Chunks+"/") {
+				return utils.NewError(utils.ErrorTypeGit, "createSnapshot",
+					workbook.Path+" has uncommitted chunk changes; commit them first")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSnapshotManifest(t *testing.T) {
+	message := snapshotMessage("2026-03", "March close", []byte(`{"version":1,"name":"2026-03","workbooks":[{"path":"Budget.xlsx"}]}`))
+	assert.Equal(t, "GitCells snapshot 2026-03\n\nMarch close\n\n{", message[:41])
+
+	manifest, err := parseSnapshotManifest(message)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03", manifest.Name)
+	assert.Equal(t, "Budget.xlsx", manifest.Workbooks[0].Path)
+
+	_, err = parseSnapshotManifest("Release 1.0\n")
+	assert.Error(t, err)
+	_, err = parseSnapshotManifest("Release\n\n{}")
+	assert.Error(t, err)
+}
+
+func TestClient_Snapshot(t *testing.T) {
+	logger := logrus.New()
+
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+	client, err := NewClient(tempDir, &Config{UserName: "GitCells", UserEmail: "gitcells@localhost"}, logger)
+	require.NoError(t, err)
+
+	writeChunk := func(workbook, content string) string {
+		chunk := filepath.Join(tempDir, ".gitcells", "data", workbook+"_chunks", "workbook.json")
+		require.NoError(t, os.MkdirAll(filepath.Dir(chunk), 0755))
+		require.NoError(t, os.WriteFile(chunk, []byte(content), 0600))
+		return chunk
+	}
+	commitChunk := func(workbook, content string) {
+		require.NoError(t, client.AutoCommit([]string{writeChunk(workbook, content)}, "GitCells: modify "+workbook))
+	}
+
+	commitChunk("Budget.xlsx", `{"v":1}`)
+	commitChunk("reports/Q1.xlsx", `{"v":1}`)
+	commitChunk("reports/Q2.xlsx", `{"v":1}`)
+
+	t.Run("tags every workbook by default", func(t *testing.T) {
+		snapshot, err := client.CreateSnapshot("2026-02", nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, "snapshot/2026-02", snapshot.Tag)
+
+		paths := make([]string, len(snapshot.Manifest.Workbooks))
+		for i, workbook := range snapshot.Manifest.Workbooks {
+			paths[i] = workbook.Path
+			assert.Len(t, workbook.SHA256, 64)
+			assert.Equal(t, 1, workbook.Files)
+		}
+		assert.Equal(t, []string{"Budget.xlsx", "reports/Q1.xlsx", "reports/Q2.xlsx"}, paths)
+
+		// The tag is annotated and resolves like any other revision
+		ref, err := repo.Reference(plumbing.NewTagReferenceName("snapshot/2026-02"), false)
+		require.NoError(t, err)
+		tag, err := repo.TagObject(ref.Hash())
+		require.NoError(t, err)
+		assert.Contains(t, tag.Message, `"sha256"`)
+
+		commit, err := client.ResolveRevision("snapshot/2026-02")
+		require.NoError(t, err)
+		assert.Equal(t, snapshot.Commit, commit.Hash)
+	})
+
+	t.Run("selects workbooks and directories", func(t *testing.T) {
+		commitChunk("Budget.xlsx", `{"v":2}`)
+
+		snapshot, err := client.CreateSnapshot("budget-v2", []string{"Budget.xlsx", "reports"}, "Board pack")
+		require.NoError(t, err)
+		assert.Len(t, snapshot.Manifest.Workbooks, 3)
+		assert.Equal(t, "Board pack", snapshot.Manifest.Note)
+
+		_, err = client.CreateSnapshot("missing", []string{"Missing.xlsx"}, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("checksums follow the chunk content", func(t *testing.T) {
+		older, err := client.Snapshot("2026-02")
+		require.NoError(t, err)
+		newer, err := client.Snapshot("snapshot/budget-v2")
+		require.NoError(t, err)
+
+		assert.NotEqual(t, older.Manifest.Workbooks[0].SHA256, newer.Manifest.Workbooks[0].SHA256)
+		assert.Equal(t, older.Manifest.Workbooks[1].SHA256, newer.Manifest.Workbooks[1].SHA256)
+	})
+
+	t.Run("refuses duplicates, bad names and uncommitted chunks", func(t *testing.T) {
+		_, err := client.CreateSnapshot("2026-02", nil, "")
+		assert.Error(t, err)
+		_, err = client.CreateSnapshot("bad name..", nil, "")
+		assert.Error(t, err)
+
+		writeChunk("Budget.xlsx", `{"v":3}`)
+		_, err = client.CreateSnapshot("dirty", []string{"Budget.xlsx"}, "")
+		assert.Error(t, err)
+		_, err = client.CreateSnapshot("reports-only", []string{"reports"}, "")
+		assert.NoError(t, err, "changes to other workbooks do not matter")
+	})
+
+	t.Run("lists snapshots and skips other tags", func(t *testing.T) {
+		head, err := repo.Head()
+		require.NoError(t, err)
+		_, err = repo.CreateTag("snapshot/lightweight", head.Hash(), nil)
+		require.NoError(t, err)
+		_, err = repo.CreateTag("v1.0", head.Hash(), nil)
+		require.NoError(t, err)
+
+		snapshots, err := client.Snapshots()
+		require.NoError(t, err)
+		names := make([]string, len(snapshots))
+		for i, snapshot := range snapshots {
+			names[i] = snapshot.Name
+		}
+		assert.ElementsMatch(t, []string{"2026-02", "budget-v2", "reports-only"}, names)
+	})
+}
@@ -0,0 +1,182 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/go-git/go-git/v5/config"
+)
+
+// BinaryStorage is how a repository stores the Excel files themselves
+type BinaryStorage string
+
+const (
+	// BinaryStorageGit commits Excel files as ordinary binary files
+	BinaryStorageGit BinaryStorage = "git"
+	// BinaryStorageLFS commits Excel files through Git LFS
+	BinaryStorageLFS BinaryStorage = "lfs"
+	// BinaryStorageIgnore keeps Excel files out of git; the JSON chunks are
+	// the source of truth
+	BinaryStorageIgnore BinaryStorage = "ignore"
+)
+
+const (
+	// ManagedBlockStart and ManagedBlockEnd delimit the lines GitCells
+	// maintains in .gitattributes and .gitignore
+	ManagedBlockStart = "# >>> gitcells >>>"
+	ManagedBlockEnd   = "# <<< gitcells <<<"
+
+	// lfsPointerPrefix starts every Git LFS pointer file
+	lfsPointerPrefix = "version https://git-lfs.github.com/spec/v1"
+)
+
+// ParseBinaryStorage validates a binary storage mode. An empty string means
+// BinaryStorageGit.
+func ParseBinaryStorage(value string) (BinaryStorage, error) {
+	switch mode := BinaryStorage(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return BinaryStorageGit, nil
+	case BinaryStorageGit, BinaryStorageLFS, BinaryStorageIgnore:
+		return mode, nil
+	default:
+		return "", utils.NewError(utils.ErrorTypeValidation, "parseBinaryStorage",
+			fmt.Sprintf("unknown binary storage %q (expected git, lfs or ignore)", value))
+	}
+}
+
+// AttributesFor returns the .gitattributes lines for a storage mode
+func AttributesFor(mode BinaryStorage) []string {
+	var lines []string
+	for _, ext := range constants.ExcelExtensions {
+		if mode == BinaryStorageLFS {
+			lines = append(lines, "*"+ext+" filter=lfs diff=lfs merge=lfs -text")
+		} else {
+			lines = append(lines, "*"+ext+" binary")
+		}
+	}
+	return append(lines, constants.GitCellsDataDir+"/** text eol=lf linguist-generated=true")
+}
+
+// IgnoresFor returns the .gitignore lines for a storage mode
+func IgnoresFor(mode BinaryStorage) []string {
+	lines := []string{
+		constants.ExcelTempPrefix + "*",
+		constants.LockFilePattern,
+		constants.TempFilePattern,
+		constants.GitCellsCacheDir + "/",
+		constants.GitCellsQueueFile,
+		constants.GitCellsQueueFile + ".tmp",
+		constants.GitCellsDaemonPIDFile,
+		constants.GitCellsDaemonLogFile,
+		constants.GitCellsDaemonSocket,
+		".DS_Store",
+		"Thumbs.db",
+	}
+	if mode == BinaryStorageIgnore {
+		for _, ext := range constants.ExcelExtensions {
+			lines = append(lines, "*"+ext)
+		}
+	}
+	return lines
+}
+
+// ReadManagedBlock returns the lines of the GitCells block in a file. It
+// reports false if the file or the block does not exist.
+func ReadManagedBlock(path string) ([]string, bool, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path within the repository
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "readManagedBlock", path, "failed to read file")
+	}
+
+	_, block, _, found := splitManagedBlock(string(data))
+	return block, found, nil
+}
+
+// WriteManagedBlock replaces the GitCells block in a file with lines,
+// appending the block if there is none and creating the file if needed.
+// Lines outside the block are kept. It reports whether the file changed.
+func WriteManagedBlock(path string, lines []string) (bool, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path within the repository
+	if err != nil && !os.IsNotExist(err) {
+		return false, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "writeManagedBlock", path, "failed to read file")
+	}
+
+	before, _, after, found := splitManagedBlock(string(data))
+	if !found {
+		before = string(data)
+		if before != "" && !strings.HasSuffix(before, "\n") {
+			before += "\n"
+		}
+		if before != "" {
+			before += "\n"
+		}
+	}
+
+	var content strings.Builder
+	content.WriteString(before)
+	content.WriteString(ManagedBlockStart + "\n")
+	for _, line := range lines {
+		content.WriteString(line + "\n")
+	}
+	content.WriteString(ManagedBlockEnd + "\n")
+	content.WriteString(after)
+
+	if content.String() == string(data) {
+		return false, nil
+	}
+	if err := os.WriteFile(path, []byte(content.String()), constants.FilePermissions); err != nil { // #nosec G306 - shared repository file
+		return false, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "writeManagedBlock", path, "failed to write file")
+	}
+	return true, nil
+}
+
+// splitManagedBlock splits content around the GitCells block
+func splitManagedBlock(content string) (before string, block []string, after string, found bool) {
+	start := strings.Index(content, ManagedBlockStart+"\n")
+	if start < 0 {
+		return content, nil, "", false
+	}
+	bodyStart := start + len(ManagedBlockStart) + 1
+
+	end := strings.Index(content[bodyStart:], ManagedBlockEnd)
+	if end < 0 {
+		return content, nil, "", false
+	}
+	body := strings.TrimSuffix(content[bodyStart:bodyStart+end], "\n")
+	if body != "" {
+		block = strings.Split(body, "\n")
+	}
+
+	after = content[bodyStart+end+len(ManagedBlockEnd):]
+	after = strings.TrimPrefix(after, "\n")
+	return content[:start], block, after, true
+}
+
+// LFSConfigured reports whether the Git LFS filter is set up for the
+// repository in its local, global or system git config
+func (c *Client) LFSConfigured() bool {
+	if c == nil {
+		return false
+	}
+
+	cfg, err := c.repo.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return false
+	}
+	return cfg.Raw.Section("filter").Subsection("lfs").Option("clean") != ""
+}
+
+// IsLFSPointer reports whether content read from r is a Git LFS pointer
+func IsLFSPointer(r io.Reader) bool {
+	header := make([]byte, len(lfsPointerPrefix))
+	n, _ := io.ReadFull(r, header)
+	return bytes.Equal(header[:n], []byte(lfsPointerPrefix))
+}
@@ -0,0 +1,98 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBinaryStorage(t *testing.T) {
+	for value, expected := range map[string]BinaryStorage{
+		"":       BinaryStorageGit,
+		"git":    BinaryStorageGit,
+		"LFS":    BinaryStorageLFS,
+		"ignore": BinaryStorageIgnore,
+	} {
+		mode, err := ParseBinaryStorage(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, mode, value)
+	}
+
+	_, err := ParseBinaryStorage("svn")
+	assert.Error(t, err)
+}
+
+func TestStorageLines(t *testing.T) {
+	assert.Contains(t, AttributesFor(BinaryStorageLFS), "*.xlsx filter=lfs diff=lfs merge=lfs -text")
+	assert.Contains(t, AttributesFor(BinaryStorageGit), "*.xlsx binary")
+
+	assert.Contains(t, IgnoresFor(BinaryStorageGit), "~$*")
+	assert.Contains(t, IgnoresFor(BinaryStorageGit), ".gitcells.cache/")
+	assert.Contains(t, IgnoresFor(BinaryStorageGit), ".gitcells/queue.json")
+	assert.Contains(t, IgnoresFor(BinaryStorageGit), ".gitcells/daemon.sock")
+	assert.NotContains(t, IgnoresFor(BinaryStorageGit), "*.xlsx")
+	assert.Contains(t, IgnoresFor(BinaryStorageIgnore), "*.xlsx")
+}
+
+func TestWriteManagedBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+
+	t.Run("creates the file", func(t *testing.T) {
+		changed, err := WriteManagedBlock(path, []string{"a", "b"})
+		require.NoError(t, err)
+		assert.True(t, changed)
+
+		lines, found, err := ReadManagedBlock(path)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, []string{"a", "b"}, lines)
+	})
+
+	t.Run("keeps lines outside the block", func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		content := "build/\n" + string(data) + "\n*.log"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+		changed, err := WriteManagedBlock(path, []string{"c"})
+		require.NoError(t, err)
+		assert.True(t, changed)
+
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "build/\n"+ManagedBlockStart+"\nc\n"+ManagedBlockEnd+"\n\n*.log", string(data))
+
+		changed, err = WriteManagedBlock(path, []string{"c"})
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("appends to a file without a block", func(t *testing.T) {
+		other := filepath.Join(t.TempDir(), ".gitattributes")
+		require.NoError(t, os.WriteFile(other, []byte("*.png binary"), 0600))
+
+		_, err := WriteManagedBlock(other, []string{"*.xlsx binary"})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(other)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(data), "*.png binary\n\n"+ManagedBlockStart+"\n"))
+	})
+
+	t.Run("reports a missing file", func(t *testing.T) {
+		_, found, err := ReadManagedBlock(filepath.Join(t.TempDir(), "missing"))
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+}
+
+func TestIsLFSPointer(t *testing.T) {
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:abc\nsize 12\n"
+	assert.True(t, IsLFSPointer(strings.NewReader(pointer)))
+	assert.False(t, IsLFSPointer(strings.NewReader("PK\x03\x04")))
+	assert.False(t, IsLFSPointer(strings.NewReader("")))
+}
@@ -0,0 +1,300 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// revertMarker introduces the hash of the commit a revert undoes, as in
+// the messages written by git revert
+const revertMarker = "This reverts commit "
+
+// undoTrailer marks the reverts written by Undo, so reverts made by hand
+// are not mistaken for them
+const undoTrailer = "GitCells-Undo: "
+
+// maxListedConflicts is the number of conflicting files an undo error names
+const maxListedConflicts = 5
+
+// templatePlaceholder matches a placeholder such as {filename} in a commit
+// template
+var templatePlaceholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// revertedHash finds the hash named by revertMarker in a revert message
+var revertedHash = regexp.MustCompile(regexp.QuoteMeta(revertMarker) + `([0-9a-f]{40})`)
+
+// UndoPlan describes how undoing a GitCells commit changes the repository
+type UndoPlan struct {
+	Commit *object.Commit
+	// Files are the repository-relative paths the commit changed
+	Files []string
+	// Workbooks are the workbooks whose chunk data or Excel file the commit
+	// changed
+	Workbooks []string
+}
+
+// MatchesCommitTemplate reports whether subject could have been rendered
+// from template, with any text standing in for each placeholder
+func MatchesCommitTemplate(template, subject string) bool {
+	if template == "" {
+		template = constants.DefaultCommitTemplate
+	}
+	template, _, _ = strings.Cut(template, "\n")
+
+	literals := templatePlaceholder.Split(template, -1)
+	for i, literal := range literals {
+		literals[i] = regexp.QuoteMeta(literal)
+	}
+	pattern, err := regexp.Compile("^" + strings.Join(literals, ".*") + "$")
+	if err != nil {
+		return false
+	}
+	return pattern.MatchString(subject)
+}
+
+// PlanUndo finds the most recent commit on the first-parent history of
+// HEAD that GitCells made, that is one by the configured committer whose
+// subject matches template or starts with "GitCells". Commits already
+// reverted by an earlier undo are skipped, so repeated undos walk back
+// through history. It fails if files the commit changed have changed since.
+func (c *Client) PlanUndo(template string) (*UndoPlan, error) {
+	if c == nil {
+		return nil, utils.NewError(utils.ErrorTypeGit, "planUndo", "not a git repository")
+	}
+
+	head, err := c.ResolveCommit("HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	reverted := map[plumbing.Hash]bool{}
+	var target *object.Commit
+	for commit := head; commit != nil; {
+		if c.isGitCellsCommit(commit, template) {
+			if match := revertedHash.FindStringSubmatch(commit.Message); match != nil {
+				reverted[plumbing.NewHash(match[1])] = true
+			} else if !reverted[commit.Hash] {
+				target = commit
+				break
+			}
+		}
+
+		if commit.NumParents() == 0 {
+			break
+		}
+		if commit, err = commit.Parent(0); err != nil {
+			return nil, utils.WrapError(err, utils.ErrorTypeGit, "planUndo", "failed to read commit history")
+		}
+	}
+	if target == nil {
+		return nil, utils.NewError(utils.ErrorTypeGit, "planUndo", "no GitCells commit to undo")
+	}
+	if target.NumParents() == 0 {
+		return nil, utils.NewError(utils.ErrorTypeGit, "planUndo", "cannot undo the first commit of the repository")
+	}
+
+	parent, err := target.Parent(0)
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "planUndo", "failed to load parent of "+target.Hash.String())
+	}
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "planUndo", "failed to read commit tree")
+	}
+	targetTree, err := target.Tree()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "planUndo", "failed to read commit tree")
+	}
+	headTree, err := head.Tree()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "planUndo", "failed to read commit tree")
+	}
+	changes, err := object.DiffTree(parentTree, targetTree)
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeGit, "planUndo", "failed to compare "+target.Hash.String()+" with its parent")
+	}
+
+	plan := &UndoPlan{Commit: target, Workbooks: c.changedWorkbooks(changes, true)}
+	var conflicts []string
+	for _, change := range changes {
+		name := change.To.Name
+		if name == "" {
+			name = change.From.Name
+		}
+		plan.Files = append(plan.Files, name)
+
+		changed, err := fileChanged(targetTree, headTree, name)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			conflicts = append(conflicts, name)
+		}
+	}
+	sort.Strings(plan.Files)
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		if len(conflicts) > maxListedConflicts {
+			conflicts = append(conflicts[:maxListedConflicts], fmt.Sprintf("and %d more", len(conflicts)-maxListedConflicts))
+		}
+		return nil, utils.NewError(utils.ErrorTypeConflict, "planUndo",
+			fmt.Sprintf("later commits changed files of %s: %s", target.Hash.String()[:7], strings.Join(conflicts, ", ")))
+	}
+
+	return plan, nil
+}
+
+// Undo commits the reverse of a planned undo. The files it restores must
+// not have uncommitted changes; other staged files are left staged and out
+// of the commit.
+func (c *Client) Undo(plan *UndoPlan) (plumbing.Hash, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status, err := c.Status()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	for _, name := range plan.Files {
+		if fileStatus, ok := status[name]; ok && (fileStatus.Staging != git.Unmodified || fileStatus.Worktree != git.Unmodified) {
+			return plumbing.ZeroHash, utils.NewError(utils.ErrorTypeGit, "undo",
+				name+" has uncommitted changes; commit or discard them first")
+		}
+	}
+
+	parent, err := plan.Commit.Parent(0)
+	if err != nil {
+		return plumbing.ZeroHash, utils.WrapError(err, utils.ErrorTypeGit, "undo", "failed to load parent of "+plan.Commit.Hash.String())
+	}
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, utils.WrapError(err, utils.ErrorTypeGit, "undo", "failed to read commit tree")
+	}
+
+	for _, name := range plan.Files {
+		if err := c.restoreFile(parentTree, name); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	subject, _, _ := strings.Cut(plan.Commit.Message, "\n")
+	message := fmt.Sprintf("Revert \"%s\"\n\n%s%s.\n\n%s%s\n",
+		subject, revertMarker, plan.Commit.Hash.String(), undoTrailer, plan.Commit.Hash.String())
+	owns := func(name string) bool {
+		i := sort.SearchStrings(plan.Files, name)
+		return i < len(plan.Files) && plan.Files[i] == name
+	}
+	var hash plumbing.Hash
+	err = c.commitOnly(status, owns, func() (err error) {
+		hash, err = c.worktree.Commit(message, &git.CommitOptions{
+			Author: &object.Signature{
+				Name:  c.config.UserName,
+				Email: c.config.UserEmail,
+				When:  time.Now(),
+			},
+			Signer: c.signer,
+		})
+		return err
+	})
+	if err != nil {
+		return plumbing.ZeroHash, utils.WrapError(err, utils.ErrorTypeGit, "undo", "failed to create git commit")
+	}
+	return hash, nil
+}
+
+// isGitCellsCommit reports whether GitCells made a commit, judging by its
+// committer and subject, and for reverts by the undo trailer. The author
+// may be whoever edited the workbook.
+func (c *Client) isGitCellsCommit(commit *object.Commit, template string) bool {
+	if commit.Committer.Name != c.config.UserName || commit.Committer.Email != c.config.UserEmail {
+		return false
+	}
+	subject, _, _ := strings.Cut(commit.Message, "\n")
+	if strings.HasPrefix(subject, "Revert \"") {
+		return strings.Contains(commit.Message, "\n"+undoTrailer)
+	}
+	return strings.HasPrefix(subject, "GitCells") ||
+		MatchesCommitTemplate(template, subject)
+}
+
+// restoreFile writes a file of tree to the worktree and stages it, or
+// removes the file if the tree does not have it
+func (c *Client) restoreFile(tree *object.Tree, name string) error {
+	file, err := tree.File(name)
+	if errors.Is(err, object.ErrFileNotFound) {
+		if _, err := c.worktree.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return utils.WrapFileError(err, utils.ErrorTypeGit, "undo", name, "failed to remove file")
+		}
+		return nil
+	}
+	if err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeGit, "undo", name, "failed to read file")
+	}
+
+	reader, err := file.Reader()
+	if err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeGit, "undo", name, "failed to read file")
+	}
+	defer reader.Close()
+
+	destPath := filepath.Join(c.Root(), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(destPath), constants.SecureDirPermissions); err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "undo", destPath, "failed to create directory")
+	}
+	out, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, constants.FilePermissions)
+	if err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "undo", destPath, "failed to write file")
+	}
+	if _, err := io.Copy(out, reader); err != nil {
+		out.Close()
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "undo", destPath, "failed to write file")
+	}
+	if err := out.Close(); err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "undo", destPath, "failed to write file")
+	}
+
+	if _, err := c.worktree.Add(path.Clean(name)); err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeGit, "undo", name, "failed to stage file")
+	}
+	return nil
+}
+
+// fileChanged reports whether a file differs between two trees, counting a
+// file missing from one of them as a difference
+func fileChanged(a, b *object.Tree, name string) (bool, error) {
+	hashOf := func(tree *object.Tree) (plumbing.Hash, error) {
+		entry, err := tree.FindEntry(name)
+		if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+			return plumbing.ZeroHash, nil
+		}
+		if err != nil {
+			return plumbing.ZeroHash, utils.WrapFileError(err, utils.ErrorTypeGit, "planUndo", name, "failed to read tree")
+		}
+		return entry.Hash, nil
+	}
+
+	hashA, err := hashOf(a)
+	if err != nil {
+		return false, err
+	}
+	hashB, err := hashOf(b)
+	if err != nil {
+		return false, err
+	}
+	return hashA != hashB, nil
+}
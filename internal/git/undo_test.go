@@ -0,0 +1,196 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesCommitTemplate(t *testing.T) {
+	assert.True(t, MatchesCommitTemplate("", "GitCells: modify Budget.xlsx at 2026-03-09 15:00:00"))
+	assert.True(t, MatchesCommitTemplate("Excel [{action}] {filename}", "Excel [create] a.xlsx, b.xlsx"))
+	assert.True(t, MatchesCommitTemplate("{action} {filename}\n\nbody", "modify Budget.xlsx"))
+	assert.False(t, MatchesCommitTemplate("Excel [{action}] {filename}", "Fix typo in README"))
+	assert.False(t, MatchesCommitTemplate("", "GitCells: modify Budget.xlsx"))
+}
+
+func TestClient_Undo(t *testing.T) {
+	logger := logrus.New()
+	config := &Config{UserName: "GitCells", UserEmail: "gitcells@localhost"}
+
+	setup := func(t *testing.T) (*Client, *git.Repository, string) {
+		tempDir := t.TempDir()
+		repo, err := git.PlainInit(tempDir, false)
+		require.NoError(t, err)
+		client, err := NewClient(tempDir, config, logger)
+		require.NoError(t, err)
+
+		readme := filepath.Join(tempDir, "README.md")
+		require.NoError(t, os.WriteFile(readme, []byte("budget"), 0600))
+		require.NoError(t, client.AutoCommit([]string{readme}, "Initial commit"))
+		return client, repo, tempDir
+	}
+
+	// commitChunk writes a chunk file and commits it as author
+	commitChunk := func(t *testing.T, repo *git.Repository, dir, workbook, content, author, message string) {
+		chunk := filepath.Join(".gitcells", "data", workbook+"_chunks", "workbook.json")
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(chunk)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, chunk), []byte(content), 0600))
+
+		worktree, err := repo.Worktree()
+		require.NoError(t, err)
+		_, err = worktree.Add(filepath.ToSlash(chunk))
+		require.NoError(t, err)
+		email := author + "@example.com"
+		if author == config.UserName {
+			email = config.UserEmail
+		}
+		_, err = worktree.Commit(message, &git.CommitOptions{Author: &object.Signature{Name: author, Email: email}})
+		require.NoError(t, err)
+	}
+
+	readChunk := func(t *testing.T, dir, workbook string) string {
+		data, err := os.ReadFile(filepath.Join(dir, ".gitcells", "data", workbook+"_chunks", "workbook.json"))
+		if os.IsNotExist(err) {
+			return ""
+		}
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("reverts the latest GitCells commit and walks back on repeat", func(t *testing.T) {
+		client, repo, dir := setup(t)
+		commitChunk(t, repo, dir, "Budget.xlsx", "1", "GitCells", "GitCells: create Budget.xlsx at 2026-03-09 09:00:00")
+		commitChunk(t, repo, dir, "Budget.xlsx", "2", "GitCells", "GitCells: modify Budget.xlsx at 2026-03-09 10:00:00")
+		commitChunk(t, repo, dir, "Other.xlsx", "1", "alice", "GitCells: modify Other.xlsx by hand")
+
+		plan, err := client.PlanUndo("")
+		require.NoError(t, err)
+		assert.Equal(t, "GitCells: modify Budget.xlsx at 2026-03-09 10:00:00", plan.Commit.Message)
+		assert.Equal(t, []string{".gitcells/data/Budget.xlsx_chunks/workbook.json"}, plan.Files)
+		assert.Equal(t, []string{"Budget.xlsx"}, plan.Workbooks)
+
+		hash, err := client.Undo(plan)
+		require.NoError(t, err)
+		assert.Equal(t, "1", readChunk(t, dir, "Budget.xlsx"))
+		commit, err := repo.CommitObject(hash)
+		require.NoError(t, err)
+		assert.Equal(t, "Revert \"GitCells: modify Budget.xlsx at 2026-03-09 10:00:00\"\n\nThis reverts commit "+plan.Commit.Hash.String()+
+			".\n\nGitCells-Undo: "+plan.Commit.Hash.String()+"\n", commit.Message)
+
+		// The next undo reverts the commit before, removing the new chunks
+		plan, err = client.PlanUndo("")
+		require.NoError(t, err)
+		assert.Equal(t, "GitCells: create Budget.xlsx at 2026-03-09 09:00:00", plan.Commit.Message)
+		_, err = client.Undo(plan)
+		require.NoError(t, err)
+		assert.Equal(t, "", readChunk(t, dir, "Budget.xlsx"))
+		assert.Equal(t, "1", readChunk(t, dir, "Other.xlsx"))
+
+		clean, err := client.IsClean()
+		require.NoError(t, err)
+		assert.True(t, clean)
+	})
+
+	t.Run("matches a custom template", func(t *testing.T) {
+		client, repo, dir := setup(t)
+		commitChunk(t, repo, dir, "Budget.xlsx", "1", "GitCells", "Excel [modify] Budget.xlsx")
+		commitChunk(t, repo, dir, "Other.xlsx", "1", "GitCells", "Update docs")
+
+		plan, err := client.PlanUndo("Excel [{action}] {filename}")
+		require.NoError(t, err)
+		assert.Equal(t, "Excel [modify] Budget.xlsx", plan.Commit.Message)
+	})
+
+	t.Run("refuses when later commits changed the same files", func(t *testing.T) {
+		client, repo, dir := setup(t)
+		commitChunk(t, repo, dir, "Budget.xlsx", "1", "GitCells", "GitCells: modify Budget.xlsx at 2026-03-09 10:00:00")
+		commitChunk(t, repo, dir, "Budget.xlsx", "2", "alice", "Hand edit")
+
+		_, err := client.PlanUndo("")
+		assert.ErrorContains(t, err, ".gitcells/data/Budget.xlsx_chunks/workbook.json")
+	})
+
+	t.Run("refuses uncommitted changes to the same files", func(t *testing.T) {
+		client, repo, dir := setup(t)
+		commitChunk(t, repo, dir, "Budget.xlsx", "1", "GitCells", "GitCells: modify Budget.xlsx at 2026-03-09 10:00:00")
+		commitChunk(t, repo, dir, "Budget.xlsx", "2", "GitCells", "GitCells: modify Budget.xlsx at 2026-03-09 11:00:00")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitcells", "data", "Budget.xlsx_chunks", "workbook.json"), []byte("3"), 0600))
+
+		plan, err := client.PlanUndo("")
+		require.NoError(t, err)
+		_, err = client.Undo(plan)
+		assert.Error(t, err)
+		assert.Equal(t, "3", readChunk(t, dir, "Budget.xlsx"))
+	})
+
+	t.Run("restores deleted files readable by others", func(t *testing.T) {
+		client, repo, dir := setup(t)
+		commitChunk(t, repo, dir, "Budget.xlsx", "1", "GitCells", "GitCells: create Budget.xlsx at 2026-03-09 09:00:00")
+		worktree, err := repo.Worktree()
+		require.NoError(t, err)
+		_, err = worktree.Remove(".gitcells/data/Budget.xlsx_chunks/workbook.json")
+		require.NoError(t, err)
+		_, err = worktree.Commit("GitCells: delete Budget.xlsx at 2026-03-09 10:00:00", &git.CommitOptions{
+			Author: &object.Signature{Name: config.UserName, Email: config.UserEmail},
+		})
+		require.NoError(t, err)
+
+		plan, err := client.PlanUndo("")
+		require.NoError(t, err)
+		_, err = client.Undo(plan)
+		require.NoError(t, err)
+		assert.Equal(t, "1", readChunk(t, dir, "Budget.xlsx"))
+		info, err := os.Stat(filepath.Join(dir, ".gitcells", "data", "Budget.xlsx_chunks", "workbook.json"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	})
+
+	t.Run("leaves other staged files out of the revert", func(t *testing.T) {
+		client, repo, dir := setup(t)
+		commitChunk(t, repo, dir, "Budget.xlsx", "1", "GitCells", "GitCells: modify Budget.xlsx at 2026-03-09 10:00:00")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("draft"), 0600))
+		worktree, err := repo.Worktree()
+		require.NoError(t, err)
+		_, err = worktree.Add("notes.txt")
+		require.NoError(t, err)
+
+		plan, err := client.PlanUndo("")
+		require.NoError(t, err)
+		hash, err := client.Undo(plan)
+		require.NoError(t, err)
+
+		commit, err := repo.CommitObject(hash)
+		require.NoError(t, err)
+		tree, err := commit.Tree()
+		require.NoError(t, err)
+		_, err = tree.File("notes.txt")
+		assert.ErrorIs(t, err, object.ErrFileNotFound)
+
+		status, err := client.Status()
+		require.NoError(t, err)
+		assert.Equal(t, git.Added, status.File("notes.txt").Staging)
+	})
+
+	t.Run("ignores reverts made by hand", func(t *testing.T) {
+		client, repo, dir := setup(t)
+		commitChunk(t, repo, dir, "Budget.xlsx", "1", "GitCells", "GitCells: modify Budget.xlsx at 2026-03-09 10:00:00")
+		commitChunk(t, repo, dir, "Other.xlsx", "1", "GitCells", "Revert \"Add Other.xlsx\"\n\nThis reverts commit 0123456789abcdef0123456789abcdef01234567.\n")
+
+		plan, err := client.PlanUndo("")
+		require.NoError(t, err)
+		assert.Equal(t, "GitCells: modify Budget.xlsx at 2026-03-09 10:00:00", plan.Commit.Message)
+	})
+
+	t.Run("nothing to undo", func(t *testing.T) {
+		client, _, _ := setup(t)
+		_, err := client.PlanUndo("")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+)
+
+// WorkbookForChunkPath maps a slash-separated, repository-relative path
+// inside a chunk directory to the repository-relative path of its workbook
+func WorkbookForChunkPath(gitRoot, relPath string) (string, bool) {
+	dataPrefix := constants.GitCellsDataDir + "/"
+	if !strings.HasPrefix(relPath, dataPrefix) {
+		return "", false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(relPath, dataPrefix), "/")
+	for i, part := range parts {
+		if !strings.HasSuffix(part, constants.ChunksDirSuffix) || i == len(parts)-1 {
+			continue
+		}
+
+		name := strings.TrimSuffix(part, constants.ChunksDirSuffix)
+		workbook := path.Join(append(parts[:i:i], name)...)
+		if isExcelPath(workbook) {
+			return workbook, true
+		}
+
+		// Older chunk directories omit the extension
+		for _, ext := range constants.ExcelExtensions {
+			if _, err := os.Stat(filepath.Join(gitRoot, filepath.FromSlash(workbook+ext))); err == nil {
+				return workbook + ext, true
+			}
+		}
+		return workbook + constants.ExtXLSX, true
+	}
+
+	return "", false
+}
+
+// isExcelPath reports whether a path has an Excel extension
+func isExcelPath(p string) bool {
+	ext := strings.ToLower(path.Ext(p))
+	for _, excelExt := range constants.ExcelExtensions {
+		if ext == excelExt {
+			return true
+		}
+	}
+	return false
+}
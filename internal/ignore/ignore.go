@@ -0,0 +1,209 @@
+// Package ignore matches paths against gitignore-style patterns, as used by
+// watcher.ignore_patterns, the --include and --exclude flags and
+// .gitcellsignore files.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// FileName is the name of the files listing patterns for the directory they
+// are in and the directories below it, like .gitignore
+const FileName = ".gitcellsignore"
+
+// Patterns is a list of patterns with gitignore semantics: a pattern without
+// a slash matches a name at any depth, one with a slash is relative to the
+// directory the patterns belong to, ** matches any number of directories, a
+// trailing slash matches only directories and a leading ! includes again
+// what an earlier pattern excluded. Later patterns take precedence.
+type Patterns struct {
+	patterns []gitignore.Pattern
+}
+
+// Parse returns the patterns in lines, relative to dir, skipping blank
+// lines and comments
+func Parse(lines []string, dir string) Patterns {
+	return Patterns{patterns: parse(lines, split(dir))}
+}
+
+func parse(lines []string, domain []string) []gitignore.Pattern {
+	var patterns []gitignore.Pattern
+	for _, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(filepath.ToSlash(line), domain))
+	}
+	return patterns
+}
+
+// Validate checks a pattern as Parse reads it. Blank lines and comments are
+// valid; a pattern with a part that is not a valid glob, such as an
+// unclosed '[', would never match anything and is not.
+func Validate(line string) error {
+	line = strings.TrimSuffix(line, "\r")
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	pattern := strings.TrimPrefix(filepath.ToSlash(line), "!")
+	if !strings.HasSuffix(pattern, "\\ ") {
+		pattern = strings.TrimRight(pattern, " ")
+	}
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return errors.New("pattern matches nothing")
+	}
+	for _, part := range strings.Split(pattern, "/") {
+		if _, err := filepath.Match(part, ""); err != nil {
+			return fmt.Errorf("%q is not a valid glob", part)
+		}
+	}
+	return nil
+}
+
+// Match reports whether path is excluded by the patterns
+func (p Patterns) Match(path string, isDir bool) bool {
+	return match(p.patterns, split(path), isDir) == gitignore.Exclude
+}
+
+// Empty reports whether there are no patterns
+func (p Patterns) Empty() bool {
+	return len(p.patterns) == 0
+}
+
+// match returns the result of the last pattern that matches path
+func match(patterns []gitignore.Pattern, path []string, isDir bool) gitignore.MatchResult {
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if result := patterns[i].Match(path, isDir); result != gitignore.NoMatch {
+			return result
+		}
+	}
+	return gitignore.NoMatch
+}
+
+// split returns the components of a path made absolute
+func split(path string) []string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// Matcher applies patterns relative to a root directory together with the
+// .gitcellsignore files in the directories of the paths it matches, those
+// in deeper directories taking precedence. For a path below the root the
+// files from the root down are read. For any other path, those in all of
+// its parent directories are, and of the root's patterns only those without
+// a slash, which match names at any depth, apply.
+type Matcher struct {
+	root     string
+	patterns []gitignore.Pattern
+	// names holds the patterns without a slash, for paths outside the root
+	names []gitignore.Pattern
+
+	mu sync.Mutex
+	// files caches the patterns of the .gitcellsignore file of each
+	// directory, nil when it has none
+	files map[string][]gitignore.Pattern
+}
+
+// NewMatcher returns a matcher for patterns relative to root
+func NewMatcher(root string, patterns []string) *Matcher {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	var names []string
+	for _, pattern := range patterns {
+		if !strings.Contains(strings.TrimSuffix(filepath.ToSlash(pattern), "/"), "/") {
+			names = append(names, pattern)
+		}
+	}
+	return &Matcher{
+		root:     root,
+		patterns: parse(patterns, split(root)),
+		names:    parse(names, nil),
+		files:    make(map[string][]gitignore.Pattern),
+	}
+}
+
+// Match reports whether path is ignored
+func (m *Matcher) Match(path string, isDir bool) bool {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if path == m.root {
+		return false
+	}
+
+	// The directories holding files that apply, deepest first
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == m.root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	root := m.patterns
+	if dirs[len(dirs)-1] != m.root {
+		root = m.names
+	}
+	patterns := append([]gitignore.Pattern(nil), root...)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		patterns = append(patterns, m.file(dirs[i])...)
+	}
+	return match(patterns, split(path), isDir) == gitignore.Exclude
+}
+
+// Reset forgets the .gitcellsignore files read so far, so that changes to
+// them are seen
+func (m *Matcher) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files = make(map[string][]gitignore.Pattern)
+}
+
+// file returns the patterns of the .gitcellsignore file in dir
+func (m *Matcher) file(dir string) []gitignore.Pattern {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if patterns, ok := m.files[dir]; ok {
+		return patterns
+	}
+
+	patterns := parse(readLines(filepath.Join(dir, FileName)), split(dir))
+	m.files[dir] = patterns
+	return patterns
+}
+
+// readLines returns the lines of a file, or none if it cannot be read
+func readLines(path string) []string {
+	file, err := os.Open(path) // #nosec G304 -- ignore files are read from watched directories
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
@@ -0,0 +1,112 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatterns(t *testing.T) {
+	root := t.TempDir()
+	patterns := Parse([]string{
+		"# comment",
+		"",
+		"*.tmp",
+		"/top.xlsx",
+		"archive/**/*.xlsx",
+		"build/",
+		"drafts/*.xlsx",
+		"!drafts/final.xlsx",
+	}, root)
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"book.tmp", false, true},
+		{"reports/q1/book.tmp", false, true},
+		{"top.xlsx", false, true},
+		{"reports/top.xlsx", false, false},
+		{"archive/old.xlsx", false, true},
+		{"archive/2023/q4/old.xlsx", false, true},
+		{"archive/notes.txt", false, false},
+		{"reports/archive/old.xlsx", false, false},
+		{"build", true, true},
+		{"reports/build", true, true},
+		{"build", false, false},
+		{"drafts/plan.xlsx", false, true},
+		{"drafts/final.xlsx", false, false},
+		{"drafts/2024/plan.xlsx", false, false},
+		{"# comment", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.ignored, patterns.Match(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir))
+		})
+	}
+
+	assert.True(t, Parse(nil, root).Empty())
+	assert.False(t, patterns.Empty())
+}
+
+func TestMatcher(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	write(FileName, "scratch/\n*_old.xlsx\n")
+	write("reports/"+FileName, "/draft.xlsx\n!keep_old.xlsx\n")
+
+	m := NewMatcher(root, []string{"*.tmp", "budget.xlsx"})
+	match := func(path string, isDir bool) bool {
+		return m.Match(filepath.Join(root, filepath.FromSlash(path)), isDir)
+	}
+
+	assert.False(t, m.Match(root, true), "the root is never ignored")
+	assert.True(t, match("a.tmp", false))
+	assert.True(t, match("budget.xlsx", false))
+	assert.True(t, match("scratch", true))
+	assert.True(t, match("reports/scratch", true))
+	assert.True(t, match("sales_old.xlsx", false))
+	assert.True(t, match("reports/sales_old.xlsx", false))
+	assert.False(t, match("reports/keep_old.xlsx", false), "a deeper file includes it again")
+	assert.True(t, match("keep_old.xlsx", false))
+	assert.True(t, match("reports/draft.xlsx", false))
+	assert.False(t, match("reports/q1/draft.xlsx", false), "anchored to the directory of its file")
+	assert.False(t, match("draft.xlsx", false))
+
+	// A .gitcellsignore file overrides the patterns given to the matcher
+	write("data/"+FileName, "!budget.xlsx\n")
+	assert.False(t, match("data/budget.xlsx", false))
+
+	// Changes are seen after Reset
+	write(FileName, "")
+	assert.True(t, match("sales_old.xlsx", false))
+	m.Reset()
+	assert.False(t, match("sales_old.xlsx", false))
+
+	t.Run("paths outside the root", func(t *testing.T) {
+		other := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(other, FileName), []byte("local.xlsx\n"), 0600))
+
+		assert.True(t, m.Match(filepath.Join(other, "a.tmp"), false))
+		assert.True(t, m.Match(filepath.Join(other, "local.xlsx"), false))
+		assert.False(t, m.Match(filepath.Join(other, "book.xlsx"), false))
+	})
+}
+
+func TestValidate(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment", "*.tmp", "!keep.xlsx", "/build/", "reports/**/*.xlsx", `\#notes.xlsx`, "[Tt]emp*"} {
+		assert.NoError(t, Validate(line), line)
+	}
+	for _, line := range []string{"!", "/", "[abc", "reports/[2024/*.xlsx"} {
+		assert.Error(t, Validate(line), line)
+	}
+}
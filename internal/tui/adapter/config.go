@@ -40,8 +40,8 @@ func (ca *ConfigAdapter) SaveSetupConfig(setup types.SetupConfig) error {
 	v.Set("git.branch", "main")
 	v.Set("git.auto_push", setup.AutoPush)
 	v.Set("git.auto_pull", true)
-	v.Set("git.user_name", "GitCells")
-	v.Set("git.user_email", "gitcells@localhost")
+	v.Set("git.user_name", constants.DefaultGitUserName)
+	v.Set("git.user_email", constants.DefaultGitUserEmail)
 	v.Set("git.commit_template", setup.CommitTemplate)
 
 	// Watcher settings
@@ -1,6 +1,11 @@
 package adapter
 
 import (
+	"os"
+	"path/filepath"
+
+	"github.com/Classic-Homes/gitcells/internal/config"
+	"github.com/Classic-Homes/gitcells/internal/constants"
 	"github.com/Classic-Homes/gitcells/internal/git"
 	"github.com/sirupsen/logrus"
 )
@@ -14,8 +19,22 @@ type GitAdapter struct {
 func NewGitAdapter(directory string) (*GitAdapter, error) {
 	// Create a minimal git config for basic commit operations only
 	gitConfig := &git.Config{
-		UserName:  "GitCells",
-		UserEmail: "gitcells@localhost",
+		UserName:  constants.DefaultGitUserName,
+		UserEmail: constants.DefaultGitUserEmail,
+	}
+
+	// Use the author, attribution and signing settings of the directory's
+	// configuration
+	configPath := filepath.Join(directory, constants.ConfigFileName)
+	if _, err := os.Stat(configPath); err == nil {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return &GitAdapter{}, err
+		}
+		gitConfig.UserName = cfg.Git.UserName
+		gitConfig.UserEmail = cfg.Git.UserEmail
+		gitConfig.Signing = git.SigningConfig(cfg.Git.Signing)
+		gitConfig.Attribution = git.AttributionConfig(cfg.Git.Attribution)
 	}
 
 	// Create a simple logger
@@ -3,10 +3,12 @@ package adapter
 import (
 	"fmt"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/Classic-Homes/gitcells/internal/config"
 	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/daemon"
 	"github.com/Classic-Homes/gitcells/internal/git"
 	"github.com/Classic-Homes/gitcells/internal/watcher"
 	"github.com/sirupsen/logrus"
@@ -15,17 +17,23 @@ import (
 // WatcherAdapter bridges the TUI with the watcher package
 type WatcherAdapter struct {
 	watcher   *watcher.FileWatcher
+	queue     *watcher.Queue
 	config    *config.Config
 	logger    *logrus.Logger
 	converter converter.Converter
 	gitClient *git.Client
+	batcher   *git.CommitBatcher
 
 	// State tracking
 	isRunning          bool
 	startTime          time.Time
 	directoriesWatched int
-	lastEvent          string
-	lastEventTime      time.Time
+
+	// mu guards the last event, which the event handler records while the
+	// TUI reads it
+	mu            sync.Mutex
+	lastEvent     string
+	lastEventTime time.Time
 
 	// Event callback for TUI updates
 	onEvent func(WatcherEvent)
@@ -48,6 +56,18 @@ type WatcherStatus struct {
 	LastEvent          string
 	LastEventTime      time.Time
 	Directories        []string
+	// PendingChanges counts the changes waiting to be processed, and
+	// FailedChanges lists those that failed every retry
+	PendingChanges int
+	FailedChanges  []watcher.QueueItem
+	// QueuedEvents counts the events waiting for a conversion slot, and
+	// ActiveEvents those being converted
+	QueuedEvents int
+	ActiveEvents int
+	// Daemon is set when the state comes from the background watcher, which
+	// may be Paused
+	Daemon bool
+	Paused bool
 }
 
 // NewWatcherAdapter creates a new watcher adapter
@@ -72,11 +92,18 @@ func (wa *WatcherAdapter) Start() error {
 	if wa.isRunning {
 		return fmt.Errorf("watcher is already running")
 	}
+	if _, ok := daemon.Running("."); ok {
+		return fmt.Errorf("the watcher daemon is already watching this repository")
+	}
 
 	// Initialize git client
 	gitConfig := &git.Config{
-		UserName:  wa.config.Git.UserName,
-		UserEmail: wa.config.Git.UserEmail,
+		UserName:       wa.config.Git.UserName,
+		UserEmail:      wa.config.Git.UserEmail,
+		CommitTemplate: wa.config.Git.CommitTemplate,
+		CommitBody:     wa.config.Git.CommitBody,
+		Signing:        git.SigningConfig(wa.config.Git.Signing),
+		Attribution:    git.AttributionConfig(wa.config.Git.Attribution),
 	}
 
 	gitClient, err := git.NewClient(".", gitConfig, wa.logger)
@@ -84,11 +111,16 @@ func (wa *WatcherAdapter) Start() error {
 		return fmt.Errorf("failed to initialize git client: %w", err)
 	}
 	wa.gitClient = gitClient
+	if wa.config.Git.Session.Enabled && gitClient != nil {
+		if _, err := gitClient.StartSession(git.SessionUser()); err != nil {
+			return fmt.Errorf("failed to start session branch: %w", err)
+		}
+	}
+	wa.batcher = git.NewCommitBatcher(wa.config.Git.CommitBatchWindow, wa.commitChanges, wa.logger)
 
 	// Create event handler
 	handler := func(event watcher.FileEvent) error {
-		wa.lastEvent = fmt.Sprintf("%s: %s", event.Type, filepath.Base(event.Path))
-		wa.lastEventTime = event.Timestamp
+		wa.setLastEvent(fmt.Sprintf("%s: %s", event.Type, filepath.Base(event.Path)), event.Timestamp)
 
 		// Notify TUI
 		if wa.onEvent != nil {
@@ -101,53 +133,28 @@ func (wa *WatcherAdapter) Start() error {
 			})
 		}
 
-		// Convert Excel to JSON
-		convertOptions := converter.ConvertOptions{
-			PreserveFormulas: wa.config.Converter.PreserveFormulas,
-			PreserveStyles:   wa.config.Converter.PreserveStyles,
-			PreserveComments: wa.config.Converter.PreserveComments,
-			CompactJSON:      wa.config.Converter.CompactJSON,
-			IgnoreEmptyCells: wa.config.Converter.IgnoreEmptyCells,
-			MaxCellsPerSheet: wa.config.Converter.MaxCellsPerSheet,
-			ChunkingStrategy: "sheet-based",
-		}
-
-		if err := wa.converter.ExcelToJSONFile(event.Path, event.Path, convertOptions); err != nil {
+		options := converter.OptionsFromConfig(wa.config.Converter)
+		change, err := watcher.UpdateChunks(wa.converter, event, options, wa.config.Watcher.OnDelete == "archive", wa.logger)
+		if err != nil {
 			if wa.onEvent != nil {
 				wa.onEvent(WatcherEvent{
 					Type:      "error",
-					Message:   fmt.Sprintf("Conversion failed: %s", filepath.Base(event.Path)),
+					Message:   fmt.Sprintf("Processing failed: %s", filepath.Base(event.Path)),
 					Details:   err.Error(),
 					Timestamp: time.Now(),
 					FilePath:  event.Path,
 				})
 			}
-			return fmt.Errorf("failed to convert Excel to JSON: %w", err)
+			return err
 		}
 
 		// Auto-commit to git
-		if wa.gitClient != nil {
-			chunkPaths, err := wa.converter.GetChunkPaths(event.Path)
-			if err != nil {
-				wa.logger.Warnf("Failed to get chunk paths for git commit: %v", err)
-				chunkPaths = []string{filepath.Join(".gitcells", "data")}
-			}
-
-			message := fmt.Sprintf("GitCells: %s %s", event.Type.String(), filepath.Base(event.Path))
-			if err := wa.gitClient.AutoCommit(chunkPaths, message); err != nil {
-				if wa.onEvent != nil {
-					wa.onEvent(WatcherEvent{
-						Type:      "error",
-						Message:   "Git commit failed",
-						Details:   err.Error(),
-						Timestamp: time.Now(),
-						FilePath:  event.Path,
-					})
-				}
-				return fmt.Errorf("failed to auto-commit: %w", err)
-			}
+		if wa.gitClient == nil || (len(change.Files) == 0 && len(change.Removed) == 0) {
+			return nil
+		}
+		if err := wa.batcher.Add(change); err != nil {
+			return fmt.Errorf("failed to auto-commit: %w", err)
 		}
-
 		return nil
 	}
 
@@ -156,9 +163,20 @@ func (wa *WatcherAdapter) Start() error {
 		IgnorePatterns: wa.config.Watcher.IgnorePatterns,
 		DebounceDelay:  wa.config.Watcher.DebounceDelay,
 		FileExtensions: wa.config.Watcher.FileExtensions,
+		WaitForUnlock:  wa.config.Watcher.WaitForUnlock,
+		Backend:        wa.config.Watcher.Backend,
+		PollInterval:   wa.config.Watcher.PollInterval,
+		PollHash:       wa.config.Watcher.PollHash,
+		MaxConcurrency: wa.config.Watcher.MaxConcurrency,
+	}
+
+	queue, err := watcher.OpenQueue(watcher.QueuePath("."), handler, wa.config.Watcher.RetryAttempts, wa.config.Watcher.RetryDelay, wa.logger)
+	if err != nil {
+		return fmt.Errorf("failed to open event queue: %w", err)
 	}
+	wa.queue = queue
 
-	fw, err := watcher.NewFileWatcher(watcherConfig, handler, wa.logger)
+	fw, err := watcher.NewFileWatcher(watcherConfig, queue.Handle, wa.logger)
 	if err != nil {
 		return fmt.Errorf("failed to create file watcher: %w", err)
 	}
@@ -182,6 +200,8 @@ func (wa *WatcherAdapter) Start() error {
 		return fmt.Errorf("failed to start file watcher: %w", err)
 	}
 
+	queue.Replay(fw.Notify)
+
 	wa.isRunning = true
 	wa.startTime = time.Now()
 	wa.directoriesWatched = len(fw.GetWatchedDirectories())
@@ -204,30 +224,74 @@ func (wa *WatcherAdapter) Stop() error {
 		return fmt.Errorf("watcher is not running")
 	}
 
+	// Stopping waits for conversions in progress, whose changes are then
+	// committed with the rest
+	var stopErr error
 	if wa.watcher != nil {
-		if err := wa.watcher.Stop(); err != nil {
-			return fmt.Errorf("failed to stop watcher: %w", err)
-		}
+		stopErr = wa.watcher.Stop()
+	}
+	if wa.queue != nil {
+		wa.queue.Close()
+	}
+
+	if wa.batcher != nil {
+		// Errors are reported to the TUI by commitChanges
+		_ = wa.batcher.Flush()
+	}
+
+	if stopErr != nil {
+		return fmt.Errorf("failed to stop watcher: %w", stopErr)
 	}
 
 	wa.isRunning = false
-	wa.lastEvent = "Stopped"
-	wa.lastEventTime = time.Now()
+	stopped := time.Now()
+	wa.setLastEvent("Stopped", stopped)
 
 	// Notify TUI
 	if wa.onEvent != nil {
 		wa.onEvent(WatcherEvent{
 			Type:      "stopped",
 			Message:   "Watcher stopped",
-			Timestamp: wa.lastEventTime,
+			Timestamp: stopped,
 		})
 	}
 
 	return nil
 }
 
-// GetStatus returns the current watcher status
+// setLastEvent records the event the status reports last
+func (wa *WatcherAdapter) setLastEvent(event string, at time.Time) {
+	wa.mu.Lock()
+	defer wa.mu.Unlock()
+	wa.lastEvent = event
+	wa.lastEventTime = at
+}
+
+// commitChanges commits a batch of changes and reports failures to the TUI
+func (wa *WatcherAdapter) commitChanges(changes []git.CommitChange) error {
+	err := wa.gitClient.CommitChanges(changes)
+	if err != nil && wa.onEvent != nil {
+		wa.onEvent(WatcherEvent{
+			Type:      "error",
+			Message:   "Git commit failed",
+			Details:   err.Error(),
+			Timestamp: time.Now(),
+			FilePath:  changes[0].Path,
+		})
+	}
+	return err
+}
+
+// GetStatus returns the current watcher status, which is that of the
+// watcher daemon when it runs
 func (wa *WatcherAdapter) GetStatus() WatcherStatus {
+	if !wa.isRunning {
+		if status, ok := wa.daemonStatus(); ok {
+			return status
+		}
+	}
+
+	wa.mu.Lock()
 	status := WatcherStatus{
 		IsRunning:          wa.isRunning,
 		StartTime:          wa.startTime,
@@ -235,10 +299,20 @@ func (wa *WatcherAdapter) GetStatus() WatcherStatus {
 		LastEvent:          wa.lastEvent,
 		LastEventTime:      wa.lastEventTime,
 	}
+	wa.mu.Unlock()
 
 	if wa.watcher != nil {
 		status.Directories = wa.watcher.GetWatchedDirectories()
 		status.DirectoriesWatched = len(status.Directories)
+		status.QueuedEvents, status.ActiveEvents = wa.watcher.QueueDepth()
+	}
+
+	if wa.queue != nil {
+		status.PendingChanges = len(wa.queue.Pending())
+		status.FailedChanges = wa.queue.Failed()
+	} else if queue, err := watcher.ReadQueue(watcher.QueuePath(".")); err == nil {
+		status.PendingChanges = len(queue.Pending)
+		status.FailedChanges = queue.Failed
 	}
 
 	return status
@@ -248,3 +322,69 @@ func (wa *WatcherAdapter) GetStatus() WatcherStatus {
 func (wa *WatcherAdapter) IsRunning() bool {
 	return wa.isRunning
 }
+
+// daemonStatus returns the status reported by the watcher daemon, or false
+// if none is running
+func (wa *WatcherAdapter) daemonStatus() (WatcherStatus, bool) {
+	client, ok := daemon.Running(".")
+	if !ok {
+		return WatcherStatus{}, false
+	}
+	live, err := client.Status()
+	if err != nil {
+		wa.logger.Warnf("Failed to reach the watcher daemon: %v", err)
+		return WatcherStatus{}, false
+	}
+
+	status := WatcherStatus{
+		IsRunning:          true,
+		StartTime:          live.Started,
+		DirectoriesWatched: len(live.Directories),
+		LastEvent:          live.LastEvent,
+		LastEventTime:      live.LastEventTime,
+		Directories:        live.Directories,
+		PendingChanges:     live.PendingChanges,
+		QueuedEvents:       live.QueuedEvents,
+		ActiveEvents:       live.ActiveEvents,
+		Daemon:             true,
+		Paused:             live.Paused,
+	}
+	if queue, err := client.Queue(); err == nil {
+		status.FailedChanges = queue.Failed
+	}
+	return status, true
+}
+
+// StartDaemon starts the watcher daemon for the configured directories
+func (wa *WatcherAdapter) StartDaemon() error {
+	args := []string{"watch", "--daemon"}
+	if wa.config != nil && len(wa.config.Watcher.Directories) > 0 {
+		args = append(args, wa.config.Watcher.Directories...)
+	} else {
+		args = append(args, ".")
+	}
+
+	_, err := daemon.Start(daemon.PathsFor("."), args)
+	return err
+}
+
+// StopDaemon commits pending changes and stops the watcher daemon
+func (wa *WatcherAdapter) StopDaemon() error {
+	_, err := daemon.Stop(daemon.PathsFor("."))
+	return err
+}
+
+// SetDaemonPaused pauses or resumes the watcher daemon
+func (wa *WatcherAdapter) SetDaemonPaused(paused bool) error {
+	client, ok := daemon.Running(".")
+	if !ok {
+		return fmt.Errorf("watcher daemon is not running")
+	}
+	var err error
+	if paused {
+		_, err = client.Pause()
+	} else {
+		_, err = client.Resume()
+	}
+	return err
+}
@@ -3,13 +3,18 @@ package adapter
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Classic-Homes/gitcells/internal/config"
+	"github.com/Classic-Homes/gitcells/internal/daemon"
+	"github.com/Classic-Homes/gitcells/internal/watcher"
+	gogit "github.com/go-git/go-git/v5"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
 )
 
 func TestNewWatcherAdapter(t *testing.T) {
@@ -77,6 +82,80 @@ func TestWatcherAdapter_GetStatus(t *testing.T) {
 		assert.Zero(t, status.DirectoriesWatched)
 		assert.Empty(t, status.LastEvent)
 	})
+
+	t.Run("reports changes left in the event queue", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(tmpDir, ".git"), 0755))
+		require.NoError(t, os.Mkdir(filepath.Join(tmpDir, ".gitcells"), 0755))
+		queue := `{"pending": [{"id": 2, "event": {"path": "b.xlsx", "type": "modify"}}],
+			"failed": [{"id": 1, "event": {"path": "a.xlsx", "type": "create"}, "attempts": 5, "last_error": "disk full"}]}`
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".gitcells", "queue.json"), []byte(queue), 0600))
+
+		originalDir, err := os.Getwd()
+		require.NoError(t, err)
+		defer func() {
+			_ = os.Chdir(originalDir)
+		}()
+		require.NoError(t, os.Chdir(tmpDir))
+
+		adapter, err := NewWatcherAdapter(&config.Config{}, nil, nil)
+		require.NoError(t, err)
+
+		status := adapter.GetStatus()
+		assert.Equal(t, 1, status.PendingChanges)
+		require.Len(t, status.FailedChanges, 1)
+		assert.Equal(t, "a.xlsx", status.FailedChanges[0].Event.Path)
+		assert.Equal(t, "disk full", status.FailedChanges[0].LastError)
+	})
+}
+
+// daemonStub answers the control API like a paused daemon
+type daemonStub struct{}
+
+func (daemonStub) Status() daemon.Status {
+	return daemon.Status{PID: os.Getpid(), Paused: true, Directories: []string{"reports"}, PendingChanges: 2, QueuedEvents: 3, ActiveEvents: 1}
+}
+func (daemonStub) Pause()             {}
+func (daemonStub) Resume()            {}
+func (daemonStub) Sync() (int, error) { return 0, nil }
+func (daemonStub) Queue() watcher.QueueState {
+	return watcher.QueueState{Failed: []watcher.QueueItem{{Event: watcher.FileEvent{Path: "a.xlsx"}}}}
+}
+func (daemonStub) ClearFailed() (int, error) { return 0, nil }
+func (daemonStub) Shutdown()                 {}
+
+func TestWatcherAdapter_DaemonStatus(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, ".git"), 0755))
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	require.NoError(t, os.Chdir(tmpDir))
+
+	paths := daemon.PathsFor(".")
+	require.NoError(t, daemon.WritePID(paths))
+	server, err := daemon.Serve(paths.Socket, daemonStub{}, logrus.New())
+	require.NoError(t, err)
+	defer server.Close()
+
+	adapter, err := NewWatcherAdapter(&config.Config{}, nil, nil)
+	require.NoError(t, err)
+
+	status := adapter.GetStatus()
+	assert.True(t, status.IsRunning)
+	assert.True(t, status.Daemon)
+	assert.True(t, status.Paused)
+	assert.Equal(t, []string{"reports"}, status.Directories)
+	assert.Equal(t, 2, status.PendingChanges)
+	assert.Equal(t, 3, status.QueuedEvents)
+	assert.Equal(t, 1, status.ActiveEvents)
+	assert.Len(t, status.FailedChanges, 1)
+
+	// The daemon already watches the repository
+	assert.Error(t, adapter.Start())
 }
 
 func TestWatcherAdapter_IsRunning(t *testing.T) {
@@ -188,6 +267,48 @@ func TestWatcherAdapter_StartStop(t *testing.T) {
 		// Should have received start and stop events
 		assert.GreaterOrEqual(t, len(events), 2)
 	})
+
+	t.Run("reports the last event while handling events", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		_, err := gogit.PlainInit(tmpDir, false)
+		require.NoError(t, err)
+
+		originalDir, err := os.Getwd()
+		require.NoError(t, err)
+		defer func() {
+			_ = os.Chdir(originalDir)
+		}()
+		require.NoError(t, os.Chdir(tmpDir))
+
+		cfg := &config.Config{
+			Watcher: config.WatcherConfig{
+				Directories:    []string{tmpDir},
+				DebounceDelay:  50 * time.Millisecond,
+				FileExtensions: []string{".xlsx"},
+			},
+			Git: config.GitConfig{
+				UserName:  "Test User",
+				UserEmail: "test@example.com",
+			},
+		}
+		logger := logrus.New()
+		logger.SetLevel(logrus.ErrorLevel)
+
+		adapter, err := NewWatcherAdapter(cfg, logger, nil)
+		require.NoError(t, err)
+		require.NoError(t, adapter.Start())
+		defer func() { _ = adapter.Stop() }()
+
+		f := excelize.NewFile()
+		require.NoError(t, f.SetCellValue("Sheet1", "A1", "hello"))
+		require.NoError(t, f.SaveAs(filepath.Join(tmpDir, "book.xlsx")))
+		require.NoError(t, f.Close())
+
+		// The TUI polls the status while the watcher handles the event
+		assert.Eventually(t, func() bool {
+			return strings.Contains(adapter.GetStatus().LastEvent, "book.xlsx")
+		}, 10*time.Second, 10*time.Millisecond)
+	})
 }
 
 func TestWatcherEvent(t *testing.T) {
@@ -10,8 +10,6 @@ import (
 	"github.com/Classic-Homes/gitcells/internal/tui/adapter"
 	"github.com/Classic-Homes/gitcells/internal/tui/messages"
 	"github.com/Classic-Homes/gitcells/internal/tui/styles"
-
-	// "github.com/Classic-Homes/gitcells/internal/watcher"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -34,7 +32,8 @@ type UnifiedDashboardModel struct {
 	config      *config.Config
 	gitAdapter  *adapter.GitAdapter
 	convAdapter *adapter.ConverterAdapter
-	// watcher     *watcher.Watcher
+	// watcherAdapter controls the watcher daemon
+	watcherAdapter *adapter.WatcherAdapter
 
 	// State
 	activeTab    DashboardTab
@@ -56,11 +55,14 @@ type SyncStatus struct {
 }
 
 type WatcherState struct {
-	IsRunning     bool
-	StartTime     time.Time
-	FilesWatched  int
-	LastEvent     string
-	LastEventTime time.Time
+	IsRunning          bool
+	Paused             bool
+	StartTime          time.Time
+	DirectoriesWatched int
+	PendingChanges     int
+	FailedChanges      int
+	LastEvent          string
+	LastEventTime      time.Time
 }
 
 type FileInfo struct {
@@ -83,12 +85,14 @@ func NewDashboardModel() *UnifiedDashboardModel {
 	cfg, _ := config.Load("")
 	gitAdapter, _ := adapter.NewGitAdapter("")
 	convAdapter := adapter.NewConverterAdapter()
+	watcherAdapter, _ := adapter.NewWatcherAdapter(cfg, nil, nil)
 
 	return &UnifiedDashboardModel{
-		config:      cfg,
-		gitAdapter:  gitAdapter,
-		convAdapter: convAdapter,
-		activeTab:   TabOverview,
+		config:         cfg,
+		gitAdapter:     gitAdapter,
+		convAdapter:    convAdapter,
+		watcherAdapter: watcherAdapter,
+		activeTab:      TabOverview,
 	}
 }
 
@@ -103,6 +107,13 @@ func (m *UnifiedDashboardModel) Init() tea.Cmd {
 
 type tickMsg time.Time
 
+// watcherStateMsg carries the state of the watcher daemon, and the error of
+// the action that changed it
+type watcherStateMsg struct {
+	state WatcherState
+	err   error
+}
+
 func (m *UnifiedDashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -141,6 +152,11 @@ func (m *UnifiedDashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.toggleWatcher()
 			}
 
+		case "p":
+			if !m.showQuickAction && m.watcherState.IsRunning {
+				return m, m.togglePause()
+			}
+
 		case "c":
 			if !m.showQuickAction {
 				m.showQuickAction = true
@@ -180,6 +196,17 @@ func (m *UnifiedDashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.handleSelection()
 		}
 
+	case watcherStateMsg:
+		m.watcherState = msg.state
+		if msg.err != nil {
+			m.activities = append([]Activity{{
+				Time:    time.Now(),
+				Type:    "error",
+				Message: "Watcher: " + msg.err.Error(),
+			}}, m.activities...)
+		}
+		return m, nil
+
 	case tickMsg:
 		// Auto-refresh data
 		return m, tea.Batch(
@@ -270,6 +297,8 @@ func (m *UnifiedDashboardModel) renderHeader() string {
 	watchIcon := "👁"
 	if !m.watcherState.IsRunning {
 		watchIcon = "💤"
+	} else if m.watcherState.Paused {
+		watchIcon = "⏸"
 	}
 	status = append(status, fmt.Sprintf("%s Watch", watchIcon))
 
@@ -323,7 +352,7 @@ func (m *UnifiedDashboardModel) renderOverview() string {
 
 	// Quick actions hint
 	content = append(content, styles.SubtitleStyle.Render("⚡ Quick Actions"))
-	content = append(content, "  Press 'w' to toggle watcher")
+	content = append(content, "  Press 'w' to start or stop the background watcher")
 	content = append(content, "  Press 'c' to convert a file")
 	content = append(content, "  Press 'd' for diff viewer")
 
@@ -419,6 +448,11 @@ func (m *UnifiedDashboardModel) renderActionBar() string {
 
 	if m.watcherState.IsRunning {
 		actions[0] = "[w] Stop Watch"
+		if m.watcherState.Paused {
+			actions = append(actions, "[p] Resume")
+		} else {
+			actions = append(actions, "[p] Pause")
+		}
 	}
 
 	actionStr := strings.Join(actions, " • ")
@@ -448,17 +482,56 @@ func (m *UnifiedDashboardModel) renderActionBar() string {
 func (m *UnifiedDashboardModel) loadDashboardData() tea.Cmd {
 	return func() tea.Msg {
 		// Load data from adapters
-		// This would be implemented to actually fetch data
-		return nil
+		// Only the watcher state is fetched so far
+		return watcherStateMsg{state: m.loadWatcherState()}
+	}
+}
+
+// loadWatcherState reads the state of the watcher daemon through its
+// control API
+func (m *UnifiedDashboardModel) loadWatcherState() WatcherState {
+	if m.watcherAdapter == nil {
+		return WatcherState{}
+	}
+	status := m.watcherAdapter.GetStatus()
+	if !status.Daemon {
+		return WatcherState{PendingChanges: status.PendingChanges, FailedChanges: len(status.FailedChanges)}
+	}
+	return WatcherState{
+		IsRunning:          true,
+		Paused:             status.Paused,
+		StartTime:          status.StartTime,
+		DirectoriesWatched: status.DirectoriesWatched,
+		PendingChanges:     status.PendingChanges,
+		FailedChanges:      len(status.FailedChanges),
+		LastEvent:          status.LastEvent,
+		LastEventTime:      status.LastEventTime,
 	}
 }
 
+// toggleWatcher starts or stops the watcher daemon
 func (m *UnifiedDashboardModel) toggleWatcher() tea.Cmd {
+	running := m.watcherState.IsRunning
+	return func() tea.Msg {
+		if m.watcherAdapter == nil {
+			return watcherStateMsg{err: fmt.Errorf("watcher is not available")}
+		}
+		var err error
+		if running {
+			err = m.watcherAdapter.StopDaemon()
+		} else {
+			err = m.watcherAdapter.StartDaemon()
+		}
+		return watcherStateMsg{state: m.loadWatcherState(), err: err}
+	}
+}
+
+// togglePause pauses or resumes the watcher daemon
+func (m *UnifiedDashboardModel) togglePause() tea.Cmd {
+	paused := m.watcherState.Paused
 	return func() tea.Msg {
-		// Toggle watcher state
-		// TODO: Implement actual watcher start/stop logic
-		m.watcherState.IsRunning = !m.watcherState.IsRunning
-		return nil
+		err := m.watcherAdapter.SetDaemonPaused(!paused)
+		return watcherStateMsg{state: m.loadWatcherState(), err: err}
 	}
 }
 
@@ -500,8 +573,14 @@ func (s SyncStatus) getStatusText() string {
 }
 
 func (w WatcherState) getStatusText() string {
-	if w.IsRunning {
-		return fmt.Sprintf("Running (%d files)", w.FilesWatched)
+	queue := fmt.Sprintf("%d pending, %d failed", w.PendingChanges, w.FailedChanges)
+	switch {
+	case w.Paused:
+		return fmt.Sprintf("Paused (%d directories, %s)", w.DirectoriesWatched, queue)
+	case w.IsRunning:
+		return fmt.Sprintf("Running (%d directories, %s)", w.DirectoriesWatched, queue)
+	case w.FailedChanges > 0:
+		return fmt.Sprintf("Stopped (%d failed)", w.FailedChanges)
 	}
 	return "Stopped"
 }
@@ -289,10 +289,12 @@ func (m *SetupModel) renderGitStep() string {
 	if m.focused == 2 { // Commit template is focused
 		templateHelp = styles.MutedStyle.Render(`
 Available placeholders:
-  {action}    - The action performed (add, update, delete)
-  {filename}  - The Excel filename
-  {timestamp} - Current timestamp
-  {user}      - Git user name`)
+  {action}         - The action performed (create, modify, delete)
+  {filename}       - The Excel filename
+  {timestamp}      - Current timestamp
+  {sheets_changed} - Number of sheets changed
+  {cells_changed}  - Number of cells changed
+  {user}           - Git user name`)
 	}
 
 	content := []string{
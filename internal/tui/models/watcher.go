@@ -24,6 +24,7 @@ type WatcherModel struct {
 
 	// Watcher state
 	watcher   *watcher.FileWatcher
+	queue     *watcher.Queue
 	isRunning bool
 	startTime time.Time
 	events    []WatcherEvent
@@ -40,6 +41,10 @@ type WatcherModel struct {
 	totalFiles  int
 	lastEvent   time.Time
 
+	// Changes waiting to be processed, and those that failed every retry
+	pendingChanges int
+	failedChanges  []watcher.QueueItem
+
 	// Background context
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -58,9 +63,11 @@ type watcherEventMsg struct {
 }
 
 type watcherStatusMsg struct {
-	isRunning   bool
-	watchedDirs []string
-	totalFiles  int
+	isRunning      bool
+	watchedDirs    []string
+	totalFiles     int
+	pendingChanges int
+	failedChanges  []watcher.QueueItem
 }
 
 func NewWatcherModel() tea.Model {
@@ -114,6 +121,8 @@ func (m WatcherModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.isRunning = msg.isRunning
 		m.watchedDirs = msg.watchedDirs
 		m.totalFiles = msg.totalFiles
+		m.pendingChanges = msg.pendingChanges
+		m.failedChanges = msg.failedChanges
 
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -288,7 +297,19 @@ func (m WatcherModel) renderStatus() string {
 			fmt.Sprintf("Last: %s", lastActivity),
 	)
 
-	return lipgloss.JoinHorizontal(lipgloss.Top, dirsBox, configBox, activityBox)
+	// Queue box
+	failedStyle := lipgloss.NewStyle()
+	if len(m.failedChanges) > 0 {
+		failedStyle = failedStyle.Foreground(styles.Error)
+	}
+
+	queueBox := boxStyle.Render(
+		styles.SubtitleStyle.Render("Queue") + "\n" +
+			fmt.Sprintf("Pending: %d", m.pendingChanges) + "\n" +
+			failedStyle.Render(fmt.Sprintf("Failed: %d", len(m.failedChanges))),
+	)
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, dirsBox, configBox, activityBox, queueBox)
 }
 
 func (m WatcherModel) renderEvents() string {
@@ -296,7 +317,24 @@ func (m WatcherModel) renderEvents() string {
 		Width(m.width - 8).
 		Height(m.height - 15)
 
-	content := styles.SubtitleStyle.Render("Recent Events") + "\n\n"
+	content := ""
+
+	// Changes that failed every retry stay listed until they succeed
+	if len(m.failedChanges) > 0 {
+		content += styles.SubtitleStyle.Render("Failed Changes") + "\n\n"
+		errorStyle := lipgloss.NewStyle().Foreground(styles.Error)
+		for _, item := range m.failedChanges {
+			content += fmt.Sprintf("%s ❌ %s %s - %s\n",
+				styles.MutedStyle.Render(item.FailedAt.Format("15:04:05")),
+				styles.SubtitleStyle.Render(item.Event.Type.String()),
+				filepath.Base(item.Event.Path),
+				errorStyle.Render(fmt.Sprintf("failed %d times: %s", item.Attempts, item.LastError)),
+			)
+		}
+		content += "\n"
+	}
+
+	content += styles.SubtitleStyle.Render("Recent Events") + "\n\n"
 
 	if len(m.events) == 0 {
 		content += styles.MutedStyle.Render("No events yet...")
@@ -418,7 +456,8 @@ func (m WatcherModel) renderHelp() string {
 				"configured directories and automatically converts them\n" +
 				"to JSON format when changes are detected. Events are\n" +
 				"debounced to avoid excessive processing during rapid\n" +
-				"file changes.\n\n" +
+				"file changes. Failed conversions are retried, and those\n" +
+				"that keep failing are listed under Failed Changes.\n\n" +
 				"Configuration is loaded from .gitcells/config.yaml\n" +
 				"in your project directory.\n\n" +
 				"Press ? to close this help",
@@ -443,11 +482,21 @@ func (m *WatcherModel) loadWatcherStatus() tea.Cmd {
 			}
 		}
 
-		return watcherStatusMsg{
+		status := watcherStatusMsg{
 			isRunning:   m.isRunning,
 			watchedDirs: m.watchedDirs,
 			totalFiles:  totalFiles,
 		}
+
+		if m.queue != nil {
+			status.pendingChanges = len(m.queue.Pending())
+			status.failedChanges = m.queue.Failed()
+		} else if queue, err := watcher.ReadQueue(watcher.QueuePath(".")); err == nil {
+			status.pendingChanges = len(queue.Pending)
+			status.failedChanges = queue.Failed
+		}
+
+		return status
 	}
 }
 
@@ -483,6 +532,12 @@ func (m *WatcherModel) startWatcher() tea.Cmd {
 
 		// Create event handler
 		handler := func(event watcher.FileEvent) error {
+			// A deleted workbook has nothing left to convert
+			if event.Type == watcher.EventTypeDelete {
+				m.logger.Infof("Workbook deleted: %s", event.Path)
+				return nil
+			}
+
 			// Convert Excel to JSON
 			conv := converter.NewConverter(m.logger)
 			convertOptions := converter.ConvertOptions{
@@ -511,9 +566,28 @@ func (m *WatcherModel) startWatcher() tea.Cmd {
 			IgnorePatterns: m.config.Watcher.IgnorePatterns,
 			DebounceDelay:  m.config.Watcher.DebounceDelay,
 			FileExtensions: m.config.Watcher.FileExtensions,
+			WaitForUnlock:  m.config.Watcher.WaitForUnlock,
+			Backend:        m.config.Watcher.Backend,
+			PollInterval:   m.config.Watcher.PollInterval,
+			PollHash:       m.config.Watcher.PollHash,
+			MaxConcurrency: m.config.Watcher.MaxConcurrency,
+		}
+
+		// Failed conversions are retried and kept in the queue file
+		queue, err := watcher.OpenQueue(watcher.QueuePath("."), handler, m.config.Watcher.RetryAttempts, m.config.Watcher.RetryDelay, m.logger)
+		if err != nil {
+			return watcherEventMsg{
+				event: WatcherEvent{
+					Path:      "queue",
+					Type:      "error",
+					Timestamp: time.Now(),
+					Status:    "failed",
+					Error:     err,
+				},
+			}
 		}
 
-		fw, err := watcher.NewFileWatcher(watcherConfig, handler, m.logger)
+		fw, err := watcher.NewFileWatcher(watcherConfig, queue.Handle, m.logger)
 		if err != nil {
 			return watcherEventMsg{
 				event: WatcherEvent{
@@ -546,7 +620,10 @@ func (m *WatcherModel) startWatcher() tea.Cmd {
 			}
 		}
 
+		queue.Replay(fw.Notify)
+
 		m.watcher = fw
+		m.queue = queue
 		m.startTime = time.Now()
 
 		return watcherEventMsg{
@@ -564,7 +641,11 @@ func (m *WatcherModel) stopWatcher() tea.Cmd {
 	return func() tea.Msg {
 		if m.watcher != nil {
 			err := m.watcher.Stop()
+			if m.queue != nil {
+				m.queue.Close()
+			}
 			m.watcher = nil
+			m.queue = nil
 
 			if err != nil {
 				return watcherEventMsg{
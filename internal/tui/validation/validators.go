@@ -116,7 +116,7 @@ func ValidateCommitTemplate(template string) error {
 	}
 
 	// Check for required placeholders
-	validPlaceholders := []string{"{action}", "{filename}", "{timestamp}", "{user}"}
+	validPlaceholders := []string{"{action}", "{filename}", "{timestamp}", "{sheets_changed}", "{cells_changed}", "{user}"}
 	hasPlaceholder := false
 
 	for _, placeholder := range validPlaceholders {
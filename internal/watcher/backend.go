@@ -0,0 +1,185 @@
+package watcher
+
+import (
+	"sync"
+
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// Watcher backends
+const (
+	// BackendFSNotify uses the operating system's file change notifications
+	BackendFSNotify = "fsnotify"
+	// BackendPoll scans the watched directories at an interval, for network
+	// shares and mounts that deliver no notifications
+	BackendPoll = "poll"
+	// BackendAuto polls directories on network file systems and uses
+	// notifications for the rest
+	BackendAuto = "auto"
+)
+
+// Backend reports changes to the files in the directories added to it. Each
+// directory is watched on its own, without its subdirectories.
+type Backend interface {
+	Add(path string) error
+	Remove(path string) error
+	Events() <-chan fsnotify.Event
+	Errors() <-chan error
+	Close() error
+}
+
+// newBackend creates the backend selected in the config. shouldHash picks
+// the files a polling backend hashes when PollHash is set.
+func newBackend(config *Config, shouldHash func(path string) bool, logger *logrus.Logger) (Backend, error) {
+	switch config.Backend {
+	case "", BackendFSNotify:
+		return newFSNotifyBackend()
+	case BackendPoll:
+		return newPollBackend(config.PollInterval, config.PollHash, shouldHash), nil
+	case BackendAuto:
+		return newAutoBackend(config, shouldHash, logger), nil
+	default:
+		return nil, utils.NewError(utils.ErrorTypeValidation, "NewFileWatcher", "unsupported watcher backend: "+config.Backend)
+	}
+}
+
+// fsnotifyBackend watches directories with fsnotify
+type fsnotifyBackend struct {
+	watcher *fsnotify.Watcher
+}
+
+func newFSNotifyBackend() (*fsnotifyBackend, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, utils.WrapError(err, utils.ErrorTypeWatcher, "NewFileWatcher", "failed to create fsnotify watcher")
+	}
+	return &fsnotifyBackend{watcher: watcher}, nil
+}
+
+func (b *fsnotifyBackend) Add(path string) error         { return b.watcher.Add(path) }
+func (b *fsnotifyBackend) Remove(path string) error      { return b.watcher.Remove(path) }
+func (b *fsnotifyBackend) Events() <-chan fsnotify.Event { return b.watcher.Events }
+func (b *fsnotifyBackend) Errors() <-chan error          { return b.watcher.Errors }
+func (b *fsnotifyBackend) Close() error                  { return b.watcher.Close() }
+
+// autoBackend sends each directory to fsnotify or, if it is on a network
+// file system or fsnotify is unavailable, to a polling backend
+type autoBackend struct {
+	native *fsnotifyBackend
+	poll   *pollBackend
+	logger *logrus.Logger
+
+	mu     sync.Mutex
+	polled map[string]bool
+
+	events chan fsnotify.Event
+	errors chan error
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newAutoBackend(config *Config, shouldHash func(path string) bool, logger *logrus.Logger) *autoBackend {
+	b := &autoBackend{
+		poll:   newPollBackend(config.PollInterval, config.PollHash, shouldHash),
+		logger: logger,
+		polled: make(map[string]bool),
+		events: make(chan fsnotify.Event),
+		errors: make(chan error),
+		done:   make(chan struct{}),
+	}
+
+	native, err := newFSNotifyBackend()
+	if err != nil {
+		logger.Warnf("File notifications are unavailable, polling instead: %v", err)
+	} else {
+		b.native = native
+	}
+
+	var wg sync.WaitGroup
+	forward := func(events <-chan fsnotify.Event, errors <-chan error) {
+		defer wg.Done()
+		for events != nil || errors != nil {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					events = nil
+					continue
+				}
+				select {
+				case b.events <- event:
+				case <-b.done:
+					return
+				}
+			case err, ok := <-errors:
+				if !ok {
+					errors = nil
+					continue
+				}
+				select {
+				case b.errors <- err:
+				case <-b.done:
+					return
+				}
+			case <-b.done:
+				return
+			}
+		}
+	}
+
+	wg.Add(1)
+	go forward(b.poll.Events(), b.poll.Errors())
+	if b.native != nil {
+		wg.Add(1)
+		go forward(b.native.Events(), b.native.Errors())
+	}
+	go func() {
+		wg.Wait()
+		close(b.events)
+		close(b.errors)
+	}()
+
+	return b
+}
+
+func (b *autoBackend) Add(path string) error {
+	if b.native != nil && !isNetworkFileSystem(path) {
+		return b.native.Add(path)
+	}
+
+	b.mu.Lock()
+	b.polled[path] = true
+	b.mu.Unlock()
+	b.logger.Debugf("Polling directory: %s", path)
+	return b.poll.Add(path)
+}
+
+func (b *autoBackend) Remove(path string) error {
+	b.mu.Lock()
+	polled := b.polled[path]
+	delete(b.polled, path)
+	b.mu.Unlock()
+
+	if polled || b.native == nil {
+		return b.poll.Remove(path)
+	}
+	return b.native.Remove(path)
+}
+
+func (b *autoBackend) Events() <-chan fsnotify.Event { return b.events }
+func (b *autoBackend) Errors() <-chan error          { return b.errors }
+
+func (b *autoBackend) Close() error {
+	var err error
+	b.once.Do(func() {
+		close(b.done)
+		err = b.poll.Close()
+		if b.native != nil {
+			if nativeErr := b.native.Close(); nativeErr != nil {
+				err = nativeErr
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,115 @@
+package watcher
+
+import (
+	"path/filepath"
+
+	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/Classic-Homes/gitcells/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// UpdateChunks brings the chunks of the workbook an event is about up to
+// date and describes the change for its commit. The chunks of a deleted
+// workbook are removed, or moved to .gitcells/archive if archive is set,
+// and those of a renamed one are moved before it is converted. A change
+// with no Files and no Removed has nothing to commit.
+func UpdateChunks(conv converter.Converter, event FileEvent, options converter.ConvertOptions, archive bool, logger *logrus.Logger) (git.CommitChange, error) {
+	if event.Type == EventTypeDelete {
+		return removeChunks(event, archive)
+	}
+
+	// Move the chunks of a renamed workbook before converting it
+	var removed []string
+	if event.Type == EventTypeRename {
+		oldDir, _, err := converter.MoveChunks(event.OldPath, event.Path)
+		if err != nil {
+			return git.CommitChange{Path: event.Path, Action: event.Type.String()}, err
+		}
+		if oldDir != "" {
+			removed = []string{oldDir}
+		}
+	}
+
+	change, err := convertForCommit(conv, event, options)
+	if err != nil {
+		return change, err
+	}
+	change.Removed = removed
+	if len(removed) > 0 {
+		change.OldPath = event.OldPath
+	}
+
+	// Stage the whole chunk directory so that removed sheets and the chunk
+	// metadata are committed too
+	chunkPaths, err := conv.GetChunkPaths(event.Path)
+	if err != nil || len(chunkPaths) == 0 {
+		logger.Warnf("Failed to get chunk paths for git commit: %v", err)
+		// Fall back to committing the entire .gitcells/data directory
+		change.Files = []string{filepath.Join(".gitcells", "data")}
+	} else {
+		change.Files = []string{filepath.Dir(chunkPaths[0])}
+	}
+	return change, nil
+}
+
+// removeChunks removes the chunks of a deleted workbook, or moves them to
+// .gitcells/archive, and describes the change for its commit. The change
+// has no removed paths if the workbook had no chunks.
+func removeChunks(event FileEvent, archive bool) (git.CommitChange, error) {
+	change := git.CommitChange{
+		Path:   event.Path,
+		Action: event.Type.String(),
+	}
+
+	if archive {
+		chunkDir, archiveDir, err := converter.ArchiveChunks(event.Path)
+		if err != nil || chunkDir == "" {
+			return change, err
+		}
+		change.Removed = []string{chunkDir}
+		change.Files = []string{archiveDir}
+		return change, nil
+	}
+
+	chunkDir, err := converter.RemoveChunks(event.Path)
+	if err != nil || chunkDir == "" {
+		return change, err
+	}
+	change.Removed = []string{chunkDir}
+	return change, nil
+}
+
+// convertForCommit converts a changed workbook to chunks and describes the
+// change for its commit, diffing against the previously converted version
+func convertForCommit(conv converter.Converter, event FileEvent, options converter.ConvertOptions) (git.CommitChange, error) {
+	change := git.CommitChange{
+		Path:   event.Path,
+		Action: event.Type.String(),
+	}
+
+	// A workbook that was never converted has no chunks yet. Copying a file
+	// in usually ends with a write event, so report it as created. One that
+	// has chunks was replaced on save, as LibreOffice does, and is modified.
+	oldDoc, err := conv.ReadChunks(event.Path)
+	if err != nil {
+		oldDoc = &models.ExcelDocument{}
+		change.Action = EventTypeCreate.String()
+	} else if event.Type == EventTypeCreate {
+		change.Action = EventTypeModify.String()
+	}
+
+	// The converter will automatically save to .gitcells/data directory
+	newDoc, err := conv.ExcelToJSON(event.Path, options)
+	if err != nil {
+		return change, utils.WrapFileError(err, utils.ErrorTypeConverter, "watch", event.Path, "failed to convert Excel to JSON")
+	}
+	if _, err := conv.WriteChunks(newDoc, event.Path, options); err != nil {
+		return change, utils.WrapFileError(err, utils.ErrorTypeConverter, "watch", event.Path, "failed to write chunks")
+	}
+
+	change.Diff = models.ComputeDiff(oldDoc, newDoc)
+	change.ModifiedBy = newDoc.Properties.LastModifiedBy
+	return change, nil
+}
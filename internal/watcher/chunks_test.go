@@ -0,0 +1,63 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Classic-Homes/gitcells/internal/converter"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestUpdateChunks(t *testing.T) {
+	dir := t.TempDir()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	conv := converter.NewConverter(logger)
+	options := converter.ConvertOptions{PreserveFormulas: true, ChunkingStrategy: "sheet-based"}
+
+	workbook := filepath.Join(dir, "a.xlsx")
+	f := excelize.NewFile()
+	require.NoError(t, f.SetCellValue("Sheet1", "A1", "hello"))
+	require.NoError(t, f.SaveAs(workbook))
+	require.NoError(t, f.Close())
+
+	// A workbook without chunks is created, whatever the event said
+	change, err := UpdateChunks(conv, FileEvent{Path: workbook, Type: EventTypeModify}, options, false, logger)
+	require.NoError(t, err)
+	assert.Equal(t, "create", change.Action)
+	assert.Equal(t, []string{converter.ChunkDir(workbook)}, change.Files)
+	assert.Empty(t, change.Removed)
+	assert.NotNil(t, change.Diff)
+
+	// The chunks of a renamed workbook move with it
+	renamed := filepath.Join(dir, "b.xlsx")
+	require.NoError(t, os.Rename(workbook, renamed))
+	oldDir := converter.ChunkDir(workbook)
+	change, err = UpdateChunks(conv, FileEvent{Path: renamed, OldPath: workbook, Type: EventTypeRename}, options, false, logger)
+	require.NoError(t, err)
+	assert.Equal(t, "rename", change.Action)
+	assert.Equal(t, []string{oldDir}, change.Removed)
+	assert.Equal(t, workbook, change.OldPath)
+	assert.Equal(t, []string{converter.ChunkDir(renamed)}, change.Files)
+	assert.NoDirExists(t, oldDir)
+
+	// Deleting archives the chunks if asked to
+	require.NoError(t, os.Remove(renamed))
+	chunkDir := converter.ChunkDir(renamed)
+	change, err = UpdateChunks(conv, FileEvent{Path: renamed, Type: EventTypeDelete}, options, true, logger)
+	require.NoError(t, err)
+	assert.Equal(t, []string{chunkDir}, change.Removed)
+	require.Len(t, change.Files, 1)
+	assert.DirExists(t, change.Files[0])
+	assert.NoDirExists(t, chunkDir)
+
+	// A workbook that had no chunks leaves nothing to commit
+	change, err = UpdateChunks(conv, FileEvent{Path: filepath.Join(dir, "c.xlsx"), Type: EventTypeDelete}, options, false, logger)
+	require.NoError(t, err)
+	assert.Empty(t, change.Files)
+	assert.Empty(t, change.Removed)
+}
@@ -0,0 +1,31 @@
+package watcher
+
+import "syscall"
+
+// Names of the file systems that deliver no events for changes made by
+// other machines
+var networkFileSystems = map[string]bool{
+	"nfs":     true,
+	"smbfs":   true,
+	"afpfs":   true,
+	"webdav":  true,
+	"macfuse": true,
+	"osxfuse": true,
+}
+
+// isNetworkFileSystem reports whether path is on a network file system
+func isNetworkFileSystem(path string) bool {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return false
+	}
+
+	name := make([]byte, 0, len(stat.Fstypename))
+	for _, c := range stat.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	return networkFileSystems[string(name)]
+}
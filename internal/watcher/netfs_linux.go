@@ -0,0 +1,25 @@
+package watcher
+
+import "syscall"
+
+// Magic numbers of the file systems that deliver no inotify events for
+// changes made by other machines
+var networkFileSystems = map[uint32]bool{
+	0x6969:     true, // NFS
+	0x517B:     true, // SMB
+	0xFF534D42: true, // CIFS
+	0xFE534D42: true, // SMB2
+	0x65735546: true, // FUSE, including sshfs and container file sharing
+	0x01021997: true, // 9P, as used by WSL and some container runtimes
+	0x00C36400: true, // Ceph
+	0x5346414F: true, // AFS
+}
+
+// isNetworkFileSystem reports whether path is on a network file system
+func isNetworkFileSystem(path string) bool {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return false
+	}
+	return networkFileSystems[uint32(stat.Type)]
+}
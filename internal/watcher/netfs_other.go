@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package watcher
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// isNetworkFileSystem reports whether path is on a network file system.
+// Only UNC paths such as \\server\share are recognised.
+func isNetworkFileSystem(path string) bool {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	return strings.HasPrefix(path, `\\`)
+}
@@ -0,0 +1,278 @@
+package watcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultPollInterval is how often a polling backend scans its directories
+// when no interval is configured
+const DefaultPollInterval = 2 * time.Second
+
+// pollBackend finds changes by comparing the size and modification time,
+// and optionally a hash of the contents, of each file between scans. It
+// reports them as the fsnotify events a native watcher would send; a file
+// that disappeared is reported as renamed, so the watcher can pair it with a
+// file that appeared in the same scan.
+type pollBackend struct {
+	interval   time.Duration
+	hash       bool
+	shouldHash func(path string) bool
+
+	mu   sync.Mutex
+	dirs map[string]map[string]fileState
+
+	events chan fsnotify.Event
+	errors chan error
+	done   chan struct{}
+	once   sync.Once
+}
+
+// fileState is what a scan records about a file
+type fileState struct {
+	size    int64
+	modTime time.Time
+	isDir   bool
+	hash    string
+}
+
+func newPollBackend(interval time.Duration, hash bool, shouldHash func(path string) bool) *pollBackend {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	b := &pollBackend{
+		interval:   interval,
+		hash:       hash,
+		shouldHash: shouldHash,
+		dirs:       make(map[string]map[string]fileState),
+		events:     make(chan fsnotify.Event, 100),
+		errors:     make(chan error, 10),
+		done:       make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Add starts polling a directory. Its current files are recorded without
+// being reported.
+func (b *pollBackend) Add(path string) error {
+	b.mu.Lock()
+	_, watched := b.dirs[path]
+	b.mu.Unlock()
+	if watched {
+		return nil
+	}
+
+	files, err := b.scan(path)
+	if err != nil {
+		return err
+	}
+	if files == nil {
+		return utils.WrapFileError(os.ErrNotExist, utils.ErrorTypeWatcher, "poll", path, "directory does not exist")
+	}
+
+	b.mu.Lock()
+	b.dirs[path] = files
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *pollBackend) Remove(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, watched := b.dirs[path]; !watched {
+		return utils.NewError(utils.ErrorTypeWatcher, "Remove", "directory is not being polled: "+path)
+	}
+	delete(b.dirs, path)
+	return nil
+}
+
+func (b *pollBackend) Events() <-chan fsnotify.Event { return b.events }
+func (b *pollBackend) Errors() <-chan error          { return b.errors }
+
+func (b *pollBackend) Close() error {
+	b.once.Do(func() { close(b.done) })
+	return nil
+}
+
+func (b *pollBackend) run() {
+	defer close(b.events)
+	defer close(b.errors)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			if !b.poll() {
+				return
+			}
+		}
+	}
+}
+
+// poll scans every directory once and reports what changed. It returns
+// false if the backend was closed meanwhile.
+func (b *pollBackend) poll() bool {
+	b.mu.Lock()
+	dirs := make([]string, 0, len(b.dirs))
+	for dir := range b.dirs {
+		dirs = append(dirs, dir)
+	}
+	b.mu.Unlock()
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		files, err := b.scan(dir)
+		if err != nil {
+			if !b.sendError(err) {
+				return false
+			}
+			continue
+		}
+
+		b.mu.Lock()
+		previous, watched := b.dirs[dir]
+		if watched {
+			if files == nil {
+				// The directory itself is gone, like its files
+				delete(b.dirs, dir)
+			} else {
+				b.dirs[dir] = files
+			}
+		}
+		b.mu.Unlock()
+		if !watched {
+			continue
+		}
+
+		for _, event := range diffScans(dir, previous, files) {
+			if !b.send(event) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// diffScans lists the events that turn one scan of a directory into the
+// next. Disappearances come first so renames can be paired up.
+func diffScans(dir string, previous, current map[string]fileState) []fsnotify.Event {
+	var gone, appeared, changed []string
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			gone = append(gone, name)
+		}
+	}
+	for name, state := range current {
+		old, ok := previous[name]
+		switch {
+		case !ok:
+			appeared = append(appeared, name)
+		case state.isDir || old.isDir:
+			// Directories are reported only when they come or go
+		case state.hash != "" || old.hash != "":
+			if state.hash != old.hash {
+				changed = append(changed, name)
+			}
+		case state.size != old.size || !state.modTime.Equal(old.modTime):
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(gone)
+	sort.Strings(appeared)
+	sort.Strings(changed)
+
+	var events []fsnotify.Event
+	for _, name := range gone {
+		op := fsnotify.Rename
+		if previous[name].isDir {
+			op = fsnotify.Remove
+		}
+		events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: op})
+	}
+	for _, name := range appeared {
+		events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Create})
+	}
+	for _, name := range changed {
+		events = append(events, fsnotify.Event{Name: filepath.Join(dir, name), Op: fsnotify.Write})
+	}
+	return events
+}
+
+// scan records the files in a directory. A directory that no longer exists
+// has no files, which is reported as a nil map.
+func (b *pollBackend) scan(dir string) (map[string]fileState, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, utils.WrapFileError(err, utils.ErrorTypeWatcher, "poll", dir, "failed to read directory")
+	}
+
+	files := make(map[string]fileState, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			// Removed since the directory was read
+			continue
+		}
+
+		state := fileState{size: info.Size(), modTime: info.ModTime(), isDir: info.IsDir()}
+		path := filepath.Join(dir, entry.Name())
+		if b.hash && !state.isDir && (b.shouldHash == nil || b.shouldHash(path)) {
+			if sum, err := hashFile(path); err == nil {
+				state.hash = sum
+			}
+		}
+		files[entry.Name()] = state
+	}
+	return files, nil
+}
+
+func (b *pollBackend) send(event fsnotify.Event) bool {
+	select {
+	case b.events <- event:
+		return true
+	case <-b.done:
+		return false
+	}
+}
+
+func (b *pollBackend) sendError(err error) bool {
+	select {
+	case b.errors <- err:
+		return true
+	case <-b.done:
+		return false
+	}
+}
+
+// hashFile returns the SHA-256 hash of a file's contents
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,141 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffScans(t *testing.T) {
+	now := time.Now()
+	previous := map[string]fileState{
+		"kept.xlsx":    {size: 10, modTime: now},
+		"changed.xlsx": {size: 10, modTime: now},
+		"touched.xlsx": {size: 10, modTime: now, hash: "a"},
+		"gone.xlsx":    {size: 10, modTime: now},
+		"olddir":       {isDir: true, modTime: now},
+	}
+	current := map[string]fileState{
+		"kept.xlsx":    {size: 10, modTime: now},
+		"changed.xlsx": {size: 12, modTime: now},
+		"touched.xlsx": {size: 10, modTime: now.Add(time.Second), hash: "a"},
+		"new.xlsx":     {size: 10, modTime: now},
+	}
+
+	assert.Equal(t, []fsnotify.Event{
+		{Name: filepath.Join("d", "gone.xlsx"), Op: fsnotify.Rename},
+		{Name: filepath.Join("d", "olddir"), Op: fsnotify.Remove},
+		{Name: filepath.Join("d", "new.xlsx"), Op: fsnotify.Create},
+		{Name: filepath.Join("d", "changed.xlsx"), Op: fsnotify.Write},
+	}, diffScans("d", previous, current))
+}
+
+func TestNewBackend(t *testing.T) {
+	logger := logrus.New()
+
+	for _, name := range []string{"", BackendFSNotify, BackendPoll, BackendAuto} {
+		backend, err := newBackend(&Config{Backend: name}, nil, logger)
+		require.NoError(t, err, name)
+		require.NoError(t, backend.Add(t.TempDir()), name)
+		assert.NoError(t, backend.Close(), name)
+	}
+
+	_, err := newBackend(&Config{Backend: "inotify"}, nil, logger)
+	assert.Error(t, err)
+
+	poll := newPollBackend(time.Second, false, nil)
+	defer poll.Close()
+	assert.Error(t, poll.Add(filepath.Join(t.TempDir(), "missing")))
+	assert.Error(t, poll.Remove(t.TempDir()))
+}
+
+func TestFileWatcher_PollBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	config := &Config{
+		DebounceDelay:     50 * time.Millisecond,
+		FileExtensions:    []string{".xlsx"},
+		StabilityInterval: 10 * time.Millisecond,
+		Backend:           BackendPoll,
+		PollInterval:      50 * time.Millisecond,
+		PollHash:          true,
+	}
+
+	eventChan := make(chan FileEvent, 10)
+	handler := func(event FileEvent) error {
+		eventChan <- event
+		return nil
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	tempDir := t.TempDir()
+	fw, err := NewFileWatcher(config, handler, logger)
+	require.NoError(t, err)
+	defer func() { _ = fw.Stop() }()
+	require.NoError(t, fw.AddDirectory(tempDir))
+	require.NoError(t, fw.Start())
+
+	waitFor := func(t *testing.T) FileEvent {
+		select {
+		case event := <-eventChan:
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timeout waiting for file event")
+			return FileEvent{}
+		}
+	}
+
+	workbook := filepath.Join(tempDir, "report.xlsx")
+	data := zipBytes(t)
+
+	t.Run("create", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(workbook, data, 0600))
+		event := waitFor(t)
+		assert.Equal(t, EventTypeCreate, event.Type)
+		assert.Equal(t, workbook, event.Path)
+	})
+
+	t.Run("touching without changing the contents is ignored", func(t *testing.T) {
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(workbook, later, later))
+		select {
+		case event := <-eventChan:
+			t.Fatalf("Unexpected event: %s %s", event.Type, event.Path)
+		case <-time.After(300 * time.Millisecond):
+		}
+	})
+
+	t.Run("modify", func(t *testing.T) {
+		modified := append(append([]byte{}, data...), 0)
+		require.NoError(t, os.WriteFile(workbook, modified, 0600))
+		event := waitFor(t)
+		assert.Equal(t, EventTypeModify, event.Type)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		renamed := filepath.Join(tempDir, "final.xlsx")
+		require.NoError(t, os.Rename(workbook, renamed))
+		event := waitFor(t)
+		assert.Equal(t, EventTypeRename, event.Type)
+		assert.Equal(t, renamed, event.Path)
+		assert.Equal(t, workbook, event.OldPath)
+		workbook = renamed
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, os.Remove(workbook))
+		event := waitFor(t)
+		assert.Equal(t, EventTypeDelete, event.Type)
+		assert.Equal(t, workbook, event.Path)
+	})
+}
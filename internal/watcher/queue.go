@@ -0,0 +1,463 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/constants"
+	"github.com/Classic-Homes/gitcells/internal/git"
+	"github.com/Classic-Homes/gitcells/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Retry defaults for queued events
+const (
+	DefaultRetryAttempts = 5
+	DefaultRetryDelay    = 2 * time.Second
+	// MaxRetryDelay caps the doubling delay between attempts
+	MaxRetryDelay = time.Minute
+)
+
+// QueueItem is a file event waiting to be handled or, after it failed every
+// attempt, kept in the dead-letter list
+type QueueItem struct {
+	ID        int64     `json:"id"`
+	Event     FileEvent `json:"event"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	Queued    time.Time `json:"queued"`
+	// NextAttempt is when an item that failed is tried again
+	NextAttempt time.Time `json:"next_attempt"`
+	// Held marks an event held while the watcher is paused
+	Held     bool      `json:"held,omitempty"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// QueueState is the content of the queue file
+type QueueState struct {
+	Pending []QueueItem `json:"pending"`
+	Failed  []QueueItem `json:"failed"`
+}
+
+// Queue records file events in a file before handling them, retries those
+// that fail and keeps the ones that never succeed as dead letters. Events
+// still pending when the process stops are handled again by Replay.
+type Queue struct {
+	path    string
+	handler EventHandler
+	// attempts is how many times an event is tried, waiting delay before
+	// the second attempt and twice as long before each later one
+	attempts int
+	delay    time.Duration
+	logger   *logrus.Logger
+
+	mu     sync.Mutex
+	state  QueueState
+	nextID int64
+	// replay holds the items pending when the queue was opened
+	replay []QueueItem
+	// submit passes events due for another attempt back to the watcher
+	submit func(FileEvent)
+	// timers wait out the delay before the next attempt of failed items
+	timers map[int64]*time.Timer
+	closed bool
+}
+
+// OpenQueue opens the queue stored at path, creating it on first use.
+// Handling an event makes up to attempts attempts, waiting delay before the
+// first retry and twice as long before each later one. Non-positive values
+// use DefaultRetryAttempts and DefaultRetryDelay.
+func OpenQueue(path string, handler EventHandler, attempts int, delay time.Duration, logger *logrus.Logger) (*Queue, error) {
+	state, err := ReadQueue(path)
+	if err != nil {
+		return nil, err
+	}
+	if attempts <= 0 {
+		attempts = DefaultRetryAttempts
+	}
+	if delay <= 0 {
+		delay = DefaultRetryDelay
+	}
+
+	q := &Queue{
+		path:     path,
+		handler:  handler,
+		attempts: attempts,
+		delay:    delay,
+		logger:   logger,
+		state:    state,
+		replay:   append([]QueueItem(nil), state.Pending...),
+		timers:   make(map[int64]*time.Timer),
+	}
+	for _, items := range [][]QueueItem{state.Pending, state.Failed} {
+		for _, item := range items {
+			if item.ID >= q.nextID {
+				q.nextID = item.ID + 1
+			}
+		}
+	}
+	return q, nil
+}
+
+// QueuePath returns the queue file of the repository containing dir, or of
+// dir itself outside a repository
+func QueuePath(dir string) string {
+	root, err := git.FindRepositoryRoot(dir)
+	if err != nil {
+		root = dir
+	}
+	return filepath.Join(root, constants.GitCellsQueueFile)
+}
+
+// ReadQueue reads the queue file at path. A missing file is an empty queue.
+func ReadQueue(path string) (QueueState, error) {
+	var state QueueState
+	data, err := os.ReadFile(path) // #nosec G304 - path within the repository
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "readQueue", path, "failed to read event queue")
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, utils.WrapFileError(err, utils.ErrorTypeCorruption, "readQueue", path, "failed to parse event queue")
+	}
+	return state, nil
+}
+
+// Handle records an event, then makes one attempt at handling it. An event
+// that fails is tried again after a delay, by passing it to the function
+// given to Replay, so that waiting for the next attempt blocks nothing. It
+// is an EventHandler, so a FileWatcher can pass its events through the
+// queue.
+func (q *Queue) Handle(event FileEvent) error {
+	item, err := q.record(event)
+	if err != nil {
+		// Without the record the event is still worth handling
+		q.logger.Warnf("Failed to record %s %s in the event queue: %v", event.Type, event.Path, err)
+	}
+
+	return q.run(item)
+}
+
+// record adds an event to the pending items. Items already pending for the
+// same path, such as one waiting for its next attempt, are combined with
+// it, since handling the event covers them too.
+func (q *Queue) record(event FileEvent) (QueueItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item := QueueItem{ID: -1, Event: event, Queued: time.Now()}
+	pending := q.state.Pending[:0]
+	for _, earlier := range q.state.Pending {
+		if earlier.Event.Path != event.Path {
+			pending = append(pending, earlier)
+			continue
+		}
+		if timer, ok := q.timers[earlier.ID]; ok {
+			timer.Stop()
+			delete(q.timers, earlier.ID)
+		}
+		if item.ID < 0 {
+			item.ID, item.Queued, item.Attempts, item.LastError = earlier.ID, earlier.Queued, earlier.Attempts, earlier.LastError
+			item.Event = coalesceEvents(earlier.Event, event)
+		}
+	}
+	if item.ID < 0 {
+		item.ID = q.nextID
+		q.nextID++
+	}
+	q.state.Pending = append(pending, item)
+	return item, q.save()
+}
+
+// Hold records an event without handling it, for a paused watcher. An event
+// already pending for the same path, held or waiting for a retry, is
+// combined with it and held too, so holding does not grow the queue while a
+// workbook is saved again and again. Held events are handed out by Release,
+// and replayed like any pending event if the process stops first.
+func (q *Queue) Hold(event FileEvent) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := range q.state.Pending {
+		if held := &q.state.Pending[i]; held.Event.Path == event.Path {
+			if timer, ok := q.timers[held.ID]; ok {
+				timer.Stop()
+				delete(q.timers, held.ID)
+			}
+			held.Event = coalesceEvents(held.Event, event)
+			held.Held = true
+			held.NextAttempt = time.Time{}
+			return q.save()
+		}
+	}
+	q.state.Pending = append(q.state.Pending, QueueItem{ID: q.nextID, Event: event, Queued: time.Now(), Held: true})
+	q.nextID++
+	return q.save()
+}
+
+// Release returns the held events, which stay pending until handled
+func (q *Queue) Release() []FileEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var events []FileEvent
+	for i := range q.state.Pending {
+		if q.state.Pending[i].Held {
+			q.state.Pending[i].Held = false
+			events = append(events, q.state.Pending[i].Event)
+		}
+	}
+	if len(events) > 0 {
+		if err := q.save(); err != nil {
+			q.logger.Warnf("Failed to update the event queue: %v", err)
+		}
+	}
+	return events
+}
+
+// Held returns how many events are held
+func (q *Queue) Held() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	held := 0
+	for _, item := range q.state.Pending {
+		if item.Held {
+			held++
+		}
+	}
+	return held
+}
+
+// Replay passes the events that were pending when the queue was opened,
+// left behind by a process that stopped before handling them, to submit.
+// From then on submit also receives the events due for another attempt.
+// It should hand them to a FileWatcher, as Notify does, so that they are
+// scheduled like any other event.
+func (q *Queue) Replay(submit func(FileEvent)) {
+	q.mu.Lock()
+	items := q.replay
+	q.replay = nil
+	q.submit = submit
+	q.mu.Unlock()
+
+	if len(items) > 0 {
+		q.logger.Infof("Replaying %d pending events", len(items))
+	}
+	for _, item := range items {
+		if item.Event.Type != EventTypeDelete {
+			if _, err := os.Stat(item.Event.Path); os.IsNotExist(err) {
+				q.logger.Infof("Dropping pending %s of %s, which no longer exists", item.Event.Type, item.Event.Path)
+				q.remove(item.ID)
+				continue
+			}
+		}
+		submit(item.Event)
+	}
+}
+
+// Close stops waiting to retry failed events. They stay pending, so the
+// next process replays them.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	for id, timer := range q.timers {
+		timer.Stop()
+		delete(q.timers, id)
+	}
+}
+
+// run makes an attempt at handling a pending item. It removes the item if
+// the attempt succeeds, arranges another attempt if it fails and attempts
+// are left, and otherwise keeps the item as a dead letter.
+func (q *Queue) run(item QueueItem) error {
+	err := q.handler(item.Event)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	index := -1
+	for i, pending := range q.state.Pending {
+		if pending.ID == item.ID {
+			index = i
+			item = pending
+			break
+		}
+	}
+
+	switch {
+	case err == nil:
+		// A success makes earlier failures for the same workbook moot
+		var failed []QueueItem
+		for _, dead := range q.state.Failed {
+			if dead.Event.Path != item.Event.Path {
+				failed = append(failed, dead)
+			}
+		}
+		q.state.Failed = failed
+	case item.Attempts+1 < q.attempts && index >= 0 && !q.closed:
+		// Locked files, git index locks and full disks all clear up, so
+		// every error is worth another attempt
+		item.Attempts++
+		item.LastError = err.Error()
+		delay := q.retryDelay(item.Attempts)
+		item.NextAttempt = time.Now().Add(delay)
+		q.state.Pending[index] = item
+		q.scheduleRetry(item.ID, delay)
+		q.logger.Warnf("Retrying %s %s in %s after attempt %d failed: %v", item.Event.Type, item.Event.Path, delay, item.Attempts, err)
+		if saveErr := q.save(); saveErr != nil {
+			q.logger.Warnf("Failed to update the event queue: %v", saveErr)
+		}
+		return nil
+	default:
+		item.Attempts++
+		item.LastError = err.Error()
+		item.NextAttempt = time.Time{}
+		item.FailedAt = time.Now()
+		q.state.Failed = append(q.state.Failed, item)
+		q.logger.Warnf("Moved %s %s to the dead-letter list after %d attempts", item.Event.Type, item.Event.Path, item.Attempts)
+		err = utils.WrapError(err, utils.ErrorTypeWatcher, "handleEvent",
+			fmt.Sprintf("failed after %d attempts", item.Attempts))
+	}
+
+	if index >= 0 {
+		q.state.Pending = append(q.state.Pending[:index], q.state.Pending[index+1:]...)
+	}
+	if saveErr := q.save(); saveErr != nil {
+		q.logger.Warnf("Failed to update the event queue: %v", saveErr)
+	}
+	return err
+}
+
+// retryDelay returns the wait after the given number of failed attempts.
+// The caller holds q.mu.
+func (q *Queue) retryDelay(attempts int) time.Duration {
+	delay := q.delay
+	for i := 1; i < attempts && delay < MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > MaxRetryDelay {
+		delay = MaxRetryDelay
+	}
+	return delay
+}
+
+// scheduleRetry submits a pending item again once delay has passed, unless
+// it was handled or combined with a newer event in the meantime. The caller
+// holds q.mu.
+func (q *Queue) scheduleRetry(id int64, delay time.Duration) {
+	q.timers[id] = time.AfterFunc(delay, func() {
+		q.mu.Lock()
+		delete(q.timers, id)
+		var event *FileEvent
+		for i := range q.state.Pending {
+			if q.state.Pending[i].ID == id {
+				event = &q.state.Pending[i].Event
+				break
+			}
+		}
+		submit := q.submit
+		if q.closed || event == nil {
+			q.mu.Unlock()
+			return
+		}
+		retry := *event
+		q.mu.Unlock()
+
+		if submit == nil {
+			// Without a watcher to pass it to, the event stays pending
+			// until the next process replays it
+			q.logger.Warnf("Leaving %s %s pending until the watcher restarts", retry.Type, retry.Path)
+			return
+		}
+		submit(retry)
+	})
+}
+
+// remove drops a pending item and saves the queue
+func (q *Queue) remove(id int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := range q.state.Pending {
+		if q.state.Pending[i].ID == id {
+			q.state.Pending = append(q.state.Pending[:i], q.state.Pending[i+1:]...)
+			if err := q.save(); err != nil {
+				q.logger.Warnf("Failed to update the event queue: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// Pending returns the items waiting to be handled
+func (q *Queue) Pending() []QueueItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]QueueItem(nil), q.state.Pending...)
+}
+
+// Failed returns the dead-letter list
+func (q *Queue) Failed() []QueueItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]QueueItem(nil), q.state.Failed...)
+}
+
+// ClearFailed empties the dead-letter list and returns how many items it had
+func (q *Queue) ClearFailed() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cleared := len(q.state.Failed)
+	if cleared == 0 {
+		return 0, nil
+	}
+	q.state.Failed = nil
+	return cleared, q.save()
+}
+
+// ClearFailed empties the dead-letter list of the queue file at path
+func ClearFailed(path string) (int, error) {
+	state, err := ReadQueue(path)
+	if err != nil || len(state.Failed) == 0 {
+		return 0, err
+	}
+	cleared := len(state.Failed)
+	state.Failed = nil
+	return cleared, writeQueue(path, state)
+}
+
+// save writes the queue file. The caller holds q.mu.
+func (q *Queue) save() error {
+	return writeQueue(q.path, q.state)
+}
+
+// writeQueue replaces the queue file, writing a temporary file first so a
+// crash never leaves a partial one
+func writeQueue(path string, state QueueState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "writeQueue", path, "failed to encode event queue")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "writeQueue", path, "failed to create queue directory")
+	}
+
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0600); err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "writeQueue", path, "failed to write event queue")
+	}
+	if err := os.Rename(temp, path); err != nil {
+		return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "writeQueue", path, "failed to replace event queue")
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package watcher
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventTypeJSON(t *testing.T) {
+	data, err := json.Marshal(FileEvent{Path: "a.xlsx", OldPath: "b.xlsx", Type: EventTypeRename})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"rename"`)
+
+	var event FileEvent
+	require.NoError(t, json.Unmarshal(data, &event))
+	assert.Equal(t, EventTypeRename, event.Type)
+	assert.Equal(t, "b.xlsx", event.OldPath)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"type":"moved"}`), &event))
+}
+
+func TestQueue(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	t.Run("retries failures and keeps dead letters until the workbook succeeds", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "queue.json")
+		var mu sync.Mutex
+		calls := map[string]int{}
+		failing := map[string]bool{"broken.xlsx": true, "flaky.xlsx": true}
+		handler := func(event FileEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls[event.Path]++
+			if event.Path == "flaky.xlsx" && calls[event.Path] > 1 {
+				return nil
+			}
+			if failing[event.Path] {
+				return errors.New("index.lock exists")
+			}
+			return nil
+		}
+		callsOf := func(path string) int {
+			mu.Lock()
+			defer mu.Unlock()
+			return calls[path]
+		}
+
+		q, err := OpenQueue(path, handler, 3, time.Millisecond, logger)
+		require.NoError(t, err)
+		defer q.Close()
+		// Retries come back through submit, as a FileWatcher passes them
+		deadLetters := make(chan error, 1)
+		q.Replay(func(event FileEvent) {
+			if err := q.Handle(event); err != nil {
+				deadLetters <- err
+			}
+		})
+
+		assert.NoError(t, q.Handle(FileEvent{Path: "ok.xlsx", Type: EventTypeModify}))
+		assert.NoError(t, q.Handle(FileEvent{Path: "flaky.xlsx", Type: EventTypeModify}))
+		require.Eventually(t, func() bool { return callsOf("flaky.xlsx") == 2 }, time.Second, 5*time.Millisecond)
+
+		assert.NoError(t, q.Handle(FileEvent{Path: "broken.xlsx", Type: EventTypeModify}))
+		select {
+		case err := <-deadLetters:
+			assert.Contains(t, err.Error(), "failed after 3 attempts")
+		case <-time.After(time.Second):
+			t.Fatal("broken.xlsx was not moved to the dead-letter list")
+		}
+		assert.Equal(t, 3, callsOf("broken.xlsx"))
+		assert.Equal(t, 2, callsOf("flaky.xlsx"))
+		assert.Empty(t, q.Pending())
+
+		failed := q.Failed()
+		require.Len(t, failed, 1)
+		assert.Equal(t, "broken.xlsx", failed[0].Event.Path)
+		assert.Equal(t, 3, failed[0].Attempts)
+		assert.Contains(t, failed[0].LastError, "index.lock exists")
+		assert.False(t, failed[0].FailedAt.IsZero())
+
+		// The dead letter is stored
+		state, err := ReadQueue(path)
+		require.NoError(t, err)
+		assert.Len(t, state.Failed, 1)
+
+		mu.Lock()
+		failing["broken.xlsx"] = false
+		mu.Unlock()
+		assert.NoError(t, q.Handle(FileEvent{Path: "broken.xlsx", Type: EventTypeModify}))
+		assert.Empty(t, q.Failed())
+	})
+
+	t.Run("waits for the next attempt without blocking", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "queue.json")
+		calls := 0
+		q, err := OpenQueue(path, func(FileEvent) error {
+			calls++
+			return errors.New("index.lock exists")
+		}, 3, time.Hour, logger)
+		require.NoError(t, err)
+		q.Replay(func(FileEvent) { t.Error("retried before the delay passed") })
+
+		start := time.Now()
+		assert.NoError(t, q.Handle(FileEvent{Path: "a.xlsx", Type: EventTypeModify}))
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, 1, calls)
+
+		// The item waits with its next attempt recorded, the delay capped
+		// at MaxRetryDelay
+		state, err := ReadQueue(path)
+		require.NoError(t, err)
+		require.Len(t, state.Pending, 1)
+		assert.Equal(t, 1, state.Pending[0].Attempts)
+		assert.WithinDuration(t, time.Now().Add(MaxRetryDelay), state.Pending[0].NextAttempt, time.Second)
+
+		// A new event for the workbook takes the place of the waiting one
+		assert.NoError(t, q.Handle(FileEvent{Path: "a.xlsx", Type: EventTypeModify}))
+		assert.Equal(t, 2, calls)
+		pending := q.Pending()
+		require.Len(t, pending, 1)
+		assert.Equal(t, state.Pending[0].ID, pending[0].ID)
+		assert.Equal(t, 2, pending[0].Attempts)
+
+		// Closing stops waiting but keeps the item for the next process
+		q.Close()
+		assert.Empty(t, q.timers)
+		assert.Len(t, q.Pending(), 1)
+	})
+
+	t.Run("uses the default delay when none is set", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "queue.json")
+		q, err := OpenQueue(path, func(FileEvent) error { return errors.New("index.lock exists") }, 0, 0, logger)
+		require.NoError(t, err)
+		defer q.Close()
+		assert.Equal(t, DefaultRetryAttempts, q.attempts)
+
+		assert.NoError(t, q.Handle(FileEvent{Path: "a.xlsx", Type: EventTypeModify}))
+		pending := q.Pending()
+		require.Len(t, pending, 1)
+		assert.WithinDuration(t, time.Now().Add(DefaultRetryDelay), pending[0].NextAttempt, time.Second)
+	})
+
+	t.Run("replays events left pending by an earlier run", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "queue.json")
+		workbook := filepath.Join(dir, "a.xlsx")
+		require.NoError(t, os.WriteFile(workbook, []byte("x"), 0600))
+		require.NoError(t, writeQueue(path, QueueState{
+			Pending: []QueueItem{
+				{ID: 4, Event: FileEvent{Path: workbook, Type: EventTypeModify}},
+				{ID: 5, Event: FileEvent{Path: filepath.Join(dir, "gone.xlsx"), Type: EventTypeModify}},
+				{ID: 6, Event: FileEvent{Path: filepath.Join(dir, "deleted.xlsx"), Type: EventTypeDelete}},
+			},
+			Failed: []QueueItem{{ID: 2, Event: FileEvent{Path: filepath.Join(dir, "b.xlsx")}}},
+		}))
+
+		var handled []string
+		q, err := OpenQueue(path, func(event FileEvent) error {
+			handled = append(handled, filepath.Base(event.Path))
+			return nil
+		}, 3, 0, logger)
+		require.NoError(t, err)
+		q.Replay(func(event FileEvent) {
+			assert.NoError(t, q.Handle(event))
+		})
+
+		assert.Equal(t, []string{"a.xlsx", "deleted.xlsx"}, handled)
+		state, err := ReadQueue(path)
+		require.NoError(t, err)
+		assert.Empty(t, state.Pending)
+		assert.Len(t, state.Failed, 1)
+
+		// New items continue after the stored IDs
+		require.NoError(t, q.Handle(FileEvent{Path: workbook, Type: EventTypeModify}))
+		assert.Equal(t, int64(8), q.nextID)
+
+		cleared, err := ClearFailed(path)
+		require.NoError(t, err)
+		assert.Equal(t, 1, cleared)
+		state, err = ReadQueue(path)
+		require.NoError(t, err)
+		assert.Empty(t, state.Failed)
+	})
+
+	t.Run("holds events in the queue file until released", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "queue.json")
+		workbook := filepath.Join(dir, "a.xlsx")
+		require.NoError(t, os.WriteFile(workbook, []byte("x"), 0600))
+
+		var handled []FileEvent
+		handler := func(event FileEvent) error {
+			handled = append(handled, event)
+			return nil
+		}
+		q, err := OpenQueue(path, handler, 3, 0, logger)
+		require.NoError(t, err)
+
+		// Saving a workbook again and again while paused holds one event
+		require.NoError(t, q.Hold(FileEvent{Path: workbook, Type: EventTypeCreate}))
+		for i := 0; i < 5; i++ {
+			require.NoError(t, q.Hold(FileEvent{Path: workbook, Type: EventTypeModify}))
+		}
+		require.NoError(t, q.Hold(FileEvent{Path: filepath.Join(dir, "b.xlsx"), Type: EventTypeDelete}))
+		assert.Equal(t, 2, q.Held())
+		assert.Empty(t, handled)
+
+		state, err := ReadQueue(path)
+		require.NoError(t, err)
+		require.Len(t, state.Pending, 2)
+		assert.True(t, state.Pending[0].Held)
+		assert.Equal(t, EventTypeCreate, state.Pending[0].Event.Type)
+
+		// A process that stops while paused replays the held events
+		reopened, err := OpenQueue(path, handler, 3, 0, logger)
+		require.NoError(t, err)
+		reopened.Replay(func(event FileEvent) {
+			assert.NoError(t, reopened.Handle(event))
+		})
+		assert.Len(t, handled, 2)
+		assert.Empty(t, reopened.Pending())
+
+		// Released events stay pending until they are handled
+		handled = nil
+		q = reopened
+		require.NoError(t, q.Hold(FileEvent{Path: workbook, Type: EventTypeModify}))
+		released := q.Release()
+		assert.Equal(t, []FileEvent{{Path: workbook, Type: EventTypeModify}}, released)
+		assert.Zero(t, q.Held())
+		require.Len(t, q.Pending(), 1)
+		require.NoError(t, q.Handle(released[0]))
+		assert.Len(t, handled, 1)
+		assert.Empty(t, q.Pending())
+	})
+
+	t.Run("holds an event waiting for a retry in its place", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "queue.json")
+		q, err := OpenQueue(path, func(FileEvent) error { return errors.New("index.lock exists") }, 3, time.Hour, logger)
+		require.NoError(t, err)
+		defer q.Close()
+		q.Replay(func(FileEvent) { t.Error("retried while held") })
+
+		require.NoError(t, q.Handle(FileEvent{Path: "a.xlsx", Type: EventTypeModify}))
+		id := q.Pending()[0].ID
+
+		// The retry of a paused watcher comes back to Hold
+		require.NoError(t, q.Hold(FileEvent{Path: "a.xlsx", Type: EventTypeModify}))
+		pending := q.Pending()
+		require.Len(t, pending, 1)
+		assert.Equal(t, id, pending[0].ID)
+		assert.True(t, pending[0].Held)
+		assert.Equal(t, 1, pending[0].Attempts)
+		assert.Empty(t, q.timers)
+
+		assert.Equal(t, []FileEvent{{Path: "a.xlsx", Type: EventTypeModify}}, q.Release())
+		assert.Len(t, q.Pending(), 1)
+	})
+
+	t.Run("clears the dead letters of an open queue", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "queue.json")
+		q, err := OpenQueue(path, func(FileEvent) error { return errors.New("disk full") }, 1, 0, logger)
+		require.NoError(t, err)
+		assert.Error(t, q.Handle(FileEvent{Path: "a.xlsx", Type: EventTypeModify}))
+
+		cleared, err := q.ClearFailed()
+		require.NoError(t, err)
+		assert.Equal(t, 1, cleared)
+		assert.Empty(t, q.Failed())
+
+		state, err := ReadQueue(path)
+		require.NoError(t, err)
+		assert.Empty(t, state.Failed)
+	})
+
+	t.Run("rejects a corrupt queue file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "queue.json")
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+		_, err := OpenQueue(path, func(FileEvent) error { return nil }, 3, 0, logger)
+		assert.Error(t, err)
+	})
+}
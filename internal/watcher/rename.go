@@ -0,0 +1,106 @@
+package watcher
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RenameWindow is how long after a file's Rename event the Create event of
+// its new name is waited for. Without one the file is reported as deleted.
+const RenameWindow = 500 * time.Millisecond
+
+// renameTracker pairs the Rename event fsnotify reports for a file's old
+// name with the Create event of its new name
+type renameTracker struct {
+	window  time.Duration
+	mu      sync.Mutex
+	pending []*pendingRename
+}
+
+type pendingRename struct {
+	path  string
+	timer *time.Timer
+}
+
+func newRenameTracker(window time.Duration) *renameTracker {
+	return &renameTracker{window: window}
+}
+
+// add records that path was renamed away. expired runs if no Create event
+// claims the rename within the window.
+func (r *renameTracker) add(path string, expired func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rename := &pendingRename{path: path}
+	rename.timer = time.AfterFunc(r.window, func() {
+		if r.remove(rename) {
+			expired()
+		}
+	})
+	r.pending = append(r.pending, rename)
+}
+
+// match claims the pending rename that most likely became newPath and
+// returns its old path. A rename keeping the file name, as when a file is
+// moved to another directory, is preferred. Otherwise a rename is only
+// claimed if it is the one pending in newPath's directory, since a polling
+// backend reports every file gone and every file added in a scan together,
+// and an unrelated delete and create must not become a rename.
+func (r *renameTracker) match(newPath string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := -1
+	for i := len(r.pending) - 1; i >= 0; i-- {
+		if filepath.Base(r.pending[i].path) == filepath.Base(newPath) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		for i, rename := range r.pending {
+			if filepath.Dir(rename.path) != filepath.Dir(newPath) {
+				continue
+			}
+			if index >= 0 {
+				return "", false
+			}
+			index = i
+		}
+	}
+	if index < 0 {
+		return "", false
+	}
+
+	rename := r.pending[index]
+	rename.timer.Stop()
+	r.pending = append(r.pending[:index], r.pending[index+1:]...)
+	return rename.path, true
+}
+
+// remove drops a pending rename, reporting whether it was still pending
+func (r *renameTracker) remove(rename *pendingRename) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, p := range r.pending {
+		if p == rename {
+			r.pending = append(r.pending[:i], r.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// stop discards all pending renames without reporting them
+func (r *renameTracker) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rename := range r.pending {
+		rename.timer.Stop()
+	}
+	r.pending = nil
+}
@@ -0,0 +1,73 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameTracker(t *testing.T) {
+	t.Run("prefers a rename keeping the file name", func(t *testing.T) {
+		tracker := newRenameTracker(time.Hour)
+		defer tracker.stop()
+		tracker.add("/a/budget.xlsx", func() {})
+		tracker.add("/a/other.xlsx", func() {})
+
+		oldPath, ok := tracker.match("/b/budget.xlsx")
+		assert.True(t, ok)
+		assert.Equal(t, "/a/budget.xlsx", oldPath)
+
+		oldPath, ok = tracker.match("/a/renamed.xlsx")
+		assert.True(t, ok)
+		assert.Equal(t, "/a/other.xlsx", oldPath)
+
+		_, ok = tracker.match("/a/new.xlsx")
+		assert.False(t, ok)
+	})
+
+	t.Run("pairs other names only with the one rename in the directory", func(t *testing.T) {
+		tracker := newRenameTracker(time.Hour)
+		defer tracker.stop()
+		tracker.add("/a/budget.xlsx", func() {})
+		tracker.add("/a/forecast.xlsx", func() {})
+
+		// Either could have become new.xlsx, so neither is claimed
+		_, ok := tracker.match("/a/new.xlsx")
+		assert.False(t, ok)
+		_, ok = tracker.match("/b/new.xlsx")
+		assert.False(t, ok)
+
+		oldPath, ok := tracker.match("/a/forecast.xlsx")
+		assert.True(t, ok)
+		assert.Equal(t, "/a/forecast.xlsx", oldPath)
+		oldPath, ok = tracker.match("/a/new.xlsx")
+		assert.True(t, ok)
+		assert.Equal(t, "/a/budget.xlsx", oldPath)
+	})
+
+	t.Run("unclaimed renames expire", func(t *testing.T) {
+		tracker := newRenameTracker(10 * time.Millisecond)
+		expired := make(chan struct{}, 1)
+		tracker.add("/a/budget.xlsx", func() { expired <- struct{}{} })
+
+		select {
+		case <-expired:
+		case <-time.After(time.Second):
+			t.Fatal("rename did not expire")
+		}
+		_, ok := tracker.match("/a/budget.xlsx")
+		assert.False(t, ok)
+	})
+
+	t.Run("claimed renames do not expire", func(t *testing.T) {
+		tracker := newRenameTracker(10 * time.Millisecond)
+		expired := false
+		tracker.add("/a/budget.xlsx", func() { expired = true })
+		_, ok := tracker.match("/a/new.xlsx")
+		assert.True(t, ok)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.False(t, expired)
+	})
+}
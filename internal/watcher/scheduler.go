@@ -0,0 +1,114 @@
+package watcher
+
+import (
+	"sync"
+)
+
+// DefaultMaxConcurrency is how many events are handled at once unless
+// configured otherwise
+const DefaultMaxConcurrency = 2
+
+// Scheduler handles events with a limit on how many are handled at once.
+// Events for the same path are handled one at a time, and an event for a
+// path that already has one waiting is combined with it, so one handling
+// covers both instead of converting the file twice.
+type Scheduler struct {
+	handle func(FileEvent)
+	slots  chan struct{}
+	wg     sync.WaitGroup
+
+	mu sync.Mutex
+	// queued holds the event waiting for each path
+	queued map[string]FileEvent
+	// active marks the paths that have a goroutine handling their events
+	active  map[string]bool
+	running int
+	stopped bool
+}
+
+// NewScheduler returns a scheduler passing events to handle, at most
+// maxConcurrency at a time. Zero or less means DefaultMaxConcurrency.
+func NewScheduler(maxConcurrency int, handle func(FileEvent)) *Scheduler {
+	if maxConcurrency <= 0 {
+		maxConcurrency = DefaultMaxConcurrency
+	}
+	return &Scheduler{
+		handle: handle,
+		slots:  make(chan struct{}, maxConcurrency),
+		queued: make(map[string]FileEvent),
+		active: make(map[string]bool),
+	}
+}
+
+// Submit queues an event for handling. Events submitted after Stop are
+// dropped.
+func (s *Scheduler) Submit(event FileEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return
+	}
+	if earlier, ok := s.queued[event.Path]; ok {
+		event = coalesceEvents(earlier, event)
+		// A rename followed by a delete reports the old path
+		if event.Path != earlier.Path {
+			delete(s.queued, earlier.Path)
+		}
+	}
+	s.queued[event.Path] = event
+
+	if !s.active[event.Path] {
+		s.active[event.Path] = true
+		s.wg.Add(1)
+		go s.run(event.Path)
+	}
+}
+
+// run handles the events queued for path, one at a time, until none is left
+func (s *Scheduler) run(path string) {
+	defer s.wg.Done()
+
+	for {
+		s.slots <- struct{}{}
+
+		s.mu.Lock()
+		event, ok := s.queued[path]
+		delete(s.queued, path)
+		if !ok {
+			delete(s.active, path)
+			s.mu.Unlock()
+			<-s.slots
+			return
+		}
+		s.running++
+		s.mu.Unlock()
+
+		s.handle(event)
+
+		s.mu.Lock()
+		s.running--
+		s.mu.Unlock()
+		<-s.slots
+	}
+}
+
+// Depth returns how many events are waiting and how many are being handled
+func (s *Scheduler) Depth() (queued, running int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queued), s.running
+}
+
+// Stop drops the events still waiting, waits for those being handled to
+// finish and returns how many were dropped
+func (s *Scheduler) Stop() int {
+	s.mu.Lock()
+	s.stopped = true
+	dropped := len(s.queued)
+	s.queued = make(map[string]FileEvent)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return dropped
+}
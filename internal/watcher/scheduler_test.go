@@ -0,0 +1,155 @@
+package watcher
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler(t *testing.T) {
+	t.Run("limits how many events are handled at once", func(t *testing.T) {
+		var mu sync.Mutex
+		running, maxRunning, handled := 0, 0, 0
+		release := make(chan struct{})
+
+		s := NewScheduler(2, func(event FileEvent) {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+
+			<-release
+
+			mu.Lock()
+			running--
+			handled++
+			mu.Unlock()
+		})
+
+		for i := 0; i < 6; i++ {
+			s.Submit(FileEvent{Path: fmt.Sprintf("book%d.xlsx", i), Type: EventTypeModify})
+		}
+		require.Eventually(t, func() bool {
+			queued, active := s.Depth()
+			return queued == 4 && active == 2
+		}, time.Second, 5*time.Millisecond)
+
+		close(release)
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return handled == 6
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 2, maxRunning)
+		assert.Equal(t, 0, s.Stop())
+	})
+
+	t.Run("handles a path one event at a time and combines waiting events", func(t *testing.T) {
+		var mu sync.Mutex
+		var handled []FileEvent
+		started := make(chan struct{}, 10)
+		release := make(chan struct{})
+
+		s := NewScheduler(4, func(event FileEvent) {
+			started <- struct{}{}
+			<-release
+			mu.Lock()
+			handled = append(handled, event)
+			mu.Unlock()
+		})
+
+		s.Submit(FileEvent{Path: "a.xlsx", Type: EventTypeCreate})
+		<-started
+
+		// The second conversion waits for the first instead of running
+		// alongside it, and the duplicates become one
+		s.Submit(FileEvent{Path: "a.xlsx", Type: EventTypeModify})
+		s.Submit(FileEvent{Path: "a.xlsx", Type: EventTypeModify})
+		s.Submit(FileEvent{Path: "a.xlsx", Type: EventTypeModify})
+		queued, active := s.Depth()
+		assert.Equal(t, 1, queued)
+		assert.Equal(t, 1, active)
+		select {
+		case <-started:
+			t.Fatal("second event for the same path started before the first finished")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(handled) == 2
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 0, s.Stop())
+
+		assert.Equal(t, EventTypeCreate, handled[0].Type)
+		assert.Equal(t, EventTypeModify, handled[1].Type)
+	})
+
+	t.Run("a rename followed by a delete waits as a delete of the old path", func(t *testing.T) {
+		release := make(chan struct{})
+		var handled []FileEvent
+		var mu sync.Mutex
+		s := NewScheduler(1, func(event FileEvent) {
+			<-release
+			mu.Lock()
+			handled = append(handled, event)
+			mu.Unlock()
+		})
+
+		s.Submit(FileEvent{Path: "busy.xlsx", Type: EventTypeModify})
+		require.Eventually(t, func() bool {
+			_, active := s.Depth()
+			return active == 1
+		}, time.Second, 5*time.Millisecond)
+
+		s.Submit(FileEvent{Path: "new.xlsx", OldPath: "old.xlsx", Type: EventTypeRename})
+		s.Submit(FileEvent{Path: "new.xlsx", Type: EventTypeDelete})
+		queued, _ := s.Depth()
+		assert.Equal(t, 1, queued)
+
+		close(release)
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(handled) == 2
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 0, s.Stop())
+		assert.Equal(t, FileEvent{Path: "old.xlsx", Type: EventTypeDelete}, handled[1])
+	})
+
+	t.Run("stop drops waiting events and waits for running ones", func(t *testing.T) {
+		release := make(chan struct{})
+		finished := false
+		s := NewScheduler(1, func(event FileEvent) {
+			<-release
+			finished = true
+		})
+
+		s.Submit(FileEvent{Path: "a.xlsx", Type: EventTypeModify})
+		s.Submit(FileEvent{Path: "b.xlsx", Type: EventTypeModify})
+		require.Eventually(t, func() bool {
+			_, active := s.Depth()
+			return active == 1
+		}, time.Second, 5*time.Millisecond)
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+		}()
+		assert.Equal(t, 1, s.Stop())
+		assert.True(t, finished)
+
+		s.Submit(FileEvent{Path: "c.xlsx", Type: EventTypeModify})
+		queued, active := s.Depth()
+		assert.Zero(t, queued)
+		assert.Zero(t, active)
+	})
+}
@@ -0,0 +1,161 @@
+package watcher
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Classic-Homes/gitcells/internal/utils"
+)
+
+const (
+	// DefaultStabilityInterval is how long a file's size and modification
+	// time must stay the same before it is considered completely written
+	DefaultStabilityInterval = 250 * time.Millisecond
+
+	// ReadyTimeout is how long to wait for a file to become stable and
+	// readable before giving up on the event
+	ReadyTimeout = 30 * time.Second
+)
+
+// Lock file prefixes. Excel holds ~$name.xlsx and LibreOffice
+// .~lock.name.xlsx# while a workbook is open.
+const (
+	excelLockPrefix       = "~$"
+	libreOfficeLockPrefix = ".~lock."
+	libreOfficeLockSuffix = "#"
+
+	// excelLockTruncateLength is the shortest name, without its extension,
+	// whose first two characters Excel replaces with the lock prefix
+	// instead of adding it
+	excelLockTruncateLength = 8
+)
+
+// zipSignature starts every zip file, including .xlsx and .xlsm workbooks
+var zipSignature = []byte("PK\x03\x04")
+
+// waitUntilReady waits until a file's size and modification time stop
+// changing and, for zip-based workbooks, its zip central directory can be
+// read. Editors write a workbook in several steps, and converting it in
+// between fails or reads a partial file.
+func (fw *FileWatcher) waitUntilReady(path string) error {
+	interval := fw.currentConfig().StabilityInterval
+	if interval <= 0 {
+		interval = DefaultStabilityInterval
+	}
+	deadline := time.Now().Add(ReadyTimeout)
+
+	var last os.FileInfo
+	var lastErr error
+	for {
+		info, err := os.Stat(path)
+		if err != nil {
+			return utils.WrapFileError(err, utils.ErrorTypeFileSystem, "waitUntilReady", path, "file disappeared before it could be read")
+		}
+
+		if last != nil && info.Size() == last.Size() && info.ModTime().Equal(last.ModTime()) {
+			if lastErr = verifyWorkbook(path, info); lastErr == nil {
+				return nil
+			}
+		}
+		last = info
+
+		if time.Now().After(deadline) {
+			if lastErr == nil {
+				lastErr = utils.NewError(utils.ErrorTypeFileSystem, "waitUntilReady", "file is still being written")
+			}
+			return utils.WrapFileError(lastErr, utils.ErrorTypeFileSystem, "waitUntilReady", path, "file did not become readable")
+		}
+
+		select {
+		case <-fw.ctx.Done():
+			return utils.NewError(utils.ErrorTypeWatcher, "waitUntilReady", "watcher stopped")
+		case <-time.After(interval):
+		}
+	}
+}
+
+// verifyWorkbook checks that a workbook is complete. A zip file must have a
+// readable central directory, which is written last. Files that are not zip
+// files at all, such as legacy .xls workbooks, are passed on so conversion
+// reports what is wrong with them.
+func verifyWorkbook(path string, info os.FileInfo) error {
+	if info.Size() == 0 {
+		return utils.NewError(utils.ErrorTypeFileSystem, "verifyWorkbook", "file is empty")
+	}
+
+	isZip, err := hasZipSignature(path)
+	if err != nil || !isZip {
+		return err
+	}
+
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return utils.WrapError(err, utils.ErrorTypeFileSystem, "verifyWorkbook", "zip central directory is not readable")
+	}
+	return reader.Close()
+}
+
+// hasZipSignature reports whether a file starts with the zip local file
+// header signature
+func hasZipSignature(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, utils.WrapFileError(err, utils.ErrorTypeFileSystem, "verifyWorkbook", path, "failed to open file")
+	}
+	defer file.Close()
+
+	header := make([]byte, len(zipSignature))
+	if _, err := io.ReadFull(file, header); err != nil {
+		// Shorter than a signature, so not a zip file
+		return false, nil
+	}
+	return bytes.Equal(header, zipSignature), nil
+}
+
+// isLockFile reports whether a file name is an Excel or LibreOffice lock
+// file
+func isLockFile(name string) bool {
+	return strings.HasPrefix(name, excelLockPrefix) ||
+		(strings.HasPrefix(name, libreOfficeLockPrefix) && strings.HasSuffix(name, libreOfficeLockSuffix))
+}
+
+// lockFileNames returns the names of the lock files that show a workbook is
+// open. Excel replaces the first two characters of long names with its
+// prefix to keep the lock file name the same length.
+func lockFileNames(workbook string) []string {
+	name := filepath.Base(workbook)
+	names := []string{excelLockPrefix + name, libreOfficeLockPrefix + name + libreOfficeLockSuffix}
+	if len(strings.TrimSuffix(name, filepath.Ext(name))) >= excelLockTruncateLength {
+		names = append(names, excelLockPrefix+name[len(excelLockPrefix):])
+	}
+	return names
+}
+
+// isLocked reports whether a workbook is open in Excel or LibreOffice
+func isLocked(workbook string) bool {
+	dir := filepath.Dir(workbook)
+	for _, name := range lockFileNames(workbook) {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// locks reports whether lockFile, if it exists, shows that workbook is open
+func locks(lockFile, workbook string) bool {
+	if filepath.Dir(lockFile) != filepath.Dir(workbook) {
+		return false
+	}
+	for _, name := range lockFileNames(workbook) {
+		if filepath.Base(lockFile) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package watcher
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zipBytes(t *testing.T) []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	part, err := writer.Create("[Content_Types].xml")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("<Types/>"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestVerifyWorkbook(t *testing.T) {
+	dir := t.TempDir()
+	data := zipBytes(t)
+
+	verify := func(name string, content []byte) error {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, content, 0600))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return verifyWorkbook(path, info)
+	}
+
+	assert.NoError(t, verify("complete.xlsx", data))
+	// The central directory is written last, so a partial file lacks it
+	assert.Error(t, verify("partial.xlsx", data[:len(data)/2]))
+	assert.Error(t, verify("empty.xlsx", nil))
+	// Files that are not zip files are left for conversion to reject
+	assert.NoError(t, verify("legacy.xls", []byte{0xD0, 0xCF, 0x11, 0xE0}))
+}
+
+func TestFileWatcher_WaitUntilReady(t *testing.T) {
+	fw, err := NewFileWatcher(&Config{StabilityInterval: 20 * time.Millisecond}, func(FileEvent) error { return nil }, logrus.New())
+	require.NoError(t, err)
+	defer func() { _ = fw.Stop() }()
+
+	dir := t.TempDir()
+	data := zipBytes(t)
+	path := filepath.Join(dir, "book.xlsx")
+	require.NoError(t, os.WriteFile(path, data[:10], 0600))
+
+	// Finish writing the workbook while it is being waited for
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = os.WriteFile(path, data, 0600)
+	}()
+	assert.NoError(t, fw.waitUntilReady(path))
+
+	assert.Error(t, fw.waitUntilReady(filepath.Join(dir, "missing.xlsx")))
+
+	// Stopping the watcher ends the wait
+	require.NoError(t, os.WriteFile(path, data[:10], 0600))
+	fw.cancel()
+	assert.Error(t, fw.waitUntilReady(path))
+}
+
+func TestLockFiles(t *testing.T) {
+	assert.True(t, isLockFile("~$Budget.xlsx"))
+	assert.True(t, isLockFile(".~lock.Budget.xlsx#"))
+	assert.False(t, isLockFile(".~lock.Budget.xlsx"))
+	assert.False(t, isLockFile("Budget.xlsx"))
+
+	dir := t.TempDir()
+	workbook := filepath.Join(dir, "Quarterly Budget.xlsx")
+	assert.True(t, locks(filepath.Join(dir, "~$Quarterly Budget.xlsx"), workbook))
+	assert.True(t, locks(filepath.Join(dir, "~$arterly Budget.xlsx"), workbook))
+	assert.True(t, locks(filepath.Join(dir, ".~lock.Quarterly Budget.xlsx#"), workbook))
+	assert.False(t, locks(filepath.Join(dir, "sub", "~$Quarterly Budget.xlsx"), workbook))
+	assert.False(t, locks(filepath.Join(dir, "~$Other.xlsx"), workbook))
+	assert.False(t, locks(filepath.Join(dir, "~$uarterly Budget.xlsx"), workbook))
+
+	// Short names keep their first characters, so a lock file of one
+	// workbook does not lock another ending in the same name
+	assert.True(t, locks(filepath.Join(dir, "~$Budget.xlsx"), filepath.Join(dir, "Budget.xlsx")))
+	assert.False(t, locks(filepath.Join(dir, "~$Budget.xlsx"), filepath.Join(dir, "xBudget.xlsx")))
+	assert.False(t, locks(filepath.Join(dir, "~$dget.xlsx"), filepath.Join(dir, "Budget.xlsx")))
+
+	assert.False(t, isLocked(workbook))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".~lock.Quarterly Budget.xlsx#"), []byte("user"), 0600))
+	assert.True(t, isLocked(workbook))
+}
+
+func TestCoalesceEvents(t *testing.T) {
+	create := FileEvent{Path: "a.xlsx", Type: EventTypeCreate}
+	modify := FileEvent{Path: "a.xlsx", Type: EventTypeModify}
+	remove := FileEvent{Path: "a.xlsx", Type: EventTypeDelete}
+	rename := FileEvent{Path: "a.xlsx", OldPath: "old.xlsx", Type: EventTypeRename}
+
+	assert.Equal(t, modify, coalesceEvents(remove, create))
+	assert.Equal(t, create, coalesceEvents(create, modify))
+	assert.Equal(t, rename, coalesceEvents(rename, modify))
+	assert.Equal(t, remove, coalesceEvents(modify, remove))
+	assert.Equal(t, FileEvent{Path: "old.xlsx", Type: EventTypeDelete}, coalesceEvents(rename, remove))
+}
@@ -8,28 +8,46 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Classic-Homes/gitcells/internal/ignore"
 	"github.com/Classic-Homes/gitcells/internal/utils"
 	"github.com/fsnotify/fsnotify"
 	"github.com/sirupsen/logrus"
 )
 
 type FileWatcher struct {
-	watcher      *fsnotify.Watcher
+	backend      Backend
 	watchedDirs  sync.Map
 	eventHandler EventHandler
 	debouncer    *Debouncer
-	config       *Config
-	logger       *logrus.Logger
-	ctx          context.Context
-	cancel       context.CancelFunc
+	renames      *renameTracker
+	// scheduler limits how many events are handled at once
+	scheduler *Scheduler
+
+	// configMu guards config, which Reconfigure replaces while watching,
+	// and ignores, which matches its ignore patterns
+	configMu sync.RWMutex
+	config   *Config
+	ignores  *ignore.Matcher
+
+	// mu guards pending, the events waiting out the debounce delay, and
+	// deferred, the events of workbooks waiting for their lock file to go
+	mu       sync.Mutex
+	pending  map[string]FileEvent
+	deferred map[string]FileEvent
+
+	logger *logrus.Logger
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 type EventHandler func(event FileEvent) error
 
 type FileEvent struct {
-	Path      string
-	Type      EventType
-	Timestamp time.Time
+	Path string `json:"path"`
+	// OldPath is the previous path of a renamed file
+	OldPath   string    `json:"old_path,omitempty"`
+	Type      EventType `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 type EventType int
@@ -38,6 +56,7 @@ const (
 	EventTypeCreate EventType = iota
 	EventTypeModify
 	EventTypeDelete
+	EventTypeRename
 )
 
 func (et EventType) String() string {
@@ -48,34 +67,78 @@ func (et EventType) String() string {
 		return "modify"
 	case EventTypeDelete:
 		return "delete"
+	case EventTypeRename:
+		return "rename"
 	default:
 		return "unknown"
 	}
 }
 
+// MarshalText stores an event type by its name
+func (et EventType) MarshalText() ([]byte, error) {
+	return []byte(et.String()), nil
+}
+
+// UnmarshalText reads an event type stored by MarshalText
+func (et *EventType) UnmarshalText(text []byte) error {
+	for _, t := range []EventType{EventTypeCreate, EventTypeModify, EventTypeDelete, EventTypeRename} {
+		if t.String() == string(text) {
+			*et = t
+			return nil
+		}
+	}
+	return utils.NewError(utils.ErrorTypeValidation, "UnmarshalText", "unknown event type: "+string(text))
+}
+
 type Config struct {
+	// IgnorePatterns are gitignore-style patterns relative to the current
+	// directory. The .gitcellsignore files of watched directories add to
+	// them.
 	IgnorePatterns []string
 	DebounceDelay  time.Duration
 	FileExtensions []string
+	// StabilityInterval is how long a file must stay unchanged before it
+	// is read; zero means DefaultStabilityInterval
+	StabilityInterval time.Duration
+	// WaitForUnlock defers events of a workbook until the Excel or
+	// LibreOffice lock file showing that it is open disappears
+	WaitForUnlock bool
+	// Backend is BackendFSNotify (the default), BackendPoll or BackendAuto
+	Backend string
+	// PollInterval is how often the polling backend scans; zero means
+	// DefaultPollInterval
+	PollInterval time.Duration
+	// PollHash makes the polling backend compare the contents of workbooks
+	// instead of their size and modification time
+	PollHash bool
+	// MaxConcurrency is how many events are handled at once; zero means
+	// DefaultMaxConcurrency
+	MaxConcurrency int
 }
 
 func NewFileWatcher(config *Config, handler EventHandler, logger *logrus.Logger) (*FileWatcher, error) {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return nil, utils.WrapError(err, utils.ErrorTypeWatcher, "NewFileWatcher", "failed to create fsnotify watcher")
-	}
-
 	ctx, cancel := context.WithCancel(context.Background())
 
 	fw := &FileWatcher{
-		watcher:      watcher,
 		eventHandler: handler,
 		debouncer:    NewDebouncer(config.DebounceDelay),
+		renames:      newRenameTracker(RenameWindow),
+		pending:      make(map[string]FileEvent),
+		deferred:     make(map[string]FileEvent),
 		config:       config,
+		ignores:      ignore.NewMatcher(".", config.IgnorePatterns),
 		logger:       logger,
 		ctx:          ctx,
 		cancel:       cancel,
 	}
+	fw.scheduler = NewScheduler(config.MaxConcurrency, fw.process)
+
+	backend, err := newBackend(config, fw.shouldProcessFile, logger)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	fw.backend = backend
 
 	return fw, nil
 }
@@ -88,7 +151,21 @@ func (fw *FileWatcher) Start() error {
 
 func (fw *FileWatcher) Stop() error {
 	fw.cancel()
-	err := fw.watcher.Close()
+	fw.renames.stop()
+
+	fw.mu.Lock()
+	if len(fw.deferred) > 0 {
+		fw.logger.Warnf("Dropping changes to %d workbooks that are still open", len(fw.deferred))
+	}
+	fw.deferred = make(map[string]FileEvent)
+	fw.mu.Unlock()
+
+	// Let conversions in progress finish, so their changes can be committed
+	if dropped := fw.scheduler.Stop(); dropped > 0 {
+		fw.logger.Warnf("Dropping changes to %d workbooks that were not processed yet", dropped)
+	}
+
+	err := fw.backend.Close()
 	fw.logger.Info("File watcher stopped")
 	if err != nil {
 		return utils.WrapError(err, utils.ErrorTypeWatcher, "Stop", "failed to close watcher")
@@ -104,11 +181,11 @@ func (fw *FileWatcher) AddDirectory(path string) error {
 		}
 
 		if info.IsDir() {
-			if fw.shouldIgnorePath(walkPath) {
+			if fw.shouldIgnorePath(walkPath, true) {
 				return filepath.SkipDir
 			}
 
-			if err := fw.watcher.Add(walkPath); err != nil {
+			if err := fw.backend.Add(walkPath); err != nil {
 				fw.logger.Warnf("Failed to watch %s: %v", walkPath, err)
 			} else {
 				fw.watchedDirs.Store(walkPath, true)
@@ -126,7 +203,7 @@ func (fw *FileWatcher) AddDirectory(path string) error {
 }
 
 func (fw *FileWatcher) RemoveDirectory(path string) error {
-	err := fw.watcher.Remove(path)
+	err := fw.backend.Remove(path)
 	if err != nil {
 		return utils.WrapFileError(err, utils.ErrorTypeWatcher, "RemoveDirectory", path, "failed to remove directory from watcher")
 	}
@@ -139,46 +216,27 @@ func (fw *FileWatcher) processEvents() {
 		case <-fw.ctx.Done():
 			return
 
-		case event, ok := <-fw.watcher.Events:
+		case event, ok := <-fw.backend.Events():
 			if !ok {
 				return
 			}
 
+			if filepath.Base(event.Name) == ignore.FileName {
+				fw.ignoreFileChanged(event.Name)
+				continue
+			}
+
+			if fw.handleDirectoryEvent(event) {
+				continue
+			}
+
 			if fw.shouldProcessFile(event.Name) {
-				fw.debouncer.Debounce(event.Name, func() {
-					fileEvent := FileEvent{
-						Path:      event.Name,
-						Timestamp: time.Now(),
-					}
-
-					// Check operations in priority order
-					// Create events can sometimes include Write, so check Create first
-					switch {
-					case event.Op&fsnotify.Create == fsnotify.Create:
-						fileEvent.Type = EventTypeCreate
-						// If a directory was created, watch it
-						if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
-							_ = fw.AddDirectory(event.Name)
-						}
-					case event.Op&fsnotify.Write == fsnotify.Write:
-						fileEvent.Type = EventTypeModify
-					case event.Op&fsnotify.Remove == fsnotify.Remove:
-						fileEvent.Type = EventTypeDelete
-					case event.Op&fsnotify.Rename == fsnotify.Rename:
-						fileEvent.Type = EventTypeDelete // Treat rename as delete for simplicity
-					default:
-						return
-					}
-
-					fw.logger.Debugf("Processing file event: %s %s", fileEvent.Type, fileEvent.Path)
-
-					if err := fw.eventHandler(fileEvent); err != nil {
-						fw.logger.Errorf("Event handler error for %s: %v", fileEvent.Path, err)
-					}
-				})
+				fw.handleEvent(event)
+			} else if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 && isLockFile(filepath.Base(event.Name)) {
+				fw.releaseDeferred(event.Name)
 			}
 
-		case err, ok := <-fw.watcher.Errors:
+		case err, ok := <-fw.backend.Errors():
 			if !ok {
 				return
 			}
@@ -187,11 +245,238 @@ func (fw *FileWatcher) processEvents() {
 	}
 }
 
+// handleDirectoryEvent watches directories as they appear and stops
+// watching them when they go, reporting whether the event was about a
+// directory. A new directory is scanned for workbooks, since they may have
+// been created before it was watched, as when a folder is moved in.
+func (fw *FileWatcher) handleDirectoryEvent(event fsnotify.Event) bool {
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 && fw.IsWatching(event.Name) {
+		fw.forgetDirectory(event.Name)
+		return true
+	}
+
+	if event.Op&fsnotify.Create == 0 {
+		return false
+	}
+	info, err := os.Stat(event.Name)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	if fw.shouldIgnorePath(event.Name, true) {
+		return true
+	}
+
+	if err := fw.AddDirectory(event.Name); err != nil {
+		fw.logger.Warnf("Failed to watch new directory %s: %v", event.Name, err)
+		return true
+	}
+	fw.scanDirectory(event.Name)
+	return true
+}
+
+// scanDirectory reports the workbooks in a directory tree as created
+func (fw *FileWatcher) scanDirectory(path string) {
+	for _, workbook := range fw.Workbooks(path) {
+		fw.dispatch(FileEvent{Path: workbook, Type: EventTypeCreate})
+	}
+}
+
+// Workbooks returns the files in a directory tree that the watcher would
+// handle events for, skipping ignored files and directories
+func (fw *FileWatcher) Workbooks(path string) []string {
+	var workbooks []string
+	_ = filepath.Walk(path, func(walkPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Removed while scanning
+			return nil
+		}
+		if info.IsDir() {
+			if walkPath != path && fw.shouldIgnorePath(walkPath, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fw.shouldProcessFile(walkPath) {
+			workbooks = append(workbooks, walkPath)
+		}
+		return nil
+	})
+	return workbooks
+}
+
+// forgetDirectory stops watching a removed or renamed directory and the
+// directories below it
+func (fw *FileWatcher) forgetDirectory(path string) {
+	prefix := path + string(filepath.Separator)
+	fw.watchedDirs.Range(func(key, value interface{}) bool {
+		dir := key.(string)
+		if dir == path || strings.HasPrefix(dir, prefix) {
+			fw.watchedDirs.Delete(dir)
+			// The backend may have dropped a deleted directory already
+			_ = fw.backend.Remove(dir)
+			fw.logger.Debugf("Stopped watching directory: %s", dir)
+		}
+		return true
+	})
+}
+
+// handleEvent turns an fsnotify event into a FileEvent. The Rename event of
+// a file's old name is held back until the Create event of its new name
+// arrives, so that the pair is reported as one rename; a file renamed to a
+// name that is not watched is reported as deleted once RenameWindow passes.
+func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
+	fileEvent := FileEvent{Path: event.Name}
+
+	// Check operations in priority order
+	// Create events can sometimes include Write, so check Create first
+	switch {
+	case event.Op&fsnotify.Create == fsnotify.Create:
+		fileEvent.Type = EventTypeCreate
+		if oldPath, ok := fw.renames.match(event.Name); ok {
+			// Saving by renaming a temporary file over the workbook, as
+			// Excel does, replaces the file under its own name
+			if oldPath == event.Name {
+				fileEvent.Type = EventTypeModify
+			} else {
+				fileEvent.Type = EventTypeRename
+				fileEvent.OldPath = oldPath
+				fw.cancelPending(oldPath)
+			}
+		}
+	case event.Op&fsnotify.Write == fsnotify.Write:
+		fileEvent.Type = EventTypeModify
+	case event.Op&fsnotify.Remove == fsnotify.Remove:
+		fileEvent.Type = EventTypeDelete
+	case event.Op&fsnotify.Rename == fsnotify.Rename:
+		fw.renames.add(event.Name, func() {
+			fw.dispatch(FileEvent{Path: event.Name, Type: EventTypeDelete})
+		})
+		return
+	default:
+		return
+	}
+
+	fw.dispatch(fileEvent)
+}
+
+// dispatch passes an event to the handler once no other event for the same
+// path has arrived for the debounce delay. Events arriving in the meantime
+// are coalesced, so the bursts editors produce while saving become one.
+func (fw *FileWatcher) dispatch(fileEvent FileEvent) {
+	// Events for "./a.xlsx" and "a.xlsx" must meet in the same slot
+	fileEvent.Path = filepath.Clean(fileEvent.Path)
+	if fileEvent.OldPath != "" {
+		fileEvent.OldPath = filepath.Clean(fileEvent.OldPath)
+	}
+
+	fw.mu.Lock()
+	if earlier, ok := fw.pending[fileEvent.Path]; ok {
+		fileEvent = coalesceEvents(earlier, fileEvent)
+	}
+	fw.pending[fileEvent.Path] = fileEvent
+	fw.mu.Unlock()
+
+	path := fileEvent.Path
+	fw.debouncer.Debounce(path, func() {
+		// Submitting before unlocking keeps the event visible to Idle
+		fw.mu.Lock()
+		defer fw.mu.Unlock()
+		if fileEvent, ok := fw.pending[path]; ok {
+			delete(fw.pending, path)
+			fw.scheduler.Submit(fileEvent)
+		}
+	})
+}
+
+// Notify handles an event found other than by watching, such as a workbook
+// changed while the watcher was stopped, as if the file system reported it
+func (fw *FileWatcher) Notify(fileEvent FileEvent) {
+	fw.dispatch(fileEvent)
+}
+
+// cancelPending drops the event waiting out the debounce delay for a path
+func (fw *FileWatcher) cancelPending(path string) {
+	path = filepath.Clean(path)
+	fw.debouncer.Cancel(path)
+	fw.mu.Lock()
+	delete(fw.pending, path)
+	fw.mu.Unlock()
+}
+
+// process waits until a workbook is closed, if configured, and completely
+// written, then passes its event to the handler
+func (fw *FileWatcher) process(fileEvent FileEvent) {
+	if fileEvent.Type != EventTypeDelete {
+		if fw.currentConfig().WaitForUnlock && isLocked(fileEvent.Path) {
+			fw.mu.Lock()
+			if earlier, ok := fw.deferred[fileEvent.Path]; ok {
+				fileEvent = coalesceEvents(earlier, fileEvent)
+			}
+			fw.deferred[fileEvent.Path] = fileEvent
+			fw.mu.Unlock()
+			fw.logger.Infof("Waiting for %s to be closed", fileEvent.Path)
+			return
+		}
+
+		if err := fw.waitUntilReady(fileEvent.Path); err != nil {
+			// A later event reports the file again if it is replaced
+			fw.logger.Warnf("Skipping %s %s: %v", fileEvent.Type, fileEvent.Path, err)
+			return
+		}
+	}
+
+	fileEvent.Timestamp = time.Now()
+	fw.logger.Debugf("Processing file event: %s %s", fileEvent.Type, fileEvent.Path)
+
+	if err := fw.eventHandler(fileEvent); err != nil {
+		fw.logger.Errorf("Event handler error for %s: %v", fileEvent.Path, err)
+	}
+}
+
+// releaseDeferred dispatches the deferred events of the workbooks a removed
+// lock file belonged to
+func (fw *FileWatcher) releaseDeferred(lockFile string) {
+	fw.mu.Lock()
+	var released []FileEvent
+	for path, fileEvent := range fw.deferred {
+		if locks(lockFile, path) {
+			released = append(released, fileEvent)
+			delete(fw.deferred, path)
+		}
+	}
+	fw.mu.Unlock()
+
+	for _, fileEvent := range released {
+		fw.dispatch(fileEvent)
+	}
+}
+
+// coalesceEvents combines two events for the same path into the one that
+// describes both
+func coalesceEvents(earlier, later FileEvent) FileEvent {
+	switch {
+	case later.Type == EventTypeDelete && earlier.Type == EventTypeRename:
+		// Renamed and then deleted: the old name is what disappeared
+		return FileEvent{Path: earlier.OldPath, Type: EventTypeDelete}
+	case later.Type == EventTypeDelete:
+		return later
+	case earlier.Type == EventTypeDelete:
+		// Deleted and written again, as when a file is replaced on save
+		later.Type = EventTypeModify
+		later.OldPath = ""
+		return later
+	case earlier.Type == EventTypeCreate || earlier.Type == EventTypeRename:
+		return earlier
+	default:
+		return later
+	}
+}
+
 func (fw *FileWatcher) shouldProcessFile(path string) bool {
 	// Check if it's an Excel file
 	ext := strings.ToLower(filepath.Ext(path))
 	validExt := false
-	for _, allowedExt := range fw.config.FileExtensions {
+	for _, allowedExt := range fw.currentConfig().FileExtensions {
 		if ext == allowedExt {
 			validExt = true
 			break
@@ -203,10 +488,12 @@ func (fw *FileWatcher) shouldProcessFile(path string) bool {
 	}
 
 	// Check ignore patterns
-	return !fw.shouldIgnorePath(path)
+	return !fw.shouldIgnorePath(path, false)
 }
 
-func (fw *FileWatcher) shouldIgnorePath(path string) bool {
+// shouldIgnorePath reports whether a file or directory is a temporary,
+// hidden or git file, or matches the ignore patterns
+func (fw *FileWatcher) shouldIgnorePath(path string, isDir bool) bool {
 	base := filepath.Base(path)
 
 	// Always ignore Excel temp files
@@ -214,8 +501,9 @@ func (fw *FileWatcher) shouldIgnorePath(path string) bool {
 		return true
 	}
 
-	// Always ignore hidden files and directories
-	if strings.HasPrefix(base, ".") {
+	// Always ignore hidden files and directories. A path of "." or ".." is
+	// a directory given relative to the current one, not a hidden one.
+	if strings.HasPrefix(base, ".") && base != "." && base != ".." {
 		return true
 	}
 
@@ -225,28 +513,95 @@ func (fw *FileWatcher) shouldIgnorePath(path string) bool {
 		return true
 	}
 
-	// Check against ignore patterns
-	for _, pattern := range fw.config.IgnorePatterns {
-		if matched, _ := filepath.Match(pattern, base); matched {
-			return true
+	fw.configMu.RLock()
+	ignores := fw.ignores
+	fw.configMu.RUnlock()
+	return ignores.Match(path, isDir)
+}
+
+// currentConfig returns the configuration in effect
+func (fw *FileWatcher) currentConfig() *Config {
+	fw.configMu.RLock()
+	defer fw.configMu.RUnlock()
+	return fw.config
+}
+
+// Reconfigure applies the settings of config that can change while
+// watching: the ignore patterns, file extensions, debounce delay,
+// stability interval and waiting for unlock. Directories that are now
+// ignored stop being watched, and those no longer ignored are watched. The
+// backend and the concurrency limit keep the values the watcher was
+// created with.
+func (fw *FileWatcher) Reconfigure(config *Config) {
+	updated := *fw.currentConfig()
+	updated.IgnorePatterns = config.IgnorePatterns
+	updated.FileExtensions = config.FileExtensions
+	updated.DebounceDelay = config.DebounceDelay
+	updated.StabilityInterval = config.StabilityInterval
+	updated.WaitForUnlock = config.WaitForUnlock
+
+	fw.configMu.Lock()
+	fw.config = &updated
+	fw.ignores = ignore.NewMatcher(".", updated.IgnorePatterns)
+	fw.configMu.Unlock()
+	fw.debouncer.SetDelay(config.DebounceDelay)
+
+	fw.refreshDirectories()
+}
+
+// ignoreFileChanged applies a change to a .gitcellsignore file
+func (fw *FileWatcher) ignoreFileChanged(path string) {
+	fw.logger.Infof("Ignore file changed: %s", path)
+	fw.configMu.RLock()
+	fw.ignores.Reset()
+	fw.configMu.RUnlock()
+	fw.refreshDirectories()
+}
+
+// refreshDirectories stops watching directories that are now ignored and
+// watches those no longer ignored
+func (fw *FileWatcher) refreshDirectories() {
+	for _, dir := range fw.GetWatchedDirectories() {
+		if fw.IsWatching(dir) && fw.shouldIgnorePath(dir, true) {
+			fw.forgetDirectory(dir)
+			fw.logger.Infof("Stopped watching ignored directory: %s", dir)
 		}
-		// Also check the full path
-		if matched, _ := filepath.Match(pattern, path); matched {
-			return true
+	}
+
+	// Directories no longer ignored are below ones that are still watched
+	for _, dir := range fw.GetWatchedDirectories() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
 		}
-		// Check if pattern contains directory separators and match path components
-		if strings.Contains(pattern, "/") || strings.Contains(pattern, string(filepath.Separator)) {
-			// Normalize both pattern and path to forward slashes for consistent comparison
-			cleanPattern := filepath.ToSlash(pattern)
-
-			// Check if path matches pattern or contains pattern as a component
-			if strings.Contains(cleanPath, cleanPattern) {
-				return true
+		for _, entry := range entries {
+			subdir := filepath.Join(dir, entry.Name())
+			if !entry.IsDir() || fw.IsWatching(subdir) || fw.shouldIgnorePath(subdir, true) {
+				continue
+			}
+			if err := fw.AddDirectory(subdir); err != nil {
+				fw.logger.Warnf("Failed to watch %s: %v", subdir, err)
+				continue
 			}
+			fw.logger.Infof("Watching directory no longer ignored: %s", subdir)
 		}
 	}
+}
+
+// QueueDepth returns how many events are waiting to be handled and how many
+// are being handled
+func (fw *FileWatcher) QueueDepth() (queued, running int) {
+	return fw.scheduler.Depth()
+}
 
-	return false
+// Idle reports whether no event is waiting out the debounce delay, waiting
+// to be handled or being handled. Events of workbooks waiting to be closed
+// do not count.
+func (fw *FileWatcher) Idle() bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	queued, running := fw.scheduler.Depth()
+	return len(fw.pending) == 0 && queued == 0 && running == 0
 }
 
 func (fw *FileWatcher) GetWatchedDirectories() []string {
@@ -3,9 +3,11 @@ package watcher
 import (
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/Classic-Homes/gitcells/internal/ignore"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -146,6 +148,7 @@ func TestFileWatcher_ShouldIgnorePath(t *testing.T) {
 	tests := []struct {
 		name     string
 		path     string
+		isDir    bool
 		expected bool
 	}{
 		{
@@ -168,11 +171,23 @@ func TestFileWatcher_ShouldIgnorePath(t *testing.T) {
 			path:     "/path/.git/config",
 			expected: true,
 		},
+		{
+			name:     "current directory",
+			path:     ".",
+			isDir:    true,
+			expected: false,
+		},
+		{
+			name:     "hidden directory",
+			path:     "./.gitcells",
+			isDir:    true,
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := fw.shouldIgnorePath(tt.path)
+			result := fw.shouldIgnorePath(tt.path, tt.isDir)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -329,3 +344,361 @@ func TestFileWatcher_HandlerError(t *testing.T) {
 	err = fw.Stop()
 	assert.NoError(t, err)
 }
+
+func TestFileWatcher_Rename(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	config := &Config{
+		IgnorePatterns: []string{"*.tmp"},
+		DebounceDelay:  100 * time.Millisecond,
+		FileExtensions: []string{".xlsx"},
+	}
+
+	eventChan := make(chan FileEvent, 10)
+	handler := func(event FileEvent) error {
+		eventChan <- event
+		return nil
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	tempDir := t.TempDir()
+	oldFile := filepath.Join(tempDir, "old.xlsx")
+	require.NoError(t, os.WriteFile(oldFile, []byte("test content"), 0600))
+
+	fw, err := NewFileWatcher(config, handler, logger)
+	require.NoError(t, err)
+	defer func() { _ = fw.Stop() }()
+	require.NoError(t, fw.AddDirectory(tempDir))
+	require.NoError(t, fw.Start())
+	time.Sleep(100 * time.Millisecond)
+
+	waitFor := func(t *testing.T) FileEvent {
+		select {
+		case event := <-eventChan:
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timeout waiting for file event")
+			return FileEvent{}
+		}
+	}
+
+	t.Run("rename is reported once with the old path", func(t *testing.T) {
+		newFile := filepath.Join(tempDir, "new.xlsx")
+		require.NoError(t, os.Rename(oldFile, newFile))
+
+		event := waitFor(t)
+		assert.Equal(t, EventTypeRename, event.Type)
+		assert.Equal(t, newFile, event.Path)
+		assert.Equal(t, oldFile, event.OldPath)
+		oldFile = newFile
+	})
+
+	t.Run("replacing a file under its own name is a modify", func(t *testing.T) {
+		// Excel saves by moving the workbook aside and renaming a temporary
+		// file over it
+		require.NoError(t, os.Rename(oldFile, filepath.Join(tempDir, "backup.tmp")))
+		temp := filepath.Join(tempDir, "A1B2C3D4")
+		require.NoError(t, os.WriteFile(temp, []byte("saved content"), 0600))
+		require.NoError(t, os.Rename(temp, oldFile))
+
+		event := waitFor(t)
+		assert.Equal(t, EventTypeModify, event.Type)
+		assert.Equal(t, oldFile, event.Path)
+	})
+
+	t.Run("rename to an unwatched name is a delete", func(t *testing.T) {
+		require.NoError(t, os.Rename(oldFile, filepath.Join(tempDir, "old.bak")))
+
+		event := waitFor(t)
+		assert.Equal(t, EventTypeDelete, event.Type)
+		assert.Equal(t, oldFile, event.Path)
+	})
+}
+
+func TestFileWatcher_WaitForUnlock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	config := &Config{
+		DebounceDelay:     100 * time.Millisecond,
+		FileExtensions:    []string{".xlsx"},
+		StabilityInterval: 20 * time.Millisecond,
+		WaitForUnlock:     true,
+	}
+
+	eventChan := make(chan FileEvent, 10)
+	handler := func(event FileEvent) error {
+		eventChan <- event
+		return nil
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	tempDir := t.TempDir()
+	fw, err := NewFileWatcher(config, handler, logger)
+	require.NoError(t, err)
+	defer func() { _ = fw.Stop() }()
+	require.NoError(t, fw.AddDirectory(tempDir))
+	require.NoError(t, fw.Start())
+	time.Sleep(100 * time.Millisecond)
+
+	// LibreOffice holds a lock file while the workbook is open
+	workbook := filepath.Join(tempDir, "Budget.xlsx")
+	lockFile := filepath.Join(tempDir, ".~lock.Budget.xlsx#")
+	require.NoError(t, os.WriteFile(lockFile, []byte("user"), 0600))
+	require.NoError(t, os.WriteFile(workbook, zipBytes(t), 0600))
+
+	select {
+	case event := <-eventChan:
+		t.Fatalf("Unexpected event while the workbook is open: %s %s", event.Type, event.Path)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	require.NoError(t, os.Remove(lockFile))
+
+	select {
+	case event := <-eventChan:
+		assert.Equal(t, workbook, event.Path)
+		assert.Contains(t, []EventType{EventTypeCreate, EventTypeModify}, event.Type)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for file event after the lock file was removed")
+	}
+}
+
+func TestFileWatcher_NewDirectories(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	config := &Config{
+		DebounceDelay:     50 * time.Millisecond,
+		FileExtensions:    []string{".xlsx"},
+		StabilityInterval: 10 * time.Millisecond,
+	}
+
+	eventChan := make(chan FileEvent, 10)
+	handler := func(event FileEvent) error {
+		eventChan <- event
+		return nil
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	tempDir := t.TempDir()
+	fw, err := NewFileWatcher(config, handler, logger)
+	require.NoError(t, err)
+	defer func() { _ = fw.Stop() }()
+	require.NoError(t, fw.AddDirectory(tempDir))
+	require.NoError(t, fw.Start())
+	time.Sleep(100 * time.Millisecond)
+
+	waitFor := func(t *testing.T) FileEvent {
+		select {
+		case event := <-eventChan:
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timeout waiting for file event")
+			return FileEvent{}
+		}
+	}
+
+	t.Run("workbooks saved in a new directory are seen", func(t *testing.T) {
+		dir := filepath.Join(tempDir, "q1")
+		require.NoError(t, os.Mkdir(dir, 0750))
+		require.Eventually(t, func() bool { return fw.IsWatching(dir) }, 2*time.Second, 10*time.Millisecond)
+
+		workbook := filepath.Join(dir, "budget.xlsx")
+		require.NoError(t, os.WriteFile(workbook, zipBytes(t), 0600))
+		event := waitFor(t)
+		assert.Equal(t, workbook, event.Path)
+	})
+
+	t.Run("workbooks in a directory moved in are scanned", func(t *testing.T) {
+		outside := filepath.Join(t.TempDir(), "q2")
+		require.NoError(t, os.MkdirAll(filepath.Join(outside, "nested"), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(outside, "nested", "sales.xlsx"), zipBytes(t), 0600))
+
+		dir := filepath.Join(tempDir, "q2")
+		require.NoError(t, os.Rename(outside, dir))
+		event := waitFor(t)
+		assert.Equal(t, EventTypeCreate, event.Type)
+		assert.Equal(t, filepath.Join(dir, "nested", "sales.xlsx"), event.Path)
+		assert.True(t, fw.IsWatching(filepath.Join(dir, "nested")))
+	})
+
+	t.Run("removed directories are no longer watched", func(t *testing.T) {
+		dir := filepath.Join(tempDir, "empty", "sub")
+		require.NoError(t, os.MkdirAll(dir, 0750))
+		require.Eventually(t, func() bool { return fw.IsWatching(dir) }, 2*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, os.RemoveAll(filepath.Join(tempDir, "empty")))
+		require.Eventually(t, func() bool {
+			return !fw.IsWatching(dir) && !fw.IsWatching(filepath.Join(tempDir, "empty"))
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestFileWatcher_Workbooks(t *testing.T) {
+	config := &Config{
+		IgnorePatterns: []string{"*_old.xlsx"},
+		FileExtensions: []string{".xlsx"},
+	}
+	fw, err := NewFileWatcher(config, func(FileEvent) error { return nil }, logrus.New())
+	require.NoError(t, err)
+	defer func() { _ = fw.Stop() }()
+
+	tempDir := t.TempDir()
+	for _, name := range []string{
+		"budget.xlsx",
+		"notes.txt",
+		"~$budget.xlsx",
+		"budget_old.xlsx",
+		filepath.Join("q1", "sales.xlsx"),
+		filepath.Join(".gitcells", "archive", "gone.xlsx"),
+	} {
+		path := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0600))
+	}
+
+	assert.ElementsMatch(t, []string{
+		filepath.Join(tempDir, "budget.xlsx"),
+		filepath.Join(tempDir, "q1", "sales.xlsx"),
+	}, fw.Workbooks(tempDir))
+}
+
+func TestFileWatcher_Reconfigure(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, dir := range []string{"archive", "reports", filepath.Join("reports", "old")} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, dir), 0750))
+	}
+
+	config := &Config{
+		IgnorePatterns: []string{"archive"},
+		DebounceDelay:  time.Second,
+		FileExtensions: []string{".xlsx"},
+	}
+	fw, err := NewFileWatcher(config, func(FileEvent) error { return nil }, logrus.New())
+	require.NoError(t, err)
+	defer func() { _ = fw.Stop() }()
+	require.NoError(t, fw.AddDirectory(tempDir))
+
+	assert.False(t, fw.IsWatching(filepath.Join(tempDir, "archive")))
+	assert.True(t, fw.IsWatching(filepath.Join(tempDir, "reports", "old")))
+	assert.False(t, fw.shouldProcessFile(filepath.Join(tempDir, "budget.xlsm")))
+
+	fw.Reconfigure(&Config{
+		IgnorePatterns: []string{"old"},
+		DebounceDelay:  3 * time.Second,
+		FileExtensions: []string{".xlsx", ".xlsm"},
+		Backend:        BackendPoll,
+	})
+
+	assert.True(t, fw.IsWatching(filepath.Join(tempDir, "archive")))
+	assert.True(t, fw.IsWatching(filepath.Join(tempDir, "reports")))
+	assert.False(t, fw.IsWatching(filepath.Join(tempDir, "reports", "old")))
+	assert.True(t, fw.shouldProcessFile(filepath.Join(tempDir, "budget.xlsm")))
+	assert.Equal(t, 3*time.Second, fw.debouncer.GetDelay())
+	assert.Empty(t, fw.currentConfig().Backend, "the backend cannot change while watching")
+}
+
+func TestFileWatcher_IgnoreFile(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() { _ = os.Chdir(originalDir) }()
+	require.NoError(t, os.Chdir(tempDir))
+	require.NoError(t, os.MkdirAll(filepath.Join("drafts", "q1"), 0750))
+
+	config := &Config{
+		IgnorePatterns: []string{"archive/**/*.xlsx", "!archive/**/keep.xlsx"},
+		DebounceDelay:  50 * time.Millisecond,
+		FileExtensions: []string{".xlsx"},
+	}
+	fw, err := NewFileWatcher(config, func(FileEvent) error { return nil }, logrus.New())
+	require.NoError(t, err)
+	defer func() { _ = fw.Stop() }()
+
+	assert.False(t, fw.shouldProcessFile(filepath.Join("archive", "2023", "old.xlsx")))
+	assert.True(t, fw.shouldProcessFile(filepath.Join("archive", "2023", "keep.xlsx")))
+	assert.True(t, fw.shouldProcessFile(filepath.Join("reports", "archive", "2023", "old.xlsx")))
+
+	require.NoError(t, fw.AddDirectory("."))
+	require.NoError(t, fw.Start())
+	assert.True(t, fw.IsWatching(filepath.Join("drafts", "q1")))
+
+	// Saving a .gitcellsignore file applies it straight away
+	require.NoError(t, os.WriteFile(ignore.FileName, []byte("drafts/\n"), 0600))
+	require.Eventually(t, func() bool {
+		return !fw.IsWatching("drafts") && !fw.IsWatching(filepath.Join("drafts", "q1"))
+	}, 2*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(ignore.FileName, []byte("drafts/\n!drafts/\n"), 0600))
+	require.Eventually(t, func() bool {
+		return fw.IsWatching(filepath.Join("drafts", "q1"))
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestFileWatcher_Notify(t *testing.T) {
+	tempDir := t.TempDir()
+	workbook := filepath.Join(tempDir, "a.xlsx")
+	require.NoError(t, os.WriteFile(workbook, []byte("test"), 0600))
+
+	var mu sync.Mutex
+	running, maxRunning, handled := 0, 0, 0
+	release := make(chan struct{})
+	handler := func(event FileEvent) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		running--
+		handled++
+		mu.Unlock()
+		return nil
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	fw, err := NewFileWatcher(&Config{
+		DebounceDelay:     10 * time.Millisecond,
+		FileExtensions:    []string{".xlsx"},
+		StabilityInterval: 10 * time.Millisecond,
+		MaxConcurrency:    4,
+	}, handler, logger)
+	require.NoError(t, err)
+	defer func() { _ = fw.Stop() }()
+	assert.True(t, fw.Idle())
+
+	// Events found other than by watching go through the scheduler, so a
+	// workbook is still converted one event at a time
+	fw.Notify(FileEvent{Path: workbook, Type: EventTypeModify})
+	assert.False(t, fw.Idle())
+	require.Eventually(t, func() bool {
+		_, active := fw.QueueDepth()
+		return active == 1
+	}, time.Second, 5*time.Millisecond)
+	fw.Notify(FileEvent{Path: workbook, Type: EventTypeModify})
+	require.Eventually(t, func() bool {
+		queued, _ := fw.QueueDepth()
+		return queued == 1
+	}, time.Second, 5*time.Millisecond)
+
+	close(release)
+	require.Eventually(t, fw.Idle, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, handled)
+	assert.Equal(t, 1, maxRunning)
+}
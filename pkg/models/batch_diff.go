@@ -0,0 +1,244 @@
+package models
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// BatchDiff aggregates workbook diffs across two directories or revisions
+type BatchDiff struct {
+	Timestamp time.Time        `json:"timestamp"`
+	OldLabel  string           `json:"old_label"`
+	NewLabel  string           `json:"new_label"`
+	Summary   BatchDiffSummary `json:"summary"`
+	Files     []WorkbookDiff   `json:"files"`
+
+	// KeepDocuments retains both versions of each workbook added through
+	// AddComparison so that ToHTMLReport can render them as grids
+	KeepDocuments bool `json:"-"`
+}
+
+// BatchDiffSummary counts workbook-level results of a batch diff
+type BatchDiffSummary struct {
+	FilesCompared  int `json:"files_compared"`
+	AddedFiles     int `json:"added_files"`
+	ModifiedFiles  int `json:"modified_files"`
+	DeletedFiles   int `json:"deleted_files"`
+	UnchangedFiles int `json:"unchanged_files"`
+	FailedFiles    int `json:"failed_files,omitempty"`
+	CellChanges    int `json:"cell_changes"`
+}
+
+// WorkbookDiff is the result for a single workbook in a batch diff.
+// Status is empty for unchanged workbooks.
+type WorkbookDiff struct {
+	Path   string     `json:"path"`
+	Status ChangeType `json:"status,omitempty"`
+	Diff   *ExcelDiff `json:"diff,omitempty"`
+	Error  string     `json:"error,omitempty"`
+
+	Old *ExcelDocument `json:"-"`
+	New *ExcelDocument `json:"-"`
+}
+
+// NewBatchDiff creates an empty batch diff between two labelled sides
+func NewBatchDiff(oldLabel, newLabel string) *BatchDiff {
+	return &BatchDiff{
+		Timestamp: time.Now(),
+		OldLabel:  oldLabel,
+		NewLabel:  newLabel,
+		Files:     []WorkbookDiff{},
+	}
+}
+
+// AddWorkbook records the diff for a workbook. hasOld and hasNew describe
+// whether the workbook exists on each side of the comparison.
+func (b *BatchDiff) AddWorkbook(path string, diff *ExcelDiff, hasOld, hasNew bool) {
+	entry := WorkbookDiff{Path: path, Diff: diff}
+
+	switch {
+	case !hasOld && hasNew:
+		entry.Status = ChangeTypeAdd
+		b.Summary.AddedFiles++
+	case hasOld && !hasNew:
+		entry.Status = ChangeTypeDelete
+		b.Summary.DeletedFiles++
+	case diff != nil && diff.HasChanges():
+		entry.Status = ChangeTypeModify
+		b.Summary.ModifiedFiles++
+	default:
+		b.Summary.UnchangedFiles++
+	}
+
+	if diff != nil {
+		b.Summary.CellChanges += diff.Summary.CellChanges
+	}
+	b.Summary.FilesCompared++
+	b.Files = append(b.Files, entry)
+}
+
+// AddComparison records the diff for a workbook given both of its versions.
+// A nil document means the workbook does not exist on that side.
+func (b *BatchDiff) AddComparison(path string, oldDoc, newDoc *ExcelDocument, diff *ExcelDiff) {
+	b.AddWorkbook(path, diff, oldDoc != nil, newDoc != nil)
+	if b.KeepDocuments {
+		entry := &b.Files[len(b.Files)-1]
+		entry.Old, entry.New = oldDoc, newDoc
+	}
+}
+
+// AddError records a workbook that could not be compared
+func (b *BatchDiff) AddError(path string, err error) {
+	b.Summary.FailedFiles++
+	b.Summary.FilesCompared++
+	b.Files = append(b.Files, WorkbookDiff{Path: path, Error: err.Error()})
+}
+
+// HasChanges returns true if any workbook was added, modified or deleted
+func (b *BatchDiff) HasChanges() bool {
+	return b.Summary.AddedFiles+b.Summary.ModifiedFiles+b.Summary.DeletedFiles > 0
+}
+
+// String returns a one-line summary of the batch diff
+func (b *BatchDiff) String() string {
+	if !b.HasChanges() && b.Summary.FailedFiles == 0 {
+		return noChangesMsg
+	}
+
+	var parts []string
+	if b.Summary.AddedFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d workbook(s) added", b.Summary.AddedFiles))
+	}
+	if b.Summary.ModifiedFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d workbook(s) modified", b.Summary.ModifiedFiles))
+	}
+	if b.Summary.DeletedFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d workbook(s) deleted", b.Summary.DeletedFiles))
+	}
+	if b.Summary.FailedFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d workbook(s) failed", b.Summary.FailedFiles))
+	}
+	if b.Summary.CellChanges > 0 {
+		parts = append(parts, fmt.Sprintf("%d cell(s) changed", b.Summary.CellChanges))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ToHTML returns a complete HTML document with a per-file summary table
+// followed by the detailed diff of every changed workbook
+func (b *BatchDiff) ToHTML() string {
+	var result strings.Builder
+
+	result.WriteString("<!DOCTYPE html>\n<html><head><meta charset='utf-8'>")
+	result.WriteString(fmt.Sprintf("<title>GitCells diff: %s → %s</title>",
+		html.EscapeString(b.OldLabel), html.EscapeString(b.NewLabel)))
+	result.WriteString("<style>" + GetDiffCSS() + GetBatchDiffCSS() + "</style></head><body>")
+
+	result.WriteString("<div class='batch-diff'>")
+	result.WriteString(fmt.Sprintf("<h1>%s → %s</h1>", html.EscapeString(b.OldLabel), html.EscapeString(b.NewLabel)))
+	result.WriteString(fmt.Sprintf("<p class='summary'>%s</p>", html.EscapeString(b.String())))
+	result.WriteString(fmt.Sprintf("<p class='timestamp'>Generated %s</p>", b.Timestamp.Format("2006-01-02 15:04:05")))
+
+	result.WriteString("<table class='files'><thead><tr><th>Workbook</th><th>Status</th><th>Changes</th></tr></thead><tbody>")
+	for i, file := range b.Files {
+		status, details := file.describe()
+		name := html.EscapeString(file.Path)
+		if file.Status != "" {
+			name = fmt.Sprintf("<a href='#file-%d'>%s</a>", i, name)
+		}
+		result.WriteString(fmt.Sprintf("<tr class='%s'><td>%s</td><td>%s</td><td>%s</td></tr>",
+			status, name, status, html.EscapeString(details)))
+	}
+	result.WriteString("</tbody></table>")
+
+	for i, file := range b.Files {
+		if file.Status == "" || file.Diff == nil {
+			continue
+		}
+		result.WriteString(fmt.Sprintf("<section id='file-%d'><h2>%s</h2>", i, html.EscapeString(file.Path)))
+		result.WriteString(file.Diff.ToHTML())
+		result.WriteString("</section>")
+	}
+
+	result.WriteString("</div></body></html>\n")
+	return result.String()
+}
+
+// ToHTMLReport returns a standalone report rendering every changed sheet as
+// side-by-side grids. Workbooks need KeepDocuments to be rendered in full.
+func (b *BatchDiff) ToHTMLReport() string {
+	report := HTMLReport{
+		Title:     fmt.Sprintf("GitCells diff: %s → %s", b.OldLabel, b.NewLabel),
+		OldLabel:  b.OldLabel,
+		NewLabel:  b.NewLabel,
+		Workbooks: make([]ReportWorkbook, 0, len(b.Files)),
+	}
+	for _, file := range b.Files {
+		report.Workbooks = append(report.Workbooks, ReportWorkbook{
+			Path:  file.Path,
+			Old:   file.Old,
+			New:   file.New,
+			Diff:  file.Diff,
+			Error: file.Error,
+		})
+	}
+	return GenerateHTMLReport(report)
+}
+
+// describe returns a status label and a short change summary for the file
+func (w WorkbookDiff) describe() (string, string) {
+	switch {
+	case w.Error != "":
+		return "error", w.Error
+	case w.Status == "":
+		return "unchanged", noChangesMsg
+	case w.Diff != nil:
+		return string(w.Status), w.Diff.String()
+	default:
+		return string(w.Status), ""
+	}
+}
+
+// GetBatchDiffCSS returns CSS styles for the batch diff summary table
+func GetBatchDiffCSS() string {
+	return `
+.batch-diff {
+	font-family: -apple-system, 'Segoe UI', Helvetica, Arial, sans-serif;
+	margin: 20px;
+}
+
+.batch-diff .timestamp {
+	color: #6c757d;
+}
+
+.batch-diff table.files {
+	border-collapse: collapse;
+	margin: 15px 0;
+	min-width: 60%;
+}
+
+.batch-diff table.files th, .batch-diff table.files td {
+	border: 1px solid #dee2e6;
+	padding: 6px 12px;
+	text-align: left;
+}
+
+.batch-diff tr.add td:nth-child(2) {
+	color: #28a745;
+}
+
+.batch-diff tr.modify td:nth-child(2) {
+	color: #b8860b;
+}
+
+.batch-diff tr.delete td:nth-child(2), .batch-diff tr.error td:nth-child(2) {
+	color: #dc3545;
+}
+
+.batch-diff tr.unchanged {
+	color: #6c757d;
+}
+`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchDiff_AddWorkbook(t *testing.T) {
+	batch := NewBatchDiff("main", "feature")
+
+	unchanged := ComputeDiff(createTestDocument(), createTestDocument())
+	batch.AddWorkbook("same.xlsx", unchanged, true, true)
+
+	modifiedDoc := createTestDocument()
+	modifiedDoc.Sheets[0].Cells["A1"] = Cell{Value: "Changed", Type: CellTypeString}
+	batch.AddWorkbook("changed.xlsx", ComputeDiff(createTestDocument(), modifiedDoc), true, true)
+
+	batch.AddWorkbook("added.xlsx", ComputeDiff(&ExcelDocument{}, createTestDocument()), false, true)
+	batch.AddWorkbook("removed.xlsx", ComputeDiff(createTestDocument(), &ExcelDocument{}), true, false)
+	batch.AddError("broken.xlsx", errors.New("corrupt chunk"))
+
+	require.Len(t, batch.Files, 5)
+	assert.Equal(t, ChangeType(""), batch.Files[0].Status)
+	assert.Equal(t, ChangeTypeModify, batch.Files[1].Status)
+	assert.Equal(t, ChangeTypeAdd, batch.Files[2].Status)
+	assert.Equal(t, ChangeTypeDelete, batch.Files[3].Status)
+	assert.Equal(t, "corrupt chunk", batch.Files[4].Error)
+
+	assert.Equal(t, 5, batch.Summary.FilesCompared)
+	assert.Equal(t, 1, batch.Summary.AddedFiles)
+	assert.Equal(t, 1, batch.Summary.ModifiedFiles)
+	assert.Equal(t, 1, batch.Summary.DeletedFiles)
+	assert.Equal(t, 1, batch.Summary.UnchangedFiles)
+	assert.Equal(t, 1, batch.Summary.FailedFiles)
+	assert.Equal(t, 3, batch.Summary.CellChanges)
+
+	assert.True(t, batch.HasChanges())
+	assert.Equal(t, "1 workbook(s) added, 1 workbook(s) modified, 1 workbook(s) deleted, 1 workbook(s) failed, 3 cell(s) changed", batch.String())
+}
+
+func TestBatchDiff_NoChanges(t *testing.T) {
+	batch := NewBatchDiff("a", "b")
+	batch.AddWorkbook("same.xlsx", ComputeDiff(createTestDocument(), createTestDocument()), true, true)
+
+	assert.False(t, batch.HasChanges())
+	assert.Equal(t, "No changes detected", batch.String())
+}
+
+func TestBatchDiff_ToHTML(t *testing.T) {
+	batch := NewBatchDiff("v1", "<v2>")
+	modifiedDoc := createTestDocument()
+	modifiedDoc.Sheets[0].Cells["A1"] = Cell{Value: "Changed", Type: CellTypeString}
+	batch.AddWorkbook("reports/a&b.xlsx", ComputeDiff(createTestDocument(), modifiedDoc), true, true)
+	batch.AddWorkbook("same.xlsx", ComputeDiff(createTestDocument(), createTestDocument()), true, true)
+
+	output := batch.ToHTML()
+
+	assert.Contains(t, output, "<!DOCTYPE html>")
+	assert.Contains(t, output, "v1 → &lt;v2&gt;")
+	assert.Contains(t, output, "<a href='#file-0'>reports/a&amp;b.xlsx</a>")
+	assert.Contains(t, output, "<section id='file-0'>")
+	assert.Contains(t, output, "<tr class='unchanged'><td>same.xlsx</td>")
+	assert.NotContains(t, output, "<section id='file-1'>")
+}
@@ -0,0 +1,268 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Changelog summarises the workbook changes made by the commits between two
+// revisions, grouped by workbook and sheet
+type Changelog struct {
+	From      string              `json:"from"`
+	To        string              `json:"to"`
+	Generated time.Time           `json:"generated"`
+	Summary   ChangelogSummary    `json:"summary"`
+	Workbooks []WorkbookChangelog `json:"workbooks"`
+
+	commits map[string]bool
+}
+
+// ChangelogSummary totals a changelog over all workbooks
+type ChangelogSummary struct {
+	Workbooks int      `json:"workbooks"`
+	Commits   int      `json:"commits"`
+	Authors   []string `json:"authors"`
+	ChangeCounts
+}
+
+// ChangeCounts counts cell, formula and sheet changes
+type ChangeCounts struct {
+	CellChanges    int `json:"cell_changes"`
+	FormulaChanges int `json:"formula_changes"`
+	SheetsAdded    int `json:"sheets_added"`
+	SheetsModified int `json:"sheets_modified"`
+	SheetsDeleted  int `json:"sheets_deleted"`
+}
+
+// WorkbookChangelog lists the changes to one workbook
+type WorkbookChangelog struct {
+	Path    string            `json:"path"`
+	Authors []string          `json:"authors"`
+	Sheets  []SheetChangelog  `json:"sheets"`
+	Commits []ChangelogCommit `json:"commits"`
+	ChangeCounts
+}
+
+// SheetChangelog totals the changes to one sheet. Status is add or delete
+// if the sheet was added or deleted within the range, otherwise modify.
+type SheetChangelog struct {
+	Name           string     `json:"name"`
+	Status         ChangeType `json:"status"`
+	CellChanges    int        `json:"cell_changes"`
+	FormulaChanges int        `json:"formula_changes"`
+	Commits        int        `json:"commits"`
+}
+
+// ChangelogCommit is one commit's changes to a workbook
+type ChangelogCommit struct {
+	Hash           string    `json:"hash"`
+	Author         string    `json:"author"`
+	Date           time.Time `json:"date"`
+	Subject        string    `json:"subject"`
+	CellChanges    int       `json:"cell_changes"`
+	FormulaChanges int       `json:"formula_changes"`
+}
+
+// NewChangelog creates an empty changelog between two labelled revisions
+func NewChangelog(from, to string) *Changelog {
+	return &Changelog{
+		From:      from,
+		To:        to,
+		Generated: time.Now(),
+		Summary:   ChangelogSummary{Authors: []string{}},
+		Workbooks: []WorkbookChangelog{},
+		commits:   make(map[string]bool),
+	}
+}
+
+// Add records a commit's diff of a workbook. Commits must be added oldest
+// first; diffs without changes are ignored.
+func (c *Changelog) Add(path string, commit ChangelogCommit, diff *ExcelDiff) {
+	if diff == nil || !diff.HasChanges() {
+		return
+	}
+
+	workbook := c.workbook(path)
+	for _, sheetDiff := range diff.SheetDiffs {
+		sheet := workbook.sheet(sheetDiff.SheetName)
+		sheet.Commits++
+		sheet.CellChanges += len(sheetDiff.Changes)
+		formulas := countFormulaChanges(sheetDiff.Changes)
+		sheet.FormulaChanges += formulas
+		commit.CellChanges += len(sheetDiff.Changes)
+		commit.FormulaChanges += formulas
+
+		switch {
+		case sheetDiff.Action == ChangeTypeDelete:
+			sheet.Status = ChangeTypeDelete
+		case sheetDiff.Action == ChangeTypeAdd && sheet.Status == "":
+			sheet.Status = ChangeTypeAdd
+		case sheetDiff.Action == ChangeTypeAdd || sheet.Status == "":
+			// A sheet deleted and added again within the range was modified
+			sheet.Status = ChangeTypeModify
+		}
+	}
+
+	workbook.Commits = append(workbook.Commits, commit)
+	workbook.Authors = appendAuthor(workbook.Authors, commit.Author)
+	workbook.ChangeCounts = sheetCounts(workbook.Sheets)
+
+	c.Summary.Authors = appendAuthor(c.Summary.Authors, commit.Author)
+	if !c.commits[commit.Hash] {
+		c.commits[commit.Hash] = true
+		c.Summary.Commits++
+	}
+	c.Summary.Workbooks = len(c.Workbooks)
+	c.Summary.ChangeCounts = ChangeCounts{}
+	for _, w := range c.Workbooks {
+		c.Summary.ChangeCounts.add(w.ChangeCounts)
+	}
+}
+
+// workbook returns the entry for path, inserting it in path order
+func (c *Changelog) workbook(path string) *WorkbookChangelog {
+	i := sort.Search(len(c.Workbooks), func(i int) bool { return c.Workbooks[i].Path >= path })
+	if i == len(c.Workbooks) || c.Workbooks[i].Path != path {
+		c.Workbooks = append(c.Workbooks, WorkbookChangelog{})
+		copy(c.Workbooks[i+1:], c.Workbooks[i:])
+		c.Workbooks[i] = WorkbookChangelog{Path: path, Authors: []string{}, Sheets: []SheetChangelog{}, Commits: []ChangelogCommit{}}
+	}
+	return &c.Workbooks[i]
+}
+
+// sheet returns the entry for a sheet, inserting it in name order
+func (w *WorkbookChangelog) sheet(name string) *SheetChangelog {
+	i := sort.Search(len(w.Sheets), func(i int) bool { return w.Sheets[i].Name >= name })
+	if i == len(w.Sheets) || w.Sheets[i].Name != name {
+		w.Sheets = append(w.Sheets, SheetChangelog{})
+		copy(w.Sheets[i+1:], w.Sheets[i:])
+		w.Sheets[i] = SheetChangelog{Name: name}
+	}
+	return &w.Sheets[i]
+}
+
+// sheetCounts totals the sheets of a workbook
+func sheetCounts(sheets []SheetChangelog) ChangeCounts {
+	var counts ChangeCounts
+	for _, sheet := range sheets {
+		counts.CellChanges += sheet.CellChanges
+		counts.FormulaChanges += sheet.FormulaChanges
+		switch sheet.Status {
+		case ChangeTypeAdd:
+			counts.SheetsAdded++
+		case ChangeTypeDelete:
+			counts.SheetsDeleted++
+		default:
+			counts.SheetsModified++
+		}
+	}
+	return counts
+}
+
+func (c *ChangeCounts) add(other ChangeCounts) {
+	c.CellChanges += other.CellChanges
+	c.FormulaChanges += other.FormulaChanges
+	c.SheetsAdded += other.SheetsAdded
+	c.SheetsModified += other.SheetsModified
+	c.SheetsDeleted += other.SheetsDeleted
+}
+
+// String returns the counts as a comma-separated list, leaving out zero
+// sheet and formula counts
+func (c ChangeCounts) String() string {
+	parts := []string{fmt.Sprintf("%d cell change(s)", c.CellChanges)}
+	if c.FormulaChanges > 0 {
+		parts = append(parts, fmt.Sprintf("%d formula change(s)", c.FormulaChanges))
+	}
+	if c.SheetsAdded > 0 {
+		parts = append(parts, fmt.Sprintf("%d sheet(s) added", c.SheetsAdded))
+	}
+	if c.SheetsModified > 0 {
+		parts = append(parts, fmt.Sprintf("%d sheet(s) modified", c.SheetsModified))
+	}
+	if c.SheetsDeleted > 0 {
+		parts = append(parts, fmt.Sprintf("%d sheet(s) deleted", c.SheetsDeleted))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// countFormulaChanges counts the changes that add, remove or edit a formula
+func countFormulaChanges(changes []CellChange) int {
+	count := 0
+	for _, change := range changes {
+		if change.OldFormula != change.NewFormula {
+			count++
+		}
+	}
+	return count
+}
+
+// appendAuthor appends author to authors unless it is already listed
+func appendAuthor(authors []string, author string) []string {
+	for _, existing := range authors {
+		if existing == author {
+			return authors
+		}
+	}
+	return append(authors, author)
+}
+
+// ToMarkdown renders the changelog as Markdown for release notes
+func (c *Changelog) ToMarkdown() string {
+	var b strings.Builder
+
+	from := c.From
+	if from == "" {
+		from = "the beginning"
+	}
+	fmt.Fprintf(&b, "# Changelog: %s → %s\n\n", from, c.To)
+
+	if len(c.Workbooks) == 0 {
+		b.WriteString("No workbook changes.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "%d workbook(s), %d commit(s) by %s: %s\n",
+		c.Summary.Workbooks, c.Summary.Commits, strings.Join(c.Summary.Authors, ", "), c.Summary.ChangeCounts)
+
+	for _, workbook := range c.Workbooks {
+		fmt.Fprintf(&b, "\n## %s\n\n", workbook.Path)
+		fmt.Fprintf(&b, "%d commit(s) by %s: %s\n\n",
+			len(workbook.Commits), strings.Join(workbook.Authors, ", "), workbook.ChangeCounts)
+
+		b.WriteString("| Sheet | Status | Cells | Formulas | Commits |\n")
+		b.WriteString("|-------|--------|------:|---------:|--------:|\n")
+		for _, sheet := range workbook.Sheets {
+			fmt.Fprintf(&b, "| %s | %s | %d | %d | %d |\n",
+				strings.ReplaceAll(sheet.Name, "|", `\|`), sheetStatusLabel(sheet.Status),
+				sheet.CellChanges, sheet.FormulaChanges, sheet.Commits)
+		}
+
+		b.WriteString("\n")
+		for _, commit := range workbook.Commits {
+			hash := commit.Hash
+			if len(hash) > 7 {
+				hash = hash[:7]
+			}
+			fmt.Fprintf(&b, "- %s `%s` %s (%s; %d cell(s), %d formula(s))\n",
+				commit.Date.Format("2006-01-02"), hash, commit.Subject, commit.Author,
+				commit.CellChanges, commit.FormulaChanges)
+		}
+	}
+
+	return b.String()
+}
+
+// sheetStatusLabel returns the word used for a sheet status in Markdown
+func sheetStatusLabel(status ChangeType) string {
+	switch status {
+	case ChangeTypeAdd:
+		return "added"
+	case ChangeTypeDelete:
+		return "deleted"
+	default:
+		return "modified"
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangelog_Add(t *testing.T) {
+	day := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	changelog := NewChangelog("v1", "v2")
+
+	// Edit a value and a formula
+	edited := createTestDocument()
+	edited.Sheets[0].Cells["A1"] = Cell{Value: "Changed", Type: CellTypeString}
+	edited.Sheets[0].Cells["B1"] = Cell{Value: 2, Formula: "1+1", Type: CellTypeNumber}
+	changelog.Add("Budget.xlsx", ChangelogCommit{Hash: "aaaaaaaaaa", Author: "Alice", Date: day, Subject: "Update prices"},
+		ComputeDiff(createTestDocument(), edited))
+
+	// Add a sheet
+	withSheet := createTestDocument()
+	withSheet.Sheets = append(withSheet.Sheets, Sheet{Name: "Q4", Cells: map[string]Cell{"A1": {Value: "x"}}})
+	changelog.Add("Budget.xlsx", ChangelogCommit{Hash: "bbbbbbbbbb", Author: "Bob", Date: day, Subject: "Add Q4"},
+		ComputeDiff(createTestDocument(), withSheet))
+	changelog.Add("Alpha.xlsx", ChangelogCommit{Hash: "bbbbbbbbbb", Author: "Bob", Date: day, Subject: "Add Q4"},
+		ComputeDiff(createTestDocument(), edited))
+
+	// Unchanged diffs are ignored
+	changelog.Add("Same.xlsx", ChangelogCommit{Hash: "cccccccccc", Author: "Carol"},
+		ComputeDiff(createTestDocument(), createTestDocument()))
+
+	require.Len(t, changelog.Workbooks, 2)
+	assert.Equal(t, "Alpha.xlsx", changelog.Workbooks[0].Path)
+
+	budget := changelog.Workbooks[1]
+	assert.Equal(t, []string{"Alice", "Bob"}, budget.Authors)
+	assert.Equal(t, ChangeCounts{CellChanges: 3, FormulaChanges: 1, SheetsAdded: 1, SheetsModified: 1}, budget.ChangeCounts)
+	require.Len(t, budget.Sheets, 2)
+	assert.Equal(t, SheetChangelog{Name: "Q4", Status: ChangeTypeAdd, CellChanges: 1, Commits: 1}, budget.Sheets[0])
+	assert.Equal(t, SheetChangelog{Name: "Test Sheet", Status: ChangeTypeModify, CellChanges: 2, FormulaChanges: 1, Commits: 1}, budget.Sheets[1])
+	assert.Equal(t, 2, budget.Commits[0].CellChanges)
+	assert.Equal(t, 1, budget.Commits[0].FormulaChanges)
+
+	assert.Equal(t, 2, changelog.Summary.Workbooks)
+	assert.Equal(t, 2, changelog.Summary.Commits)
+	assert.Equal(t, []string{"Alice", "Bob"}, changelog.Summary.Authors)
+	assert.Equal(t, 5, changelog.Summary.CellChanges)
+	assert.Equal(t, 2, changelog.Summary.FormulaChanges)
+}
+
+func TestChangelog_SheetStatus(t *testing.T) {
+	withSheet := createTestDocument()
+	withSheet.Sheets = append(withSheet.Sheets, Sheet{Name: "Q4", Cells: map[string]Cell{"A1": {Value: "x"}}})
+
+	changelog := NewChangelog("", "HEAD")
+	changelog.Add("Budget.xlsx", ChangelogCommit{Hash: "a"}, ComputeDiff(withSheet, createTestDocument()))
+	assert.Equal(t, ChangeTypeDelete, changelog.Workbooks[0].Sheets[0].Status)
+
+	changelog.Add("Budget.xlsx", ChangelogCommit{Hash: "b"}, ComputeDiff(createTestDocument(), withSheet))
+	assert.Equal(t, ChangeTypeModify, changelog.Workbooks[0].Sheets[0].Status, "deleted and added again")
+}
+
+func TestChangelog_ToMarkdown(t *testing.T) {
+	assert.Contains(t, NewChangelog("", "HEAD").ToMarkdown(), "# Changelog: the beginning → HEAD\n\nNo workbook changes.")
+
+	edited := createTestDocument()
+	edited.Sheets[0].Name = "A|B"
+	changelog := NewChangelog("v1", "v2")
+	changelog.Add("Budget.xlsx", ChangelogCommit{
+		Hash:    "0123456789abcdef",
+		Author:  "Alice",
+		Date:    time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC),
+		Subject: "Update prices",
+	}, ComputeDiff(createTestDocument(), edited))
+
+	markdown := changelog.ToMarkdown()
+	assert.Contains(t, markdown, "# Changelog: v1 → v2\n\n1 workbook(s), 1 commit(s) by Alice: 2 cell change(s), 1 sheet(s) added, 1 sheet(s) deleted\n")
+	assert.Contains(t, markdown, "## Budget.xlsx\n\n1 commit(s) by Alice: ")
+	assert.Contains(t, markdown, "| A\\|B | added | 1 | 0 | 1 |\n")
+	assert.Contains(t, markdown, "| Test Sheet | deleted | 1 | 0 | 1 |\n")
+	assert.Contains(t, markdown, "- 2026-03-31 `0123456` Update prices (Alice; 2 cell(s), 0 formula(s))\n")
+}
@@ -29,6 +29,9 @@ type DiffSummary struct {
 	ModifiedSheets int `json:"modified_sheets"`
 	DeletedSheets  int `json:"deleted_sheets"`
 	CellChanges    int `json:"cell_changes"`
+	// RecalculatedCells counts changes whose formula is unchanged and only the
+	// cached result differs. Only populated in formula-aware mode.
+	RecalculatedCells int `json:"recalculated_cells,omitempty"`
 }
 
 type SheetDiff struct {
@@ -45,6 +48,20 @@ type CellChange struct {
 	OldFormula  string      `json:"old_formula,omitempty"`
 	NewFormula  string      `json:"new_formula,omitempty"`
 	Description string      `json:"description,omitempty"`
+
+	// Formula-aware fields, see DiffOptions
+	Recalculated bool                 `json:"recalculated,omitempty"`
+	FormulaDiff  []FormulaDiffSegment `json:"formula_diff,omitempty"`
+}
+
+// DiffOptions controls how documents are compared
+type DiffOptions struct {
+	// FormulaAware separates recalculated formula results from input edits
+	// and attaches a token-level diff to every formula edit
+	FormulaAware bool
+	// IgnoreRecalculated drops recalculated results from the diff entirely.
+	// It implies FormulaAware.
+	IgnoreRecalculated bool
 }
 
 type ChangeType string
@@ -57,6 +74,16 @@ const (
 
 // ComputeDiff computes the differences between two Excel documents
 func ComputeDiff(oldDoc, newDoc *ExcelDocument) *ExcelDiff {
+	return ComputeDiffWithOptions(oldDoc, newDoc, DiffOptions{})
+}
+
+// ComputeDiffWithOptions computes the differences between two Excel documents
+// using the given comparison options
+func ComputeDiffWithOptions(oldDoc, newDoc *ExcelDocument, opts DiffOptions) *ExcelDiff {
+	if opts.IgnoreRecalculated {
+		opts.FormulaAware = true
+	}
+
 	diff := &ExcelDiff{
 		Timestamp:  time.Now(),
 		SheetDiffs: []SheetDiff{},
@@ -126,7 +153,7 @@ func ComputeDiff(oldDoc, newDoc *ExcelDocument) *ExcelDiff {
 			}
 		case hasOld && hasNew:
 			// Sheet exists in both, compare cells
-			cellChanges := compareCells(oldSheet.Cells, newSheet.Cells)
+			cellChanges := compareCells(oldSheet.Cells, newSheet.Cells, opts)
 			if len(cellChanges) > 0 {
 				sheetDiff.Changes = cellChanges
 				diff.Summary.ModifiedSheets++
@@ -141,6 +168,11 @@ func ComputeDiff(oldDoc, newDoc *ExcelDocument) *ExcelDiff {
 	// Calculate totals
 	for _, sheetDiff := range diff.SheetDiffs {
 		diff.Summary.CellChanges += len(sheetDiff.Changes)
+		for _, change := range sheetDiff.Changes {
+			if change.Recalculated {
+				diff.Summary.RecalculatedCells++
+			}
+		}
 	}
 	diff.Summary.TotalChanges = diff.Summary.AddedSheets + diff.Summary.ModifiedSheets + diff.Summary.DeletedSheets
 
@@ -148,7 +180,7 @@ func ComputeDiff(oldDoc, newDoc *ExcelDocument) *ExcelDiff {
 }
 
 // compareCells compares the cells between two sheets
-func compareCells(oldCells, newCells map[string]Cell) []CellChange {
+func compareCells(oldCells, newCells map[string]Cell, opts DiffOptions) []CellChange {
 	var changes []CellChange
 
 	// Find all unique cell references
@@ -186,17 +218,36 @@ func compareCells(oldCells, newCells map[string]Cell) []CellChange {
 			})
 		case hasOld && hasNew:
 			// Cell exists in both, check for changes
-			if cellsAreDifferent(&oldCell, &newCell) {
-				changes = append(changes, CellChange{
-					Cell:        cellRef,
-					Type:        ChangeTypeModify,
-					OldValue:    oldCell.Value,
-					NewValue:    newCell.Value,
-					OldFormula:  oldCell.Formula,
-					NewFormula:  newCell.Formula,
-					Description: describeCellChange(&oldCell, &newCell),
-				})
+			if !cellsAreDifferent(&oldCell, &newCell) {
+				continue
+			}
+
+			change := CellChange{
+				Cell:        cellRef,
+				Type:        ChangeTypeModify,
+				OldValue:    oldCell.Value,
+				NewValue:    newCell.Value,
+				OldFormula:  oldCell.Formula,
+				NewFormula:  newCell.Formula,
+				Description: describeCellChange(&oldCell, &newCell),
+			}
+
+			if opts.FormulaAware {
+				if isRecalculation(&oldCell, &newCell) {
+					if opts.IgnoreRecalculated {
+						continue
+					}
+					change.Recalculated = true
+					change.Description = fmt.Sprintf("Recalculated value: %v → %v", oldCell.Value, newCell.Value)
+				} else if oldCell.Formula != "" && newCell.Formula != "" && oldCell.Formula != newCell.Formula {
+					change.FormulaDiff = DiffFormulas(oldCell.Formula, newCell.Formula)
+					change.Description = strings.Replace(change.Description,
+						fmt.Sprintf("formula: %s → %s", oldCell.Formula, newCell.Formula),
+						"formula: "+FormatFormulaDiff(change.FormulaDiff), 1)
+				}
 			}
+
+			changes = append(changes, change)
 		}
 	}
 
@@ -241,6 +292,22 @@ func cellsAreDifferent(old, updated *Cell) bool {
 	return false
 }
 
+// isRecalculation reports whether the only difference between two cells is
+// the cached result of an unchanged formula
+func isRecalculation(old, updated *Cell) bool {
+	if old.Formula == "" || old.Formula != updated.Formula {
+		return false
+	}
+	if reflect.DeepEqual(old.Value, updated.Value) {
+		return false
+	}
+
+	// Compare everything else with the value held constant
+	sameValue := *updated
+	sameValue.Value = old.Value
+	return !cellsAreDifferent(old, &sameValue)
+}
+
 // describeCellChange generates a human-readable description of the change
 func describeCellChange(old, newCell *Cell) string {
 	if old == nil && newCell != nil {
@@ -349,6 +416,14 @@ func (d *ExcelDiff) formatSummaryParts(colorized bool) []string {
 		}
 	}
 
+	if d.Summary.RecalculatedCells > 0 {
+		if colorized {
+			parts = append(parts, fmt.Sprintf("\033[90m%d recalculated\033[0m", d.Summary.RecalculatedCells)) // Gray
+		} else {
+			parts = append(parts, fmt.Sprintf("%d recalculated", d.Summary.RecalculatedCells))
+		}
+	}
+
 	return parts
 }
 
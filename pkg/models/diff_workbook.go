@@ -0,0 +1,193 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffSummarySheetName is the name of the sheet listing every change in a
+// highlighted diff workbook
+const DiffSummarySheetName = "GitCells Changes"
+
+// Fill colours used to highlight changed cells, matching Excel's built-in
+// good/neutral/bad cell styles
+const (
+	diffAddedColor    = "#C6EFCE"
+	diffModifiedColor = "#FFEB9C"
+	diffDeletedColor  = "#FFC7CE"
+	diffRecalcColor   = "#E7E6E6"
+)
+
+// diffSummaryHeaders are the columns of the summary sheet
+var diffSummaryHeaders = []string{"Sheet", "Cell", "Change", "Old Value", "New Value", "Old Formula", "New Formula", "Description"}
+
+// BuildDiffWorkbook returns a document for reviewing a diff in Excel. It
+// contains the new version with changed cells highlighted and the previous
+// value in a cell comment. Deleted cells are restored with their old value
+// so they remain visible, and deleted sheets are appended. The first sheet
+// lists every CellChange. oldDoc and newDoc may be nil.
+func BuildDiffWorkbook(oldDoc, newDoc *ExcelDocument, diff *ExcelDiff) *ExcelDocument {
+	result := &ExcelDocument{DefinedNames: make(map[string]string)}
+	if newDoc != nil {
+		result.Version = newDoc.Version
+		result.Metadata = newDoc.Metadata
+		result.Properties = newDoc.Properties
+		for name, refersTo := range newDoc.DefinedNames {
+			result.DefinedNames[name] = refersTo
+		}
+		for _, sheet := range newDoc.Sheets {
+			result.Sheets = append(result.Sheets, copySheet(sheet))
+		}
+	}
+
+	summaryName := uniqueSheetName(result, DiffSummarySheetName)
+	summary := Sheet{
+		Name:         summaryName,
+		Cells:        make(map[string]Cell),
+		ColumnWidths: map[string]float64{"A": 20, "B": 8, "C": 14, "D": 24, "E": 24, "F": 30, "G": 30, "H": 60},
+	}
+	headerStyle := &CellStyle{Font: &Font{Bold: true}, Fill: &Fill{Type: "pattern", Pattern: "solid", Color: "#D9E1F2"}}
+	for i, header := range diffSummaryHeaders {
+		summary.Cells[ColumnName(i+1)+"1"] = Cell{Value: header, Type: CellTypeString, Style: headerStyle}
+	}
+
+	row := 2
+	if diff != nil {
+		for _, sd := range diff.SheetDiffs {
+			target := findSheet(result, sd.SheetName)
+			if target == nil {
+				// Sheet only exists in the old version; restore it so deleted
+				// cells can be highlighted in place
+				restored := Sheet{Name: sd.SheetName, Cells: make(map[string]Cell)}
+				if old := findSheet(oldDoc, sd.SheetName); old != nil {
+					restored = copySheet(*old)
+				}
+				result.Sheets = append(result.Sheets, restored)
+				target = &result.Sheets[len(result.Sheets)-1]
+			}
+
+			for _, change := range sd.Changes {
+				highlightChange(target, change)
+				writeSummaryRow(&summary, row, sd.SheetName, change)
+				row++
+			}
+		}
+	}
+
+	// The summary becomes the first, active sheet
+	result.Sheets = append([]Sheet{summary}, result.Sheets...)
+	for i := range result.Sheets {
+		result.Sheets[i].Index = i
+	}
+
+	return result
+}
+
+// highlightChange applies the fill colour and old-value comment for a change
+func highlightChange(sheet *Sheet, change CellChange) {
+	ref := change.Cell
+	cell, exists := sheet.Cells[ref]
+
+	color := diffModifiedColor
+	var note []string
+	switch {
+	case change.Recalculated:
+		color = diffRecalcColor
+		note = append(note, "Recalculated. Old value: "+commentValue(change.OldValue))
+	case change.Type == ChangeTypeAdd:
+		color = diffAddedColor
+		note = append(note, "Added")
+	case change.Type == ChangeTypeDelete:
+		color = diffDeletedColor
+		note = append(note, "Deleted")
+		if !exists {
+			cell = Cell{Value: change.OldValue, Formula: change.OldFormula, Type: CellTypeString}
+			if change.OldFormula != "" {
+				cell.Type = CellTypeFormula
+			}
+		}
+	default:
+		note = append(note, "Old value: "+commentValue(change.OldValue))
+		if change.OldFormula != change.NewFormula {
+			note = append(note, fmt.Sprintf("Old formula: %s", change.OldFormula))
+		}
+	}
+
+	style := &CellStyle{}
+	if cell.Style != nil {
+		copied := *cell.Style
+		style = &copied
+	}
+	style.Fill = &Fill{Type: "pattern", Pattern: "solid", Color: color}
+	if change.Type == ChangeTypeDelete {
+		font := Font{}
+		if style.Font != nil {
+			font = *style.Font
+		}
+		font.Color = "#9C0006"
+		style.Font = &font
+	}
+	cell.Style = style
+
+	text := strings.Join(note, "\n")
+	if cell.Comment != nil && cell.Comment.Text != "" {
+		text += "\n\n" + cell.Comment.Text
+	}
+	cell.Comment = &Comment{Author: "GitCells", Text: text}
+
+	sheet.Cells[ref] = cell
+}
+
+// writeSummaryRow lists a single change on the summary sheet
+func writeSummaryRow(summary *Sheet, row int, sheetName string, change CellChange) {
+	changeType := string(change.Type)
+	if change.Recalculated {
+		changeType = "recalculated"
+	}
+
+	values := []interface{}{
+		sheetName,
+		change.Cell,
+		changeType,
+		change.OldValue,
+		change.NewValue,
+		change.OldFormula,
+		change.NewFormula,
+		change.Description,
+	}
+	for i, value := range values {
+		if value == nil || value == "" {
+			continue
+		}
+		// Formulas are listed as text so they are not evaluated
+		summary.Cells[fmt.Sprintf("%s%d", ColumnName(i+1), row)] = Cell{Value: value, Type: CellTypeString}
+	}
+}
+
+// commentValue renders an old value for a cell comment
+func commentValue(value interface{}) string {
+	if text := formatReportValue(value); text != "" {
+		return text
+	}
+	return "(empty)"
+}
+
+// copySheet returns a copy of sheet whose cell map can be modified freely
+func copySheet(sheet Sheet) Sheet {
+	cells := make(map[string]Cell, len(sheet.Cells))
+	for ref, cell := range sheet.Cells {
+		cells[ref] = cell
+	}
+	sheet.Cells = cells
+	return sheet
+}
+
+// uniqueSheetName returns name, suffixed if the document already has a
+// sheet with that name
+func uniqueSheetName(doc *ExcelDocument, name string) string {
+	candidate := name
+	for i := 2; findSheet(doc, candidate) != nil; i++ {
+		candidate = fmt.Sprintf("%s (%d)", name, i)
+	}
+	return candidate
+}
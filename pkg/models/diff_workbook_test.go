@@ -0,0 +1,87 @@
+package models
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDiffWorkbook(t *testing.T) {
+	oldDoc := createTestDocument()
+	oldDoc.Sheets[0].Cells["B1"] = Cell{Value: 5, Type: CellTypeNumber}
+	oldDoc.Sheets[0].Cells["C1"] = Cell{Value: 1, Formula: "=A2", Type: CellTypeFormula}
+	oldDoc.Sheets = append(oldDoc.Sheets, Sheet{Name: "Removed", Cells: map[string]Cell{"A1": {Value: "gone", Type: CellTypeString}}})
+
+	newDoc := createTestDocument()
+	newDoc.Sheets[0].Cells["A1"] = Cell{Value: "Changed", Type: CellTypeString, Comment: &Comment{Text: "note"}}
+	newDoc.Sheets[0].Cells["A2"] = Cell{Value: 42, Type: CellTypeNumber}
+	newDoc.Sheets[0].Cells["C1"] = Cell{Value: 42, Formula: "=A2", Type: CellTypeFormula}
+
+	diff := ComputeDiffWithOptions(oldDoc, newDoc, DiffOptions{FormulaAware: true})
+	result := BuildDiffWorkbook(oldDoc, newDoc, diff)
+
+	require.Len(t, result.Sheets, 3)
+	assert.Equal(t, DiffSummarySheetName, result.Sheets[0].Name)
+	assert.Equal(t, 0, result.Sheets[0].Index)
+	assert.Equal(t, "Test Sheet", result.Sheets[1].Name)
+	assert.Equal(t, "Removed", result.Sheets[2].Name)
+
+	t.Run("highlights changed cells", func(t *testing.T) {
+		cells := result.Sheets[1].Cells
+
+		modified := cells["A1"]
+		assert.Equal(t, "Changed", modified.Value)
+		assert.Equal(t, diffModifiedColor, modified.Style.Fill.Color)
+		assert.Equal(t, "Old value: Test Value\n\nnote", modified.Comment.Text)
+
+		added := cells["A2"]
+		assert.Equal(t, diffAddedColor, added.Style.Fill.Color)
+		assert.Equal(t, "Added", added.Comment.Text)
+
+		deleted := cells["B1"]
+		assert.Equal(t, 5, deleted.Value)
+		assert.Equal(t, diffDeletedColor, deleted.Style.Fill.Color)
+
+		recalculated := cells["C1"]
+		assert.Equal(t, diffRecalcColor, recalculated.Style.Fill.Color)
+		assert.Equal(t, "Recalculated. Old value: 1", recalculated.Comment.Text)
+	})
+
+	t.Run("restores deleted sheets", func(t *testing.T) {
+		cell := result.Sheets[2].Cells["A1"]
+		assert.Equal(t, "gone", cell.Value)
+		assert.Equal(t, diffDeletedColor, cell.Style.Fill.Color)
+	})
+
+	t.Run("lists every change on the summary sheet", func(t *testing.T) {
+		summary := result.Sheets[0].Cells
+		assert.Equal(t, "Sheet", summary["A1"].Value)
+		assert.Equal(t, "Description", summary["H1"].Value)
+
+		rows := 0
+		for row := 2; ; row++ {
+			if _, ok := summary["A"+strconv.Itoa(row)]; !ok {
+				break
+			}
+			rows++
+		}
+		assert.Equal(t, diff.Summary.CellChanges, rows)
+	})
+
+	t.Run("does not modify the input documents", func(t *testing.T) {
+		assert.Nil(t, newDoc.Sheets[0].Cells["A1"].Style)
+		assert.Equal(t, "note", newDoc.Sheets[0].Cells["A1"].Comment.Text)
+		_, exists := newDoc.Sheets[0].Cells["B1"]
+		assert.False(t, exists)
+	})
+}
+
+func TestBuildDiffWorkbook_SummaryNameClash(t *testing.T) {
+	doc := &ExcelDocument{Sheets: []Sheet{{Name: DiffSummarySheetName, Cells: map[string]Cell{}}}}
+	result := BuildDiffWorkbook(doc, doc, ComputeDiff(doc, doc))
+
+	require.Len(t, result.Sheets, 2)
+	assert.Equal(t, DiffSummarySheetName+" (2)", result.Sheets[0].Name)
+}
@@ -30,6 +30,8 @@ type DocumentProperties struct {
 	Company     string `json:"company,omitempty"`
 	Keywords    string `json:"keywords,omitempty"`
 	Description string `json:"description,omitempty"`
+	// LastModifiedBy is the name of the person who last saved the workbook
+	LastModifiedBy string `json:"last_modified_by,omitempty"`
 }
 
 type Sheet struct {
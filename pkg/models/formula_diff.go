@@ -0,0 +1,208 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxFormulaDiffCells bounds the LCS table used for token diffs so that
+// pathological formulas fall back to a whole-formula replacement
+const maxFormulaDiffCells = 250000
+
+// FormulaDiffOp describes how a formula segment changed
+type FormulaDiffOp string
+
+const (
+	FormulaDiffEqual  FormulaDiffOp = "equal"
+	FormulaDiffInsert FormulaDiffOp = "insert"
+	FormulaDiffDelete FormulaDiffOp = "delete"
+)
+
+// FormulaDiffSegment is a run of formula text with the same diff operation
+type FormulaDiffSegment struct {
+	Op   FormulaDiffOp `json:"op"`
+	Text string        `json:"text"`
+}
+
+// DiffFormulas computes a token-level diff between two formulas.
+// References and ranges such as A1:A10 or Sheet1!B2 are treated as single
+// tokens so that a changed range is highlighted as a whole.
+func DiffFormulas(oldFormula, newFormula string) []FormulaDiffSegment {
+	oldTokens := tokenizeFormula(oldFormula)
+	newTokens := tokenizeFormula(newFormula)
+
+	if len(oldTokens)*len(newTokens) > maxFormulaDiffCells {
+		return mergeFormulaSegments([]FormulaDiffSegment{
+			{Op: FormulaDiffDelete, Text: oldFormula},
+			{Op: FormulaDiffInsert, Text: newFormula},
+		})
+	}
+
+	// Longest common subsequence table, filled from the end
+	n, m := len(oldTokens), len(newTokens)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldTokens[i] == newTokens[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var segments []FormulaDiffSegment
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case oldTokens[i] == newTokens[j]:
+			segments = append(segments, FormulaDiffSegment{Op: FormulaDiffEqual, Text: oldTokens[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			segments = append(segments, FormulaDiffSegment{Op: FormulaDiffDelete, Text: oldTokens[i]})
+			i++
+		default:
+			segments = append(segments, FormulaDiffSegment{Op: FormulaDiffInsert, Text: newTokens[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		segments = append(segments, FormulaDiffSegment{Op: FormulaDiffDelete, Text: oldTokens[i]})
+	}
+	for ; j < m; j++ {
+		segments = append(segments, FormulaDiffSegment{Op: FormulaDiffInsert, Text: newTokens[j]})
+	}
+
+	return mergeFormulaSegments(segments)
+}
+
+// FormatFormulaDiff renders formula segments inline, wrapping deletions in
+// [-...-] and insertions in {+...+}
+func FormatFormulaDiff(segments []FormulaDiffSegment) string {
+	return FormatFormulaDiffWith(segments, "[-", "-]", "{+", "+}")
+}
+
+// FormatFormulaDiffWith renders formula segments inline using custom markers,
+// which allows callers to substitute terminal colors or HTML tags
+func FormatFormulaDiffWith(segments []FormulaDiffSegment, delStart, delEnd, insStart, insEnd string) string {
+	var result strings.Builder
+	for _, segment := range segments {
+		switch segment.Op {
+		case FormulaDiffDelete:
+			result.WriteString(delStart + segment.Text + delEnd)
+		case FormulaDiffInsert:
+			result.WriteString(insStart + segment.Text + insEnd)
+		default:
+			result.WriteString(segment.Text)
+		}
+	}
+	return result.String()
+}
+
+// mergeFormulaSegments joins adjacent segments that share an operation and
+// orders each changed run as deletions followed by insertions
+func mergeFormulaSegments(segments []FormulaDiffSegment) []FormulaDiffSegment {
+	var merged []FormulaDiffSegment
+	var deleted, inserted strings.Builder
+
+	flush := func() {
+		if deleted.Len() > 0 {
+			merged = append(merged, FormulaDiffSegment{Op: FormulaDiffDelete, Text: deleted.String()})
+			deleted.Reset()
+		}
+		if inserted.Len() > 0 {
+			merged = append(merged, FormulaDiffSegment{Op: FormulaDiffInsert, Text: inserted.String()})
+			inserted.Reset()
+		}
+	}
+
+	for _, segment := range segments {
+		if segment.Text == "" {
+			continue
+		}
+		switch segment.Op {
+		case FormulaDiffDelete:
+			deleted.WriteString(segment.Text)
+		case FormulaDiffInsert:
+			inserted.WriteString(segment.Text)
+		default:
+			flush()
+			if last := len(merged) - 1; last >= 0 && merged[last].Op == FormulaDiffEqual {
+				merged[last].Text += segment.Text
+			} else {
+				merged = append(merged, segment)
+			}
+		}
+	}
+	flush()
+
+	return merged
+}
+
+// tokenizeFormula splits an Excel formula into diffable tokens
+func tokenizeFormula(formula string) []string {
+	var tokens []string
+	runes := []rune(formula)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case r == '"':
+			i = skipQuoted(runes, i, '"')
+		case r == '\'':
+			// Quoted sheet name, keep the reference that follows in the same token
+			i = skipQuoted(runes, i, '\'')
+			for i < len(runes) && isReferenceRune(runes[i]) {
+				i++
+			}
+		case isReferenceRune(r):
+			for i < len(runes) && isReferenceRune(runes[i]) {
+				i++
+			}
+		case unicode.IsSpace(r):
+			for i < len(runes) && unicode.IsSpace(runes[i]) {
+				i++
+			}
+		case (r == '<' || r == '>') && i+1 < len(runes) && (runes[i+1] == '=' || (r == '<' && runes[i+1] == '>')):
+			i += 2
+		default:
+			i++
+		}
+
+		tokens = append(tokens, string(runes[start:i]))
+	}
+
+	return tokens
+}
+
+// skipQuoted returns the index just past a quoted literal starting at start,
+// treating doubled quote characters as escapes
+func skipQuoted(runes []rune, start int, quote rune) int {
+	i := start + 1
+	for i < len(runes) {
+		if runes[i] == quote {
+			if i+1 < len(runes) && runes[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return i
+}
+
+// isReferenceRune reports whether r can be part of a name, number, cell
+// reference, range or error literal
+func isReferenceRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) ||
+		r == '_' || r == '$' || r == '.' || r == '!' || r == ':' || r == '#' || r == '?'
+}
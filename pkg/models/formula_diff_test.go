@@ -0,0 +1,163 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenizeFormula(t *testing.T) {
+	tests := []struct {
+		name     string
+		formula  string
+		expected []string
+	}{
+		{
+			name:     "function with range",
+			formula:  "=SUM(A1:A10)",
+			expected: []string{"=", "SUM", "(", "A1:A10", ")"},
+		},
+		{
+			name:     "sheet qualified reference",
+			formula:  "=Sheet1!B2*2",
+			expected: []string{"=", "Sheet1!B2", "*", "2"},
+		},
+		{
+			name:     "quoted sheet name",
+			formula:  "='My Sheet'!$A$1+1",
+			expected: []string{"=", "'My Sheet'!$A$1", "+", "1"},
+		},
+		{
+			name:     "string literal with escaped quote",
+			formula:  `=IF(A1<>"say ""hi""",1,0)`,
+			expected: []string{"=", "IF", "(", "A1", "<>", `"say ""hi"""`, ",", "1", ",", "0", ")"},
+		},
+		{
+			name:     "whitespace is preserved",
+			formula:  "=A1 + B1",
+			expected: []string{"=", "A1", " ", "+", " ", "B1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tokenizeFormula(tt.formula))
+		})
+	}
+}
+
+func TestDiffFormulas(t *testing.T) {
+	t.Run("changed range is highlighted as a whole", func(t *testing.T) {
+		segments := DiffFormulas("SUM(A1:A10)", "SUM(A1:A12)")
+
+		require.Len(t, segments, 4)
+		assert.Equal(t, FormulaDiffSegment{Op: FormulaDiffEqual, Text: "SUM("}, segments[0])
+		assert.Equal(t, FormulaDiffSegment{Op: FormulaDiffDelete, Text: "A1:A10"}, segments[1])
+		assert.Equal(t, FormulaDiffSegment{Op: FormulaDiffInsert, Text: "A1:A12"}, segments[2])
+		assert.Equal(t, FormulaDiffSegment{Op: FormulaDiffEqual, Text: ")"}, segments[3])
+		assert.Equal(t, "SUM([-A1:A10-]{+A1:A12+})", FormatFormulaDiff(segments))
+	})
+
+	t.Run("identical formulas", func(t *testing.T) {
+		segments := DiffFormulas("=A1+B1", "=A1+B1")
+
+		require.Len(t, segments, 1)
+		assert.Equal(t, FormulaDiffEqual, segments[0].Op)
+		assert.Equal(t, "=A1+B1", segments[0].Text)
+	})
+
+	t.Run("inserted argument", func(t *testing.T) {
+		segments := DiffFormulas("=MAX(A1,B1)", "=MAX(A1,B1,C1)")
+
+		assert.Equal(t, "=MAX(A1,B1{+,C1+})", FormatFormulaDiff(segments))
+	})
+
+	t.Run("function replaced", func(t *testing.T) {
+		segments := DiffFormulas("=SUM(A1:A3)", "=AVERAGE(A1:A3)")
+
+		assert.Equal(t, "=[-SUM-]{+AVERAGE+}(A1:A3)", FormatFormulaDiff(segments))
+	})
+
+	t.Run("custom markers", func(t *testing.T) {
+		segments := DiffFormulas("=A1", "=A2")
+
+		assert.Equal(t, "=<del>A1</del><ins>A2</ins>",
+			FormatFormulaDiffWith(segments, "<del>", "</del>", "<ins>", "</ins>"))
+	})
+}
+
+func TestComputeDiffWithOptions_FormulaAware(t *testing.T) {
+	newDocs := func() (*ExcelDocument, *ExcelDocument) {
+		doc1 := createTestDocument()
+		doc2 := createTestDocument()
+		doc1.Sheets[0].Cells["B1"] = Cell{Value: 10, Type: CellTypeNumber}
+		doc1.Sheets[0].Cells["B2"] = Cell{Value: 10, Formula: "=SUM(B1:B1)", Type: CellTypeFormula}
+		doc1.Sheets[0].Cells["B3"] = Cell{Value: 10, Formula: "=SUM(A1:A10)", Type: CellTypeFormula}
+		doc2.Sheets[0].Cells["B1"] = Cell{Value: 15, Type: CellTypeNumber}
+		doc2.Sheets[0].Cells["B2"] = Cell{Value: 15, Formula: "=SUM(B1:B1)", Type: CellTypeFormula}
+		doc2.Sheets[0].Cells["B3"] = Cell{Value: 12, Formula: "=SUM(A1:A12)", Type: CellTypeFormula}
+		return doc1, doc2
+	}
+
+	t.Run("default mode treats every change alike", func(t *testing.T) {
+		doc1, doc2 := newDocs()
+		diff := ComputeDiff(doc1, doc2)
+
+		assert.Equal(t, 3, diff.Summary.CellChanges)
+		assert.Zero(t, diff.Summary.RecalculatedCells)
+		for _, change := range diff.SheetDiffs[0].Changes {
+			assert.False(t, change.Recalculated)
+			assert.Empty(t, change.FormulaDiff)
+		}
+	})
+
+	t.Run("formula aware mode flags recalculated results", func(t *testing.T) {
+		doc1, doc2 := newDocs()
+		diff := ComputeDiffWithOptions(doc1, doc2, DiffOptions{FormulaAware: true})
+
+		require.Len(t, diff.SheetDiffs, 1)
+		changes := diff.SheetDiffs[0].Changes
+		require.Len(t, changes, 3)
+		assert.Equal(t, 3, diff.Summary.CellChanges)
+		assert.Equal(t, 1, diff.Summary.RecalculatedCells)
+
+		assert.Equal(t, "B1", changes[0].Cell)
+		assert.False(t, changes[0].Recalculated)
+
+		assert.Equal(t, "B2", changes[1].Cell)
+		assert.True(t, changes[1].Recalculated)
+		assert.Equal(t, "Recalculated value: 10 → 15", changes[1].Description)
+
+		assert.Equal(t, "B3", changes[2].Cell)
+		assert.False(t, changes[2].Recalculated)
+		assert.Equal(t, "=SUM([-A1:A10-]{+A1:A12+})", FormatFormulaDiff(changes[2].FormulaDiff))
+		assert.Contains(t, changes[2].Description, "formula: =SUM([-A1:A10-]{+A1:A12+})")
+		assert.Contains(t, diff.String(), "1 recalculated")
+	})
+
+	t.Run("ignore recalculated drops them", func(t *testing.T) {
+		doc1, doc2 := newDocs()
+		diff := ComputeDiffWithOptions(doc1, doc2, DiffOptions{IgnoreRecalculated: true})
+
+		require.Len(t, diff.SheetDiffs, 1)
+		changes := diff.SheetDiffs[0].Changes
+		require.Len(t, changes, 2)
+		assert.Equal(t, "B1", changes[0].Cell)
+		assert.Equal(t, "B3", changes[1].Cell)
+		assert.NotEmpty(t, changes[1].FormulaDiff)
+		assert.Zero(t, diff.Summary.RecalculatedCells)
+	})
+
+	t.Run("only recalculated changes leaves sheet unmodified", func(t *testing.T) {
+		doc1 := createTestDocument()
+		doc2 := createTestDocument()
+		doc1.Sheets[0].Cells["C1"] = Cell{Value: 1, Formula: "=NOW()", Type: CellTypeFormula}
+		doc2.Sheets[0].Cells["C1"] = Cell{Value: 2, Formula: "=NOW()", Type: CellTypeFormula}
+
+		diff := ComputeDiffWithOptions(doc1, doc2, DiffOptions{IgnoreRecalculated: true})
+
+		assert.False(t, diff.HasChanges())
+		assert.Empty(t, diff.SheetDiffs)
+	})
+}
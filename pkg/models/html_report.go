@@ -0,0 +1,526 @@
+package models
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxReportRows and maxReportCols cap the rendered grid of a single sheet
+	maxReportRows = 500
+	maxReportCols = 52
+	// reportContext is the number of unchanged rows and columns shown around
+	// changes when a sheet is too large to render in full
+	reportContext = 2
+)
+
+// HTMLReport describes a standalone side-by-side diff report
+type HTMLReport struct {
+	Title     string
+	OldLabel  string
+	NewLabel  string
+	Workbooks []ReportWorkbook
+}
+
+// ReportWorkbook pairs both versions of a workbook with their diff. Old or
+// New is nil when the workbook only exists on one side. Error is set for
+// workbooks that could not be compared.
+type ReportWorkbook struct {
+	Path  string
+	Old   *ExcelDocument
+	New   *ExcelDocument
+	Diff  *ExcelDiff
+	Error string
+}
+
+// gridBounds is an inclusive, 1-based rectangle of cells
+type gridBounds struct {
+	minRow, maxRow, minCol, maxCol int
+}
+
+// GenerateHTMLReport renders a self-contained HTML document that shows each
+// changed sheet as old and new spreadsheet grids side by side. The output
+// has no external assets so it can be attached to CI artifacts as-is.
+func GenerateHTMLReport(report HTMLReport) string {
+	var b strings.Builder
+
+	title := report.Title
+	if title == "" {
+		title = "GitCells Diff Report"
+	}
+
+	b.WriteString("<!DOCTYPE html>\n<html lang='en'><head><meta charset='utf-8'>")
+	b.WriteString("<meta name='viewport' content='width=device-width, initial-scale=1'>")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>")
+	b.WriteString("<style>" + GetHTMLReportCSS() + "</style></head><body>\n")
+
+	b.WriteString("<header><h1>" + html.EscapeString(title) + "</h1>")
+	b.WriteString(fmt.Sprintf("<p class='labels'><span class='old-label'>%s</span> → <span class='new-label'>%s</span></p>",
+		html.EscapeString(report.OldLabel), html.EscapeString(report.NewLabel)))
+	b.WriteString(fmt.Sprintf("<p class='generated'>Generated %s</p>", time.Now().Format("2006-01-02 15:04:05")))
+	b.WriteString("<p class='legend'><span class='cell-add'>added</span><span class='cell-modify'>modified</span>" +
+		"<span class='cell-delete'>deleted</span><span class='cell-recalc'>recalculated</span></p></header>\n")
+
+	writeReportIndex(&b, report.Workbooks)
+
+	b.WriteString("<main>\n")
+	for i, wb := range report.Workbooks {
+		if wb.Diff == nil || !wb.Diff.HasChanges() {
+			continue
+		}
+		writeReportWorkbook(&b, i, wb, report.OldLabel, report.NewLabel)
+	}
+	b.WriteString("</main>\n")
+
+	b.WriteString("<script>" + htmlReportScript + "</script>\n</body></html>\n")
+	return b.String()
+}
+
+// writeReportIndex renders the navigation index of workbooks and sheets
+func writeReportIndex(b *strings.Builder, workbooks []ReportWorkbook) {
+	b.WriteString("<nav class='index'><h2>Index</h2><ul>")
+	for i, wb := range workbooks {
+		if wb.Error != "" {
+			b.WriteString(fmt.Sprintf("<li class='failed'>%s <span class='badge delete'>failed: %s</span></li>",
+				html.EscapeString(wb.Path), html.EscapeString(wb.Error)))
+			continue
+		}
+		if wb.Diff == nil || !wb.Diff.HasChanges() {
+			b.WriteString(fmt.Sprintf("<li class='unchanged'>%s <span class='badge'>unchanged</span></li>", html.EscapeString(wb.Path)))
+			continue
+		}
+
+		b.WriteString(fmt.Sprintf("<li><a href='#wb-%d'>%s</a> <span class='badge %s'>%s</span><ul>",
+			i, html.EscapeString(wb.Path), workbookStatus(wb), html.EscapeString(wb.Diff.String())))
+		for j, sd := range wb.Diff.SheetDiffs {
+			b.WriteString(fmt.Sprintf("<li><a href='#wb-%d-sheet-%d' class='sheet-link' data-target='wb-%d-sheet-%d'>%s</a> <span class='count'>%d</span></li>",
+				i, j, i, j, html.EscapeString(sd.SheetName), len(sd.Changes)))
+		}
+		b.WriteString("</ul></li>")
+	}
+	b.WriteString("</ul></nav>\n")
+}
+
+// writeReportWorkbook renders the sheet tabs and grids of one workbook
+func writeReportWorkbook(b *strings.Builder, index int, wb ReportWorkbook, oldLabel, newLabel string) {
+	b.WriteString(fmt.Sprintf("<section class='workbook' id='wb-%d'><h2>%s <span class='badge %s'>%s</span></h2>",
+		index, html.EscapeString(wb.Path), workbookStatus(wb), workbookStatus(wb)))
+	b.WriteString("<p class='summary'>" + html.EscapeString(wb.Diff.String()) + "</p>")
+
+	// Sheet tabs
+	b.WriteString("<div class='tabs'>")
+	for j, sd := range wb.Diff.SheetDiffs {
+		active := ""
+		if j == 0 {
+			active = " active"
+		}
+		b.WriteString(fmt.Sprintf("<button class='tab %s%s' data-target='wb-%d-sheet-%d'>%s <span class='count'>%d</span></button>",
+			sd.Action, active, index, j, html.EscapeString(sd.SheetName), len(sd.Changes)))
+	}
+	b.WriteString("</div>")
+
+	for j, sd := range wb.Diff.SheetDiffs {
+		active := ""
+		if j == 0 {
+			active = " active"
+		}
+		b.WriteString(fmt.Sprintf("<div class='sheet-panel%s' id='wb-%d-sheet-%d'>", active, index, j))
+		writeSheetComparison(b, findSheet(wb.Old, sd.SheetName), findSheet(wb.New, sd.SheetName), sd, oldLabel, newLabel)
+		b.WriteString("</div>")
+	}
+
+	b.WriteString("</section>\n")
+}
+
+// writeSheetComparison renders the old and new grids of a sheet side by side
+func writeSheetComparison(b *strings.Builder, oldSheet, newSheet *Sheet, sd SheetDiff, oldLabel, newLabel string) {
+	changes := make(map[string]CellChange, len(sd.Changes))
+	for _, change := range sd.Changes {
+		changes[normalizeCellRef(change.Cell)] = change
+	}
+
+	bounds, truncated := reportBounds(oldSheet, newSheet, sd.Changes)
+	if truncated {
+		b.WriteString(fmt.Sprintf("<p class='note'>Sheet is large; showing %s%d:%s%d around the changes.</p>",
+			ColumnName(bounds.minCol), bounds.minRow, ColumnName(bounds.maxCol), bounds.maxRow))
+	}
+
+	b.WriteString("<div class='grid-pair'>")
+	writeGridSide(b, oldSheet, changes, bounds, oldLabel, true)
+	writeGridSide(b, newSheet, changes, bounds, newLabel, false)
+	b.WriteString("</div>")
+}
+
+// writeGridSide renders one side of a sheet comparison as a table
+func writeGridSide(b *strings.Builder, sheet *Sheet, changes map[string]CellChange, bounds gridBounds, label string, isOld bool) {
+	b.WriteString("<div class='grid-side'><h4>" + html.EscapeString(label) + "</h4><div class='grid-scroll'>")
+
+	if sheet == nil {
+		if isOld {
+			b.WriteString("<p class='missing'>Sheet did not exist</p>")
+		} else {
+			b.WriteString("<p class='missing'>Sheet was deleted</p>")
+		}
+		b.WriteString("</div></div>")
+		return
+	}
+
+	cells := make(map[string]Cell, len(sheet.Cells))
+	for ref, cell := range sheet.Cells {
+		cells[normalizeCellRef(ref)] = cell
+	}
+
+	b.WriteString("<table class='grid'><thead><tr><th class='corner'></th>")
+	for col := bounds.minCol; col <= bounds.maxCol; col++ {
+		b.WriteString("<th>" + ColumnName(col) + "</th>")
+	}
+	b.WriteString("</tr></thead><tbody>")
+
+	for row := bounds.minRow; row <= bounds.maxRow; row++ {
+		b.WriteString(fmt.Sprintf("<tr><th>%d</th>", row))
+		for col := bounds.minCol; col <= bounds.maxCol; col++ {
+			ref := ColumnName(col) + strconv.Itoa(row)
+			cell, hasCell := cells[ref]
+			change, changed := changes[ref]
+
+			class := ""
+			if changed {
+				class = reportCellClass(change, isOld)
+			}
+
+			var tooltip []string
+			if hasCell && cell.Formula != "" {
+				tooltip = append(tooltip, ref+": "+cell.Formula)
+			}
+			if changed && change.Description != "" {
+				tooltip = append(tooltip, change.Description)
+			}
+
+			b.WriteString("<td")
+			if class != "" {
+				b.WriteString(" class='" + class + "'")
+			}
+			if len(tooltip) > 0 {
+				b.WriteString(" title='" + html.EscapeString(strings.Join(tooltip, "\n")) + "'")
+			}
+			b.WriteString(">")
+			if hasCell {
+				b.WriteString(html.EscapeString(formatReportValue(cell.Value)))
+			}
+			b.WriteString("</td>")
+		}
+		b.WriteString("</tr>")
+	}
+
+	b.WriteString("</tbody></table></div></div>")
+}
+
+// reportCellClass chooses the CSS class of a changed cell on one side
+func reportCellClass(change CellChange, isOld bool) string {
+	if change.Recalculated {
+		return "cell-recalc"
+	}
+	switch change.Type {
+	case ChangeTypeAdd:
+		if isOld {
+			return "cell-ghost"
+		}
+		return "cell-add"
+	case ChangeTypeDelete:
+		if isOld {
+			return "cell-delete"
+		}
+		return "cell-ghost"
+	default:
+		return "cell-modify"
+	}
+}
+
+// reportBounds computes the rectangle of cells to render. Small sheets are
+// rendered in full; large ones are limited to the changes plus context.
+func reportBounds(oldSheet, newSheet *Sheet, changes []CellChange) (gridBounds, bool) {
+	used, hasUsed := gridBounds{}, false
+	for _, sheet := range []*Sheet{oldSheet, newSheet} {
+		if sheet == nil {
+			continue
+		}
+		for ref := range sheet.Cells {
+			used, hasUsed = extendBounds(used, hasUsed, ref)
+		}
+	}
+	if !hasUsed {
+		return gridBounds{minRow: 1, maxRow: 1, minCol: 1, maxCol: 1}, false
+	}
+
+	// Always start at A1 for small sheets so the grid looks like Excel
+	full := gridBounds{minRow: 1, maxRow: used.maxRow, minCol: 1, maxCol: used.maxCol}
+	if full.maxRow <= maxReportRows && full.maxCol <= maxReportCols {
+		return full, false
+	}
+
+	changed, hasChanged := gridBounds{}, false
+	for _, change := range changes {
+		changed, hasChanged = extendBounds(changed, hasChanged, change.Cell)
+	}
+	if !hasChanged {
+		changed = used
+	}
+
+	bounds := gridBounds{
+		minRow: max(1, changed.minRow-reportContext),
+		maxRow: changed.maxRow + reportContext,
+		minCol: max(1, changed.minCol-reportContext),
+		maxCol: changed.maxCol + reportContext,
+	}
+	bounds.maxRow = min(bounds.maxRow, bounds.minRow+maxReportRows-1)
+	bounds.maxCol = min(bounds.maxCol, bounds.minCol+maxReportCols-1)
+	return bounds, true
+}
+
+// extendBounds grows bounds to include the given cell reference
+func extendBounds(bounds gridBounds, initialized bool, ref string) (gridBounds, bool) {
+	col, row, ok := ParseCellRef(ref)
+	if !ok {
+		return bounds, initialized
+	}
+	if !initialized {
+		return gridBounds{minRow: row, maxRow: row, minCol: col, maxCol: col}, true
+	}
+	bounds.minRow = min(bounds.minRow, row)
+	bounds.maxRow = max(bounds.maxRow, row)
+	bounds.minCol = min(bounds.minCol, col)
+	bounds.maxCol = max(bounds.maxCol, col)
+	return bounds, true
+}
+
+// workbookStatus returns add, delete or modify for a changed workbook
+func workbookStatus(wb ReportWorkbook) ChangeType {
+	switch {
+	case wb.Old == nil && wb.New != nil:
+		return ChangeTypeAdd
+	case wb.Old != nil && wb.New == nil:
+		return ChangeTypeDelete
+	default:
+		return ChangeTypeModify
+	}
+}
+
+// findSheet returns the named sheet of a document, or nil
+func findSheet(doc *ExcelDocument, name string) *Sheet {
+	if doc == nil {
+		return nil
+	}
+	for i := range doc.Sheets {
+		if doc.Sheets[i].Name == name {
+			return &doc.Sheets[i]
+		}
+	}
+	return nil
+}
+
+// formatReportValue renders a cell value for display
+func formatReportValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// normalizeCellRef upper-cases a reference and strips absolute markers
+func normalizeCellRef(ref string) string {
+	return strings.ToUpper(strings.ReplaceAll(ref, "$", ""))
+}
+
+// ParseCellRef converts an A1-style reference into 1-based column and row
+// numbers. Absolute markers ($) are ignored.
+func ParseCellRef(ref string) (col, row int, ok bool) {
+	ref = normalizeCellRef(ref)
+	i := 0
+	for i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z' {
+		col = col*26 + int(ref[i]-'A'+1)
+		i++
+	}
+	if i == 0 || i == len(ref) {
+		return 0, 0, false
+	}
+	row, err := strconv.Atoi(ref[i:])
+	if err != nil || row <= 0 {
+		return 0, 0, false
+	}
+	return col, row, true
+}
+
+// ColumnName converts a 1-based column number into its letter name
+func ColumnName(col int) string {
+	var name []byte
+	for col > 0 {
+		col--
+		name = append([]byte{byte('A' + col%26)}, name...)
+		col /= 26
+	}
+	return string(name)
+}
+
+// htmlReportScript powers sheet tabs, index navigation and synchronized
+// scrolling of the side-by-side grids
+const htmlReportScript = `
+function activate(id) {
+	var panel = document.getElementById(id);
+	if (!panel) { return; }
+	var section = panel.closest('.workbook');
+	section.querySelectorAll('.sheet-panel').forEach(function (p) { p.classList.toggle('active', p === panel); });
+	section.querySelectorAll('.tab').forEach(function (t) { t.classList.toggle('active', t.dataset.target === id); });
+}
+document.querySelectorAll('.tab, .sheet-link').forEach(function (el) {
+	el.addEventListener('click', function () { activate(el.dataset.target); });
+});
+if (location.hash) { activate(location.hash.substring(1)); }
+document.querySelectorAll('.grid-pair').forEach(function (pair) {
+	var sides = pair.querySelectorAll('.grid-scroll');
+	sides.forEach(function (side) {
+		side.addEventListener('scroll', function () {
+			sides.forEach(function (other) {
+				if (other !== side) { other.scrollTop = side.scrollTop; other.scrollLeft = side.scrollLeft; }
+			});
+		});
+	});
+});
+`
+
+// GetHTMLReportCSS returns CSS styles for the standalone HTML report
+func GetHTMLReportCSS() string {
+	return `
+body {
+	font-family: -apple-system, 'Segoe UI', Helvetica, Arial, sans-serif;
+	margin: 0;
+	padding: 20px;
+	color: #212529;
+	background: #f8f9fa;
+}
+
+header .labels .old-label { color: #dc3545; }
+header .labels .new-label { color: #28a745; }
+header .generated { color: #6c757d; }
+
+.legend span {
+	display: inline-block;
+	padding: 2px 8px;
+	margin-right: 6px;
+	border-radius: 3px;
+}
+
+.index {
+	background: white;
+	border: 1px solid #dee2e6;
+	border-radius: 6px;
+	padding: 10px 20px;
+	margin-bottom: 20px;
+}
+
+.index li.unchanged { color: #6c757d; }
+
+.badge, .count {
+	font-size: 0.8em;
+	padding: 1px 6px;
+	border-radius: 8px;
+	background: #e9ecef;
+}
+
+.badge.add { background: #d4edda; }
+.badge.modify { background: #fff3cd; }
+.badge.delete { background: #f8d7da; }
+
+.workbook {
+	background: white;
+	border: 1px solid #dee2e6;
+	border-radius: 6px;
+	padding: 15px 20px;
+	margin-bottom: 20px;
+}
+
+.tabs {
+	border-bottom: 1px solid #dee2e6;
+	margin-bottom: 10px;
+}
+
+.tab {
+	border: 1px solid transparent;
+	border-bottom: none;
+	background: none;
+	padding: 6px 12px;
+	cursor: pointer;
+	border-radius: 4px 4px 0 0;
+}
+
+.tab.active {
+	border-color: #dee2e6;
+	background: #f8f9fa;
+	font-weight: bold;
+}
+
+.tab.add { color: #28a745; }
+.tab.delete { color: #dc3545; }
+
+.sheet-panel { display: none; }
+.sheet-panel.active { display: block; }
+
+.grid-pair {
+	display: flex;
+	gap: 16px;
+}
+
+.grid-side {
+	flex: 1;
+	min-width: 0;
+}
+
+.grid-scroll {
+	overflow: auto;
+	max-height: 70vh;
+	border: 1px solid #dee2e6;
+}
+
+table.grid {
+	border-collapse: collapse;
+	font-family: 'Consolas', 'Menlo', monospace;
+	font-size: 12px;
+}
+
+table.grid th, table.grid td {
+	border: 1px solid #e2e3e5;
+	padding: 2px 6px;
+	min-width: 48px;
+	height: 18px;
+	white-space: nowrap;
+}
+
+table.grid th {
+	background: #f1f3f5;
+	color: #495057;
+	position: sticky;
+	top: 0;
+}
+
+table.grid tbody th {
+	position: sticky;
+	left: 0;
+}
+
+table.grid td[title] { cursor: help; }
+
+.cell-add { background: #d4edda; }
+.cell-modify { background: #fff3cd; }
+.cell-delete { background: #f8d7da; text-decoration: line-through; }
+.cell-recalc { background: #e2e3e5; color: #6c757d; }
+.cell-ghost { background: repeating-linear-gradient(45deg, #fff, #fff 4px, #f1f3f5 4px, #f1f3f5 8px); }
+
+.missing, .note {
+	color: #6c757d;
+	font-style: italic;
+	padding: 8px;
+}
+`
+}
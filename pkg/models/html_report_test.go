@@ -0,0 +1,124 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCellRef(t *testing.T) {
+	tests := []struct {
+		ref      string
+		col, row int
+		ok       bool
+	}{
+		{"A1", 1, 1, true},
+		{"$B$12", 2, 12, true},
+		{"z3", 26, 3, true},
+		{"AA10", 27, 10, true},
+		{"A", 0, 0, false},
+		{"12", 0, 0, false},
+		{"A0", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			col, row, ok := ParseCellRef(tt.ref)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.col, col)
+			assert.Equal(t, tt.row, row)
+		})
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", ColumnName(1))
+	assert.Equal(t, "Z", ColumnName(26))
+	assert.Equal(t, "AA", ColumnName(27))
+	assert.Equal(t, "AZ", ColumnName(52))
+	assert.Equal(t, "BA", ColumnName(53))
+}
+
+func TestGenerateHTMLReport(t *testing.T) {
+	oldDoc := createTestDocument()
+	oldDoc.Sheets[0].Cells["B2"] = Cell{Value: 10, Formula: "=SUM(C1:C3)", Type: CellTypeFormula}
+	newDoc := createTestDocument()
+	newDoc.Sheets[0].Cells["A1"] = Cell{Value: "<Changed>", Type: CellTypeString}
+	newDoc.Sheets[0].Cells["B2"] = Cell{Value: 12, Formula: "=SUM(C1:C4)", Type: CellTypeFormula}
+	newDoc.Sheets = append(newDoc.Sheets, Sheet{Name: "New", Cells: map[string]Cell{"C3": {Value: "x", Type: CellTypeString}}})
+
+	report := HTMLReport{
+		OldLabel: "v1",
+		NewLabel: "v2",
+		Workbooks: []ReportWorkbook{
+			{Path: "budget.xlsx", Old: oldDoc, New: newDoc, Diff: ComputeDiff(oldDoc, newDoc)},
+			{Path: "same.xlsx", Old: createTestDocument(), New: createTestDocument(), Diff: ComputeDiff(createTestDocument(), createTestDocument())},
+			{Path: "broken.xlsx", Error: "corrupt chunk"},
+		},
+	}
+
+	output := GenerateHTMLReport(report)
+
+	t.Run("is self-contained", func(t *testing.T) {
+		assert.True(t, strings.HasPrefix(output, "<!DOCTYPE html>"))
+		assert.Contains(t, output, "<style>")
+		assert.Contains(t, output, "<script>")
+		assert.NotContains(t, output, "<link")
+		assert.NotContains(t, output, "src=")
+	})
+
+	t.Run("renders index and tabs", func(t *testing.T) {
+		assert.Contains(t, output, "<a href='#wb-0'>budget.xlsx</a>")
+		assert.Contains(t, output, "same.xlsx <span class='badge'>unchanged</span>")
+		assert.Contains(t, output, "failed: corrupt chunk")
+		assert.Contains(t, output, "data-target='wb-0-sheet-0'>Test Sheet")
+		assert.Contains(t, output, "<button class='tab add' data-target='wb-0-sheet-1'>New")
+		assert.NotContains(t, output, "id='wb-1'")
+	})
+
+	t.Run("renders grids side by side", func(t *testing.T) {
+		assert.Equal(t, 2, strings.Count(output, "<div class='grid-side'><h4>v1</h4>"))
+		assert.Contains(t, output, "<td class='cell-modify' title='Changed value: Test Value → &lt;Changed&gt;'>&lt;Changed&gt;</td>")
+		assert.Contains(t, output, "title='B2: =SUM(C1:C4)")
+		assert.Contains(t, output, "<p class='missing'>Sheet did not exist</p>")
+		assert.Contains(t, output, "<td class='cell-add' title='New cell in added sheet'>x</td>")
+	})
+}
+
+func TestReportBounds(t *testing.T) {
+	t.Run("small sheet starts at A1", func(t *testing.T) {
+		sheet := &Sheet{Cells: map[string]Cell{"C4": {Value: 1}}}
+		bounds, truncated := reportBounds(sheet, nil, nil)
+		assert.False(t, truncated)
+		assert.Equal(t, gridBounds{minRow: 1, maxRow: 4, minCol: 1, maxCol: 3}, bounds)
+	})
+
+	t.Run("large sheet is limited to changes with context", func(t *testing.T) {
+		sheet := &Sheet{Cells: map[string]Cell{"A1": {Value: 1}, "A2000": {Value: 2}, "E1000": {Value: 3}}}
+		changes := []CellChange{{Cell: "E1000", Type: ChangeTypeModify}}
+		bounds, truncated := reportBounds(sheet, sheet, changes)
+		require.True(t, truncated)
+		assert.Equal(t, gridBounds{minRow: 998, maxRow: 1002, minCol: 3, maxCol: 7}, bounds)
+	})
+}
+
+func TestBatchDiff_ToHTMLReport(t *testing.T) {
+	batch := NewBatchDiff("v1", "v2")
+	batch.KeepDocuments = true
+
+	newDoc := createTestDocument()
+	newDoc.Sheets[0].Cells["A1"] = Cell{Value: "Changed", Type: CellTypeString}
+	batch.AddComparison("a.xlsx", createTestDocument(), newDoc, ComputeDiff(createTestDocument(), newDoc))
+	batch.AddComparison("b.xlsx", nil, createTestDocument(), ComputeDiff(&ExcelDocument{}, createTestDocument()))
+
+	require.Len(t, batch.Files, 2)
+	assert.NotNil(t, batch.Files[0].Old)
+	assert.Nil(t, batch.Files[1].Old)
+	assert.Equal(t, ChangeTypeAdd, batch.Files[1].Status)
+
+	output := batch.ToHTMLReport()
+	assert.Contains(t, output, "<td class='cell-modify' title='Changed value: Test Value → Changed'>Changed</td>")
+	assert.Contains(t, output, "<span class='badge add'>add</span>")
+}
@@ -0,0 +1,423 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PatchFormatVersion is the version written to, and accepted from, patch files
+const PatchFormatVersion = "1"
+
+// Patch is a serializable set of workbook changes that can be applied to
+// another copy of the workbook. Every edit records the content it expects to
+// replace so that changes made to the target in the meantime are detected
+// instead of being overwritten.
+type Patch struct {
+	Version string      `json:"version"`
+	Created time.Time   `json:"created"`
+	Source  string      `json:"source,omitempty"`
+	Target  string      `json:"target,omitempty"`
+	Hunks   []PatchHunk `json:"hunks"`
+}
+
+// PatchHunk groups the changes to a single sheet. Action is add or delete
+// when the whole sheet is created or removed, and modify otherwise.
+type PatchHunk struct {
+	Sheet     string          `json:"sheet"`
+	Action    ChangeType      `json:"action"`
+	Cells     []PatchCell     `json:"cells,omitempty"`
+	Structure *PatchStructure `json:"structure,omitempty"`
+}
+
+// PatchCell is a single cell edit. Old is the expected current content and
+// New the replacement; either is nil when the cell is absent on that side.
+type PatchCell struct {
+	Cell string     `json:"cell"`
+	Type ChangeType `json:"type"`
+	Old  *Cell      `json:"old,omitempty"`
+	New  *Cell      `json:"new,omitempty"`
+}
+
+// PatchStructure records a change to the layout of a sheet
+type PatchStructure struct {
+	Old SheetLayout `json:"old"`
+	New SheetLayout `json:"new"`
+}
+
+// SheetLayout is the structural part of a sheet that a patch can change
+type SheetLayout struct {
+	MergedCells  []MergedCell       `json:"merged_cells,omitempty"`
+	RowHeights   map[int]float64    `json:"row_heights,omitempty"`
+	ColumnWidths map[string]float64 `json:"column_widths,omitempty"`
+	Hidden       bool               `json:"hidden,omitempty"`
+}
+
+// PatchResult reports the outcome of applying a patch
+type PatchResult struct {
+	Applied  int              `json:"applied"`
+	Skipped  int              `json:"skipped"`
+	Rejected []PatchRejection `json:"rejected,omitempty"`
+}
+
+// PatchRejection describes a hunk or cell edit that did not match the
+// target. Cell is empty when a whole hunk or its structure was rejected.
+type PatchRejection struct {
+	Sheet  string    `json:"sheet"`
+	Cell   string    `json:"cell,omitempty"`
+	Reason string    `json:"reason"`
+	Hunk   PatchHunk `json:"hunk"`
+}
+
+// NewPatch builds a patch that turns oldDoc into newDoc. The cell edits are
+// taken from diff, so any filtering applied to the diff carries over; full
+// cell content is read from the documents. Hunks and cells are sorted so
+// that patches are stable across runs.
+func NewPatch(oldDoc, newDoc *ExcelDocument, diff *ExcelDiff) *Patch {
+	patch := &Patch{
+		Version: PatchFormatVersion,
+		Created: time.Now(),
+		Hunks:   []PatchHunk{},
+	}
+	if oldDoc != nil {
+		patch.Source = oldDoc.Metadata.OriginalFile
+	}
+	if newDoc != nil {
+		patch.Target = newDoc.Metadata.OriginalFile
+	}
+
+	hunks := make(map[string]*PatchHunk)
+	if diff != nil {
+		for _, sd := range diff.SheetDiffs {
+			oldSheet, newSheet := findSheet(oldDoc, sd.SheetName), findSheet(newDoc, sd.SheetName)
+			hunk := &PatchHunk{Sheet: sd.SheetName, Action: sd.Action}
+			if hunk.Action == "" {
+				hunk.Action = ChangeTypeModify
+			}
+
+			for _, change := range sd.Changes {
+				hunk.Cells = append(hunk.Cells, PatchCell{
+					Cell: change.Cell,
+					Type: change.Type,
+					Old:  lookupCell(oldSheet, change.Cell),
+					New:  lookupCell(newSheet, change.Cell),
+				})
+			}
+			sortPatchCells(hunk.Cells)
+
+			if hunk.Action == ChangeTypeAdd && newSheet != nil {
+				layout := sheetLayout(newSheet)
+				hunk.Structure = &PatchStructure{New: layout}
+			}
+			hunks[sd.SheetName] = hunk
+		}
+	}
+
+	// Layout changes are not part of ExcelDiff, so compare sheets that
+	// exist on both sides directly
+	if oldDoc != nil && newDoc != nil {
+		for i := range newDoc.Sheets {
+			newSheet := &newDoc.Sheets[i]
+			oldSheet := findSheet(oldDoc, newSheet.Name)
+			if oldSheet == nil {
+				continue
+			}
+			oldLayout, newLayout := sheetLayout(oldSheet), sheetLayout(newSheet)
+			if layoutsEqual(oldLayout, newLayout) {
+				continue
+			}
+			hunk, ok := hunks[newSheet.Name]
+			if !ok {
+				hunk = &PatchHunk{Sheet: newSheet.Name, Action: ChangeTypeModify}
+				hunks[newSheet.Name] = hunk
+			}
+			hunk.Structure = &PatchStructure{Old: oldLayout, New: newLayout}
+		}
+	}
+
+	names := make([]string, 0, len(hunks))
+	for name := range hunks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		patch.Hunks = append(patch.Hunks, *hunks[name])
+	}
+
+	return patch
+}
+
+// IsEmpty returns true if the patch contains no changes
+func (p *Patch) IsEmpty() bool {
+	return len(p.Hunks) == 0
+}
+
+// String returns a one-line summary of the patch
+func (p *Patch) String() string {
+	if p.IsEmpty() {
+		return "Empty patch"
+	}
+	cells := 0
+	for _, hunk := range p.Hunks {
+		cells += len(hunk.Cells)
+	}
+	return fmt.Sprintf("%d hunk(s), %d cell edit(s)", len(p.Hunks), cells)
+}
+
+// HasRejections returns true if any part of the patch did not apply
+func (r *PatchResult) HasRejections() bool {
+	return len(r.Rejected) > 0
+}
+
+// String returns a one-line summary of the result
+func (r *PatchResult) String() string {
+	return fmt.Sprintf("%d applied, %d already applied, %d rejected", r.Applied, r.Skipped, len(r.Rejected))
+}
+
+// ApplyPatch applies a patch to doc in place. Edits whose expected content
+// does not match the document are rejected and reported; edits whose
+// result is already present are skipped. Everything else is applied.
+func ApplyPatch(doc *ExcelDocument, patch *Patch) *PatchResult {
+	result := &PatchResult{}
+	for _, hunk := range patch.Hunks {
+		switch hunk.Action {
+		case ChangeTypeAdd:
+			applyAddSheet(doc, hunk, result)
+		case ChangeTypeDelete:
+			applyDeleteSheet(doc, hunk, result)
+		default:
+			applyModifySheet(doc, hunk, result)
+		}
+	}
+	return result
+}
+
+// applyAddSheet creates the sheet of an add hunk
+func applyAddSheet(doc *ExcelDocument, hunk PatchHunk, result *PatchResult) {
+	if existing := findSheet(doc, hunk.Sheet); existing != nil {
+		for _, edit := range hunk.Cells {
+			if !cellsMatch(lookupCell(existing, edit.Cell), edit.New) {
+				result.reject(hunk, "", "sheet already exists with different content")
+				return
+			}
+		}
+		result.Skipped++
+		return
+	}
+
+	sheet := Sheet{Name: hunk.Sheet, Index: len(doc.Sheets), Cells: make(map[string]Cell, len(hunk.Cells))}
+	for _, edit := range hunk.Cells {
+		if edit.New != nil {
+			sheet.Cells[edit.Cell] = *edit.New
+		}
+	}
+	if hunk.Structure != nil {
+		setSheetLayout(&sheet, hunk.Structure.New)
+	}
+	doc.Sheets = append(doc.Sheets, sheet)
+	result.Applied++
+}
+
+// applyDeleteSheet removes the sheet of a delete hunk if it still holds
+// exactly the content recorded in the patch
+func applyDeleteSheet(doc *ExcelDocument, hunk PatchHunk, result *PatchResult) {
+	sheet := findSheet(doc, hunk.Sheet)
+	if sheet == nil {
+		result.Skipped++
+		return
+	}
+
+	expected := make(map[string]bool, len(hunk.Cells))
+	for _, edit := range hunk.Cells {
+		expected[edit.Cell] = true
+		if !cellsMatch(lookupCell(sheet, edit.Cell), edit.Old) {
+			result.reject(hunk, "", fmt.Sprintf("sheet has changed since the patch was created (cell %s)", edit.Cell))
+			return
+		}
+	}
+	for ref := range sheet.Cells {
+		if !expected[ref] {
+			result.reject(hunk, "", fmt.Sprintf("sheet has changed since the patch was created (cell %s)", ref))
+			return
+		}
+	}
+
+	sheets := make([]Sheet, 0, len(doc.Sheets)-1)
+	for _, s := range doc.Sheets {
+		if s.Name != hunk.Sheet {
+			s.Index = len(sheets)
+			sheets = append(sheets, s)
+		}
+	}
+	doc.Sheets = sheets
+	result.Applied++
+}
+
+// applyModifySheet applies the cell edits and layout change of a modify hunk
+func applyModifySheet(doc *ExcelDocument, hunk PatchHunk, result *PatchResult) {
+	sheet := findSheet(doc, hunk.Sheet)
+	if sheet == nil {
+		result.reject(hunk, "", "sheet not found")
+		return
+	}
+	if sheet.Cells == nil {
+		sheet.Cells = make(map[string]Cell)
+	}
+
+	for _, edit := range hunk.Cells {
+		current := lookupCell(sheet, edit.Cell)
+		switch {
+		case cellsMatch(current, edit.New):
+			result.Skipped++
+		case cellsMatch(current, edit.Old):
+			if edit.New == nil {
+				delete(sheet.Cells, edit.Cell)
+			} else {
+				sheet.Cells[edit.Cell] = *edit.New
+			}
+			result.Applied++
+		default:
+			rejected := hunk
+			rejected.Cells = []PatchCell{edit}
+			rejected.Structure = nil
+			result.reject(rejected, edit.Cell, fmt.Sprintf("expected %s, found %s", describePatchCell(edit.Old), describePatchCell(current)))
+		}
+	}
+
+	if hunk.Structure != nil {
+		current := sheetLayout(sheet)
+		switch {
+		case layoutsEqual(current, hunk.Structure.New):
+			result.Skipped++
+		case layoutsEqual(current, hunk.Structure.Old):
+			setSheetLayout(sheet, hunk.Structure.New)
+			result.Applied++
+		default:
+			rejected := PatchHunk{Sheet: hunk.Sheet, Action: hunk.Action, Structure: hunk.Structure}
+			result.reject(rejected, "", "sheet layout has changed since the patch was created")
+		}
+	}
+}
+
+// reject records a rejected hunk or cell edit
+func (r *PatchResult) reject(hunk PatchHunk, cell, reason string) {
+	r.Rejected = append(r.Rejected, PatchRejection{Sheet: hunk.Sheet, Cell: cell, Reason: reason, Hunk: hunk})
+}
+
+// RejectedPatch returns a patch holding only the rejected hunks, suitable
+// for saving alongside the original for manual resolution
+func (r *PatchResult) RejectedPatch() *Patch {
+	patch := &Patch{Version: PatchFormatVersion, Created: time.Now(), Hunks: make([]PatchHunk, 0, len(r.Rejected))}
+	for _, rejection := range r.Rejected {
+		patch.Hunks = append(patch.Hunks, rejection.Hunk)
+	}
+	return patch
+}
+
+// cellsMatch compares the content of two cells for context checking: what
+// a diff reports as a change, that is the value, formula, type, comment and
+// hyperlink. Values are compared by their printed form so that numbers
+// survive a JSON round trip, and formulas with or without a leading '='.
+// Styles are not part of the context.
+func cellsMatch(a, b *Cell) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return normalizeFormula(a.Formula) == normalizeFormula(b.Formula) &&
+		formatReportValue(a.Value) == formatReportValue(b.Value) &&
+		a.Type == b.Type &&
+		commentText(a.Comment) == commentText(b.Comment) &&
+		(a.Comment == nil) == (b.Comment == nil) &&
+		a.Hyperlink == b.Hyperlink
+}
+
+// commentText returns the text of a comment, or "" for none
+func commentText(comment *Comment) string {
+	if comment == nil {
+		return ""
+	}
+	return comment.Text
+}
+
+// normalizeFormula strips the optional leading '=' that the converter keeps
+// for some formulas but not others
+func normalizeFormula(formula string) string {
+	return strings.TrimPrefix(strings.TrimSpace(formula), "=")
+}
+
+// describePatchCell renders a cell for rejection messages
+func describePatchCell(cell *Cell) string {
+	switch {
+	case cell == nil:
+		return "empty cell"
+	case cell.Formula != "":
+		return fmt.Sprintf("%q (%s)", formatReportValue(cell.Value), cell.Formula)
+	default:
+		return fmt.Sprintf("%q", formatReportValue(cell.Value))
+	}
+}
+
+// lookupCell returns a copy of the cell at ref, or nil if absent
+func lookupCell(sheet *Sheet, ref string) *Cell {
+	if sheet == nil {
+		return nil
+	}
+	cell, ok := sheet.Cells[ref]
+	if !ok {
+		return nil
+	}
+	return &cell
+}
+
+// sheetLayout extracts the structural part of a sheet
+func sheetLayout(sheet *Sheet) SheetLayout {
+	return SheetLayout{
+		MergedCells:  sheet.MergedCells,
+		RowHeights:   sheet.RowHeights,
+		ColumnWidths: sheet.ColumnWidths,
+		Hidden:       sheet.Hidden,
+	}
+}
+
+// setSheetLayout replaces the structural part of a sheet
+func setSheetLayout(sheet *Sheet, layout SheetLayout) {
+	sheet.MergedCells = layout.MergedCells
+	sheet.RowHeights = layout.RowHeights
+	sheet.ColumnWidths = layout.ColumnWidths
+	sheet.Hidden = layout.Hidden
+}
+
+// layoutsEqual compares two layouts, treating nil and empty collections alike
+func layoutsEqual(a, b SheetLayout) bool {
+	if a.Hidden != b.Hidden || len(a.MergedCells) != len(b.MergedCells) ||
+		len(a.RowHeights) != len(b.RowHeights) || len(a.ColumnWidths) != len(b.ColumnWidths) {
+		return false
+	}
+	if len(a.MergedCells) > 0 && !reflect.DeepEqual(a.MergedCells, b.MergedCells) {
+		return false
+	}
+	if len(a.RowHeights) > 0 && !reflect.DeepEqual(a.RowHeights, b.RowHeights) {
+		return false
+	}
+	if len(a.ColumnWidths) > 0 && !reflect.DeepEqual(a.ColumnWidths, b.ColumnWidths) {
+		return false
+	}
+	return true
+}
+
+// sortPatchCells orders cell edits row by row
+func sortPatchCells(cells []PatchCell) {
+	sort.SliceStable(cells, func(i, j int) bool {
+		ci, ri, okI := ParseCellRef(cells[i].Cell)
+		cj, rj, okJ := ParseCellRef(cells[j].Cell)
+		if !okI || !okJ {
+			return strings.Compare(cells[i].Cell, cells[j].Cell) < 0
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return ci < cj
+	})
+}
@@ -0,0 +1,197 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createPatchDocuments() (*ExcelDocument, *ExcelDocument) {
+	oldDoc := createTestDocument()
+	oldDoc.Sheets[0].Cells["B2"] = Cell{Value: 10, Type: CellTypeNumber}
+	oldDoc.Sheets[0].Cells["C3"] = Cell{Value: "remove me", Type: CellTypeString}
+	oldDoc.Sheets = append(oldDoc.Sheets, Sheet{Name: "Old", Index: 1, Cells: map[string]Cell{"A1": {Value: "x", Type: CellTypeString}}})
+
+	newDoc := createTestDocument()
+	newDoc.Sheets[0].Cells["A1"] = Cell{Value: "Updated", Type: CellTypeString}
+	newDoc.Sheets[0].Cells["B2"] = Cell{Value: 20, Formula: "=A5*2", Type: CellTypeFormula}
+	newDoc.Sheets[0].ColumnWidths = map[string]float64{"A": 30}
+	newDoc.Sheets = append(newDoc.Sheets, Sheet{Name: "New", Index: 1, Cells: map[string]Cell{"B1": {Value: 1.5, Type: CellTypeNumber}}})
+
+	return oldDoc, newDoc
+}
+
+func TestNewPatch(t *testing.T) {
+	oldDoc, newDoc := createPatchDocuments()
+	patch := NewPatch(oldDoc, newDoc, ComputeDiff(oldDoc, newDoc))
+
+	assert.Equal(t, PatchFormatVersion, patch.Version)
+	require.Len(t, patch.Hunks, 3)
+
+	// Hunks are sorted by sheet name
+	assert.Equal(t, "New", patch.Hunks[0].Sheet)
+	assert.Equal(t, ChangeTypeAdd, patch.Hunks[0].Action)
+	assert.Equal(t, "Old", patch.Hunks[1].Sheet)
+	assert.Equal(t, ChangeTypeDelete, patch.Hunks[1].Action)
+
+	modified := patch.Hunks[2]
+	assert.Equal(t, "Test Sheet", modified.Sheet)
+	assert.Equal(t, ChangeTypeModify, modified.Action)
+	require.Len(t, modified.Cells, 3)
+	assert.Equal(t, []string{"A1", "B2", "C3"}, []string{modified.Cells[0].Cell, modified.Cells[1].Cell, modified.Cells[2].Cell})
+	assert.Equal(t, "Test Value", modified.Cells[0].Old.Value)
+	assert.Equal(t, "=A5*2", modified.Cells[1].New.Formula)
+	assert.Nil(t, modified.Cells[2].New)
+
+	require.NotNil(t, modified.Structure)
+	assert.Equal(t, 30.0, modified.Structure.New.ColumnWidths["A"])
+
+	assert.Equal(t, "3 hunk(s), 5 cell edit(s)", patch.String())
+}
+
+func TestApplyPatch(t *testing.T) {
+	oldDoc, newDoc := createPatchDocuments()
+	patch := NewPatch(oldDoc, newDoc, ComputeDiff(oldDoc, newDoc))
+
+	// Round-trip through JSON like a patch file would
+	data, err := json.Marshal(patch)
+	require.NoError(t, err)
+	var loaded Patch
+	require.NoError(t, json.Unmarshal(data, &loaded))
+
+	t.Run("applies cleanly to the original", func(t *testing.T) {
+		target, _ := createPatchDocuments()
+		result := ApplyPatch(target, &loaded)
+
+		assert.False(t, result.HasRejections())
+		assert.Equal(t, 6, result.Applied)
+		require.Len(t, target.Sheets, 2)
+		assert.Equal(t, "New", target.Sheets[1].Name)
+		assert.EqualValues(t, 1.5, target.Sheets[1].Cells["B1"].Value)
+		assert.Equal(t, "Updated", target.Sheets[0].Cells["A1"].Value)
+		assert.Equal(t, "=A5*2", target.Sheets[0].Cells["B2"].Formula)
+		assert.NotContains(t, target.Sheets[0].Cells, "C3")
+		assert.Equal(t, 30.0, target.Sheets[0].ColumnWidths["A"])
+	})
+
+	t.Run("reapplying is a no-op", func(t *testing.T) {
+		target, _ := createPatchDocuments()
+		ApplyPatch(target, &loaded)
+		result := ApplyPatch(target, &loaded)
+
+		assert.False(t, result.HasRejections())
+		assert.Zero(t, result.Applied)
+		assert.Equal(t, 6, result.Skipped)
+	})
+
+	t.Run("rejects edits whose context changed", func(t *testing.T) {
+		target, _ := createPatchDocuments()
+		target.Sheets[0].Cells["A1"] = Cell{Value: "Someone else", Type: CellTypeString}
+		target.Sheets[1].Cells["A2"] = Cell{Value: "extra", Type: CellTypeString}
+
+		result := ApplyPatch(target, &loaded)
+
+		require.Len(t, result.Rejected, 2)
+		assert.Equal(t, "Old", result.Rejected[0].Sheet)
+		assert.Contains(t, result.Rejected[0].Reason, "sheet has changed")
+		assert.Equal(t, "A1", result.Rejected[1].Cell)
+		assert.Equal(t, `expected "Test Value", found "Someone else"`, result.Rejected[1].Reason)
+
+		// The remaining edits still apply
+		assert.EqualValues(t, 20, target.Sheets[0].Cells["B2"].Value)
+		assert.Equal(t, "Someone else", target.Sheets[0].Cells["A1"].Value)
+
+		rejected := result.RejectedPatch()
+		require.Len(t, rejected.Hunks, 2)
+		assert.Len(t, rejected.Hunks[1].Cells, 1)
+	})
+
+	t.Run("formula context ignores the leading equals sign", func(t *testing.T) {
+		target, _ := createPatchDocuments()
+		target.Sheets[0].Cells["B2"] = Cell{Value: 20, Formula: "A5*2", Type: CellTypeFormula}
+
+		result := ApplyPatch(target, &loaded)
+
+		assert.False(t, result.HasRejections())
+		assert.Equal(t, "A5*2", target.Sheets[0].Cells["B2"].Formula)
+	})
+
+	t.Run("rejects hunks for missing sheets", func(t *testing.T) {
+		target := &ExcelDocument{}
+		result := ApplyPatch(target, &loaded)
+
+		require.NotEmpty(t, result.Rejected)
+		assert.Equal(t, "sheet not found", result.Rejected[len(result.Rejected)-1].Reason)
+	})
+}
+
+func TestApplyPatchCellDetails(t *testing.T) {
+	base := func() *ExcelDocument {
+		doc := createTestDocument()
+		doc.Sheets[0].Cells["B2"] = Cell{Value: 10, Type: CellTypeNumber}
+		return doc
+	}
+	apply := func(t *testing.T, change func(cell *Cell)) (*ExcelDocument, PatchResult) {
+		oldDoc, newDoc := base(), base()
+		cell := newDoc.Sheets[0].Cells["B2"]
+		change(&cell)
+		newDoc.Sheets[0].Cells["B2"] = cell
+
+		patch := NewPatch(oldDoc, newDoc, ComputeDiff(oldDoc, newDoc))
+		require.Len(t, patch.Hunks, 1)
+		data, err := json.Marshal(patch)
+		require.NoError(t, err)
+		var loaded Patch
+		require.NoError(t, json.Unmarshal(data, &loaded))
+
+		target := base()
+		result := ApplyPatch(target, &loaded)
+		return target, *result
+	}
+
+	t.Run("applies a comment-only edit", func(t *testing.T) {
+		target, result := apply(t, func(cell *Cell) {
+			cell.Comment = &Comment{Author: "Ana", Text: "check this"}
+		})
+
+		assert.False(t, result.HasRejections())
+		assert.Equal(t, 1, result.Applied)
+		require.NotNil(t, target.Sheets[0].Cells["B2"].Comment)
+		assert.Equal(t, "check this", target.Sheets[0].Cells["B2"].Comment.Text)
+	})
+
+	t.Run("applies a hyperlink-only edit", func(t *testing.T) {
+		target, result := apply(t, func(cell *Cell) {
+			cell.Hyperlink = "https://example.com/report"
+		})
+
+		assert.False(t, result.HasRejections())
+		assert.Equal(t, 1, result.Applied)
+		assert.Equal(t, "https://example.com/report", target.Sheets[0].Cells["B2"].Hyperlink)
+	})
+
+	t.Run("applies a type-only edit", func(t *testing.T) {
+		target, result := apply(t, func(cell *Cell) {
+			cell.Type = CellTypeString
+		})
+
+		assert.False(t, result.HasRejections())
+		assert.Equal(t, 1, result.Applied)
+		assert.Equal(t, CellTypeString, target.Sheets[0].Cells["B2"].Type)
+	})
+
+	t.Run("rejects an edit whose comment changed since", func(t *testing.T) {
+		oldDoc, newDoc := base(), base()
+		newDoc.Sheets[0].Cells["B2"] = Cell{Value: 10, Type: CellTypeNumber, Hyperlink: "https://example.com"}
+		patch := NewPatch(oldDoc, newDoc, ComputeDiff(oldDoc, newDoc))
+
+		target := base()
+		target.Sheets[0].Cells["B2"] = Cell{Value: 10, Type: CellTypeNumber, Comment: &Comment{Text: "someone else's note"}}
+		result := ApplyPatch(target, patch)
+
+		assert.True(t, result.HasRejections())
+		assert.Empty(t, target.Sheets[0].Cells["B2"].Hyperlink)
+	})
+}